@@ -0,0 +1,198 @@
+package code
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ApplyPatch применяет дерево различий к документу base и возвращает новый документ:
+// добавленные ключи добавляются, удалённые удаляются, изменённые получают новое значение,
+// вложенные узлы обрабатываются рекурсивно. Если base не совпадает со старыми значениями
+// из дерева, возвращается ошибка. Исходный документ не изменяется.
+func ApplyPatch(base map[string]interface{}, tree *Node) (map[string]interface{}, error) {
+	return ApplyPatchWithOptions(base, tree, Options{})
+}
+
+// ApplyPatchWithOptions работает как ApplyPatch. Значения сравниваются с учётом opts,
+// а с opts.ForcePatch несовпадения не считаются ошибкой: новые значения записываются
+// поверх того, что есть в base.
+func ApplyPatchWithOptions(base map[string]interface{}, tree *Node, opts Options) (map[string]interface{}, error) {
+	p := &patcher{differ: newDiffer(opts), force: opts.ForcePatch}
+	result, _ := cloneValue(base).(map[string]interface{})
+	if result == nil {
+		result = make(map[string]interface{})
+	}
+	if err := p.applyMap(result, tree, nil); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// patcher применяет узлы дерева различий к документу
+type patcher struct {
+	differ *differ
+	force  bool
+}
+
+// applyMap применяет дочерние узлы node к карте target на месте
+func (p *patcher) applyMap(target map[string]interface{}, node *Node, nodePath []string) error {
+	for _, child := range node.Children {
+		childPath := appendPath(nodePath, child.name())
+		current, exists := target[child.Key]
+
+		switch child.Type {
+		case NodeTypeAdded:
+			if exists && !p.force {
+				return p.mismatch(childPath, "key to be absent", current)
+			}
+			target[child.Key] = cloneValue(child.NewValue)
+		case NodeTypeRemoved:
+			if err := p.check(childPath, exists, current, child.OldValue); err != nil {
+				return err
+			}
+			delete(target, child.Key)
+		case NodeTypeUpdated:
+			if err := p.check(childPath, exists, current, child.OldValue); err != nil {
+				return err
+			}
+			target[child.Key] = cloneValue(child.NewValue)
+		case NodeTypeUnchanged:
+			if err := p.check(childPath, exists, current, child.Value); err != nil {
+				return err
+			}
+		case NodeTypeNested:
+			value, err := p.applyNested(current, exists, child, childPath)
+			if err != nil {
+				return err
+			}
+			target[child.Key] = value
+		}
+	}
+	return nil
+}
+
+// applyNested применяет вложенный узел к значению current. Карты изменяются
+// рекурсивно, элементы массивов, сопоставленных по ArrayKeyFields, находятся по ключевому
+// полю, а массивы, описанные поэлементно, сверяются и заменяются целиком.
+func (p *patcher) applyNested(current interface{}, exists bool, node *Node, nodePath []string) (interface{}, error) {
+	if node.keyField != "" {
+		return p.applyKeyedArray(current, exists, node, nodePath)
+	}
+	if m, ok := current.(map[string]interface{}); ok && !isArrayNode(node) {
+		if err := p.applyMap(m, node, nodePath); err != nil {
+			return nil, err
+		}
+		return m, nil
+	}
+
+	if err := p.check(nodePath, exists, current, sideValue(node, true)); err != nil {
+		return nil, err
+	}
+	return sideValue(node, false), nil
+}
+
+// applyKeyedArray применяет узел массива объектов, сопоставленного по полю node.keyField.
+// Элементы находятся по значению поля, поэтому их порядок в current сохраняется,
+// а добавленные элементы дописываются в конец.
+func (p *patcher) applyKeyedArray(current interface{}, exists bool, node *Node, nodePath []string) (interface{}, error) {
+	items, ok := current.([]interface{})
+	if !ok {
+		if !p.force {
+			if !exists {
+				return nil, fmt.Errorf("cannot apply patch at %s: key is missing", strings.Join(nodePath, "."))
+			}
+			return nil, p.mismatch(nodePath, "array", current)
+		}
+		items = nil
+	}
+
+	result := make([]interface{}, 0, len(items))
+	positions := make(map[string]int, len(items))
+	for _, item := range items {
+		if object, ok := item.(map[string]interface{}); ok {
+			if value, exists := object[node.keyField]; exists {
+				positions[fmt.Sprintf("%v", value)] = len(result)
+			}
+		}
+		result = append(result, cloneValue(item))
+	}
+
+	removed := make(map[int]bool)
+	for _, child := range node.Children {
+		childPath := appendPath(nodePath, child.name())
+		pos, found := positions[child.Key]
+		var current interface{}
+		if found {
+			current = result[pos]
+		}
+
+		var value interface{}
+		switch child.Type {
+		case NodeTypeAdded:
+			if found && !p.force {
+				return nil, p.mismatch(childPath, "key to be absent", current)
+			}
+			value = cloneValue(child.NewValue)
+		case NodeTypeRemoved:
+			if err := p.check(childPath, found, current, child.OldValue); err != nil {
+				return nil, err
+			}
+			if found {
+				removed[pos] = true
+			}
+			continue
+		case NodeTypeUpdated:
+			if err := p.check(childPath, found, current, child.OldValue); err != nil {
+				return nil, err
+			}
+			value = cloneValue(child.NewValue)
+		case NodeTypeUnchanged:
+			if err := p.check(childPath, found, current, child.Value); err != nil {
+				return nil, err
+			}
+			continue
+		case NodeTypeNested:
+			nested, err := p.applyNested(current, found, child, childPath)
+			if err != nil {
+				return nil, err
+			}
+			value = nested
+		default:
+			continue
+		}
+
+		if found {
+			result[pos] = value
+		} else {
+			result = append(result, value)
+		}
+	}
+
+	kept := result[:0]
+	for i, item := range result {
+		if !removed[i] {
+			kept = append(kept, item)
+		}
+	}
+	return kept, nil
+}
+
+// check сверяет текущее значение документа со старым значением из дерева
+func (p *patcher) check(nodePath []string, exists bool, current, expected interface{}) error {
+	if p.force {
+		return nil
+	}
+	if !exists {
+		return fmt.Errorf("cannot apply patch at %s: key is missing", strings.Join(nodePath, "."))
+	}
+	if !p.differ.isEqual(current, expected) {
+		return p.mismatch(nodePath, formatPlainExpanded(expected), current)
+	}
+	return nil
+}
+
+// mismatch описывает расхождение документа с деревом различий
+func (p *patcher) mismatch(nodePath []string, expected string, current interface{}) error {
+	return fmt.Errorf("cannot apply patch at %s: expected %s, found %s",
+		strings.Join(nodePath, "."), expected, formatPlainExpanded(current))
+}
@@ -0,0 +1,116 @@
+package code
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyPatch_RoundTrip(t *testing.T) {
+	read := func(name string) map[string]interface{} {
+		content, err := os.ReadFile(filepath.Join("testdata", "fixture", name))
+		require.NoError(t, err)
+		data, err := parseJSON(content)
+		require.NoError(t, err)
+		return data
+	}
+	data1, data2 := read("file1.json"), read("file2.json")
+
+	patched, err := ApplyPatch(data1, BuildDiff(data1, data2))
+	require.NoError(t, err)
+	assert.Equal(t, data2, patched)
+	// The base document is left untouched
+	assert.Equal(t, read("file1.json"), data1)
+
+	t.Run("element-wise arrays", func(t *testing.T) {
+		a := map[string]interface{}{"hosts": []interface{}{"a", "b", "c"}, "port": 80.0}
+		b := map[string]interface{}{"hosts": []interface{}{"a", "x", "c", "d"}, "port": 80.0}
+		tree, _ := buildTree(a, b, Options{IndexArrays: true})
+
+		patched, err := ApplyPatch(a, tree)
+		require.NoError(t, err)
+		assert.Equal(t, b, patched)
+	})
+
+	t.Run("keyed arrays", func(t *testing.T) {
+		a := map[string]interface{}{"c": []interface{}{
+			map[string]interface{}{"name": "web", "image": "v1"},
+			map[string]interface{}{"name": "db", "image": "pg"},
+			map[string]interface{}{"name": "cache", "image": "redis"},
+		}}
+		b := map[string]interface{}{"c": []interface{}{
+			map[string]interface{}{"name": "db", "image": "pg"},
+			map[string]interface{}{"name": "web", "image": "v2"},
+			map[string]interface{}{"name": "proxy", "image": "nginx"},
+		}}
+		tree, _ := buildTree(a, b, Options{ArrayKeyFields: []string{"name"}})
+
+		// Elements are matched by name: base order is kept and new ones are appended
+		patched, err := ApplyPatch(a, tree)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]interface{}{"c": []interface{}{
+			map[string]interface{}{"name": "web", "image": "v2"},
+			map[string]interface{}{"name": "db", "image": "pg"},
+			map[string]interface{}{"name": "proxy", "image": "nginx"},
+		}}, patched)
+
+		again, _ := buildTree(patched, b, Options{ArrayKeyFields: []string{"name"}})
+		assert.False(t, again.HasChanges())
+
+		_, err = ApplyPatch(b, tree)
+		assert.EqualError(t, err, "cannot apply patch at c.cache: key is missing")
+	})
+}
+
+func TestApplyPatch_Mismatch(t *testing.T) {
+	a := map[string]interface{}{"host": "a", "db": map[string]interface{}{"user": "x", "pool": 5.0}}
+	b := map[string]interface{}{"host": "b", "db": map[string]interface{}{"user": "x"}, "debug": true}
+	tree := BuildDiff(a, b)
+
+	other := map[string]interface{}{"host": "c", "db": map[string]interface{}{"user": "x", "pool": 5.0}}
+	_, err := ApplyPatch(other, tree)
+	require.Error(t, err)
+	assert.Equal(t, "cannot apply patch at host: expected 'a', found 'c'", err.Error())
+
+	missing := map[string]interface{}{"host": "a", "db": map[string]interface{}{"user": "x"}}
+	_, err = ApplyPatch(missing, tree)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "cannot apply patch at db.pool: key is missing")
+
+	t.Run("force", func(t *testing.T) {
+		patched, err := ApplyPatchWithOptions(other, tree, Options{ForcePatch: true})
+		require.NoError(t, err)
+		assert.Equal(t, b, patched)
+	})
+}
+
+func TestInvertDiff(t *testing.T) {
+	a := map[string]interface{}{
+		"host": "a",
+		"db":   map[string]interface{}{"user": "x", "pool": 5.0},
+		"tags": []interface{}{"a", "b"},
+	}
+	b := map[string]interface{}{
+		"host":  "b",
+		"db":    map[string]interface{}{"user": "x", "ssl": true},
+		"tags":  []interface{}{"a", "c", "d"},
+		"debug": true,
+	}
+	tree, _ := buildTree(a, b, Options{IndexArrays: true})
+
+	inverted := InvertDiff(tree)
+	assert.Equal(t, NodeTypeRemoved, inverted.Children[1].Type)
+	assert.Equal(t, "debug", inverted.Children[1].Key)
+	assert.Equal(t, &Node{Type: NodeTypeUpdated, Key: "host", OldValue: "b", NewValue: "a", Path: []string{"host"}}, inverted.Children[2])
+
+	// Inverting twice gives back the original tree
+	assert.Equal(t, tree, InvertDiff(inverted))
+
+	// The inverted tree undoes the change
+	restored, err := ApplyPatch(b, inverted)
+	require.NoError(t, err)
+	assert.Equal(t, a, restored)
+}
@@ -0,0 +1,193 @@
+package code
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// isScalarSlice проверяет, является ли значение массивом без вложенных карт и массивов
+func isScalarSlice(v interface{}) bool {
+	items, ok := v.([]interface{})
+	if !ok {
+		return false
+	}
+	for _, item := range items {
+		switch item.(type) {
+		case map[string]interface{}, []interface{}:
+			return false
+		}
+	}
+	return true
+}
+
+// isSlice проверяет, является ли значение массивом
+func isSlice(v interface{}) bool {
+	_, ok := v.([]interface{})
+	return ok
+}
+
+// buildIndexArrayTree сравнивает элементы двух массивов с одинаковыми индексами.
+// Лишние элементы более длинного массива считаются добавленными или удалёнными.
+func (d *differ) buildIndexArrayTree(before, after []interface{}, path []string) *Node {
+	root := &Node{Type: NodeTypeRoot, Children: []*Node{}}
+	for i := 0; i < max(len(before), len(after)); i++ {
+		var node *Node
+		switch {
+		case i >= len(after):
+			d.changed = true
+			node = &Node{Type: NodeTypeRemoved, OldValue: before[i]}
+		case i >= len(before):
+			d.changed = true
+			node = &Node{Type: NodeTypeAdded, NewValue: after[i]}
+		default:
+			node = d.processExistingKey("", before[i], after[i], appendPath(path, strconv.Itoa(i)))
+		}
+		if node != nil {
+			node.Index = intPtr(i)
+			root.Children = append(root.Children, node)
+		}
+
+		if d.opts.StopAtFirstChange && d.changed {
+			break
+		}
+	}
+	return root
+}
+
+// buildSetArrayTree сравнивает массивы как множества. Повторы элемента учитываются
+// один раз; узлы удалённых и неизменённых элементов хранят индекс в исходном массиве,
+// добавленных — в новом.
+func (d *differ) buildSetArrayTree(before, after []interface{}) *Node {
+	root := &Node{Type: NodeTypeRoot, Children: []*Node{}}
+	for i, item := range before {
+		if d.containsEqual(before[:i], item) {
+			continue
+		}
+		if d.containsEqual(after, item) {
+			root.Children = append(root.Children, &Node{Type: NodeTypeUnchanged, Index: intPtr(i), Value: item})
+			continue
+		}
+		d.changed = true
+		root.Children = append(root.Children, &Node{Type: NodeTypeRemoved, Index: intPtr(i), OldValue: item})
+	}
+	for j, item := range after {
+		if d.containsEqual(after[:j], item) || d.containsEqual(before, item) {
+			continue
+		}
+		d.changed = true
+		root.Children = append(root.Children, &Node{Type: NodeTypeAdded, Index: intPtr(j), NewValue: item})
+	}
+	return root
+}
+
+// containsEqual проверяет, есть ли в массиве элемент, равный item с учётом параметров сравнения
+func (d *differ) containsEqual(items []interface{}, item interface{}) bool {
+	for _, candidate := range items {
+		if d.isEqual(candidate, item) {
+			return true
+		}
+	}
+	return false
+}
+
+// buildLCSArrayTree строит дерево различий двух массивов скаляров по наибольшей общей
+// подпоследовательности. Узлы элементов хранят индекс: для удалённых — в исходном
+// массиве, для добавленных и неизменённых — в новом.
+func (d *differ) buildLCSArrayTree(before, after []interface{}) *Node {
+	root := &Node{Type: NodeTypeRoot, Children: []*Node{}}
+
+	// lengths[i][j] — длина общей подпоследовательности суффиксов before[i:] и after[j:]
+	lengths := make([][]int, len(before)+1)
+	for i := range lengths {
+		lengths[i] = make([]int, len(after)+1)
+	}
+	for i := len(before) - 1; i >= 0; i-- {
+		for j := len(after) - 1; j >= 0; j-- {
+			if d.isEqual(before[i], after[j]) {
+				lengths[i][j] = lengths[i+1][j+1] + 1
+			} else {
+				lengths[i][j] = max(lengths[i+1][j], lengths[i][j+1])
+			}
+		}
+	}
+
+	// Восстанавливаем последовательность правок
+	i, j := 0, 0
+	for i < len(before) || j < len(after) {
+		switch {
+		case i < len(before) && j < len(after) && d.isEqual(before[i], after[j]):
+			root.Children = append(root.Children, &Node{Type: NodeTypeUnchanged, Index: intPtr(j), Value: after[j]})
+			i++
+			j++
+		case i < len(before) && (j == len(after) || lengths[i+1][j] >= lengths[i][j+1]):
+			d.changed = true
+			root.Children = append(root.Children, &Node{Type: NodeTypeRemoved, Index: intPtr(i), OldValue: before[i]})
+			i++
+		default:
+			d.changed = true
+			root.Children = append(root.Children, &Node{Type: NodeTypeAdded, Index: intPtr(j), NewValue: after[j]})
+			j++
+		}
+
+		if d.opts.StopAtFirstChange && d.changed {
+			break
+		}
+	}
+
+	return root
+}
+
+// intPtr возвращает указатель на копию числа
+func intPtr(v int) *int {
+	return &v
+}
+
+// arrayKeyField возвращает первое из opts.ArrayKeyFields, по которому можно однозначно
+// сопоставить элементы двух массивов объектов
+func (d *differ) arrayKeyField(a, b interface{}) (string, bool) {
+	if len(d.opts.ArrayKeyFields) == 0 {
+		return "", false
+	}
+	itemsA, okA := a.([]interface{})
+	itemsB, okB := b.([]interface{})
+	if !okA || !okB {
+		return "", false
+	}
+
+	for _, field := range d.opts.ArrayKeyFields {
+		if keyedItems(itemsA, field) != nil && keyedItems(itemsB, field) != nil {
+			return field, true
+		}
+	}
+	return "", false
+}
+
+// keyedItems превращает массив объектов в карту по значению поля field. Возвращает nil,
+// если какой-либо элемент не является объектом, не содержит поле или значения повторяются.
+func keyedItems(items []interface{}, field string) map[string]interface{} {
+	result := make(map[string]interface{}, len(items))
+	for _, item := range items {
+		object, ok := item.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		value, exists := object[field]
+		if !exists || isMap(value) {
+			return nil
+		}
+		key := fmt.Sprintf("%v", value)
+		if _, duplicate := result[key]; duplicate {
+			return nil
+		}
+		result[key] = item
+	}
+	return result
+}
+
+// buildKeyedArrayTree сравнивает массивы объектов как карты, ключами которых служат
+// значения поля field, так что перестановка элементов не считается изменением
+func (d *differ) buildKeyedArrayTree(before, after []interface{}, field string, path []string) *Node {
+	node := d.buildDiffTree(keyedItems(before, field), keyedItems(after, field), path)
+	node.keyField = field
+	return node
+}
@@ -0,0 +1,172 @@
+package code
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildLCSArrayTree_InsertAtFront(t *testing.T) {
+	old := []interface{}{"a", "b", "c", "d", "e"}
+	updated := []interface{}{"z", "a", "b", "c", "d", "e"}
+
+	tree := newDiffer(Options{}).buildLCSArrayTree(old, updated)
+
+	stats := tree.Stats()
+	assert.Equal(t, Stats{Added: 1, Unchanged: 5}, stats)
+	assert.Equal(t, &Node{Type: NodeTypeAdded, Index: intPtr(0), NewValue: "z"}, tree.Children[0])
+}
+
+func TestBuildLCSArrayTree_RemoveAndReplace(t *testing.T) {
+	old := []interface{}{1.0, 2.0, 3.0}
+	updated := []interface{}{1.0, 4.0}
+
+	tree := newDiffer(Options{}).buildLCSArrayTree(old, updated)
+
+	assert.Equal(t, Stats{Added: 1, Removed: 2, Unchanged: 1}, tree.Stats())
+}
+
+func TestGenDiff_LCSArrays(t *testing.T) {
+	file1 := createTempFile(t, `{"hosts":["a","b","c","d","e"]}`)
+	file2 := createTempFile(t, `{"hosts":["z","a","b","c","d","e"]}`)
+	defer func() {
+		if err := os.Remove(file1); err != nil {
+			t.Logf("failed to remove temp file %s: %v", file1, err)
+		}
+		if err := os.Remove(file2); err != nil {
+			t.Logf("failed to remove temp file %s: %v", file2, err)
+		}
+	}()
+
+	result, err := GenDiffWithOptions(file1, file2, "plain", Options{LCSArrays: true})
+	require.NoError(t, err)
+	assert.Equal(t, "Property 'hosts.0' was added with value: 'z'", result)
+
+	// Without the option the whole array is reported as updated
+	result, err = GenDiff(file1, file2, "plain")
+	require.NoError(t, err)
+	assert.Contains(t, result, "Property 'hosts' was updated")
+}
+
+func TestGenDiff_ArrayIndexInJSON(t *testing.T) {
+	file1 := createTempFile(t, `{"hosts":["a","b"]}`)
+	file2 := createTempFile(t, `{"hosts":["a","c"]}`)
+	defer func() {
+		if err := os.Remove(file1); err != nil {
+			t.Logf("failed to remove temp file %s: %v", file1, err)
+		}
+		if err := os.Remove(file2); err != nil {
+			t.Logf("failed to remove temp file %s: %v", file2, err)
+		}
+	}()
+
+	result, err := GenDiffWithOptions(file1, file2, "json", Options{LCSArrays: true})
+	require.NoError(t, err)
+
+	var tree struct {
+		Children []struct {
+			Key      string `json:"key"`
+			Children []map[string]interface{}
+		}
+	}
+	require.NoError(t, json.Unmarshal([]byte(result), &tree))
+	require.Len(t, tree.Children, 1)
+	assert.Equal(t, "hosts", tree.Children[0].Key)
+
+	// Array elements carry an index and no key
+	elements := tree.Children[0].Children
+	require.Len(t, elements, 3)
+	for _, element := range elements {
+		assert.NotContains(t, element, "key")
+	}
+	assert.Equal(t, map[string]interface{}{"type": "unchanged", "index": 0.0, "value": "a"}, elements[0])
+	assert.Equal(t, map[string]interface{}{"type": "removed", "index": 1.0, "oldValue": "b"}, elements[1])
+	assert.Equal(t, map[string]interface{}{"type": "added", "index": 1.0, "newValue": "c"}, elements[2])
+}
+
+func TestSideValue_Arrays(t *testing.T) {
+	tree := newDiffer(Options{LCSArrays: true}).buildDiffTree(
+		map[string]interface{}{"hosts": []interface{}{"a", "b", "c"}},
+		map[string]interface{}{"hosts": []interface{}{"z", "a", "c"}},
+		nil,
+	)
+
+	assert.Equal(t, map[string]interface{}{"hosts": []interface{}{"a", "b", "c"}}, oldDocument(tree))
+	assert.Equal(t, map[string]interface{}{"hosts": []interface{}{"z", "a", "c"}}, newDocument(tree))
+}
+
+func TestGenDiff_IndexArrays(t *testing.T) {
+	opts := Options{IndexArrays: true}
+
+	t.Run("middle element changed", func(t *testing.T) {
+		content1 := `{"hosts": ["a", "b", "c"], "servers": [{"name": "web", "port": 80}, {"name": "db", "port": 5432}]}`
+		content2 := `{"hosts": ["a", "x", "c"], "servers": [{"name": "web", "port": 80}, {"name": "db", "port": 6432}]}`
+
+		result, err := GenDiffString(content1, content2, "json", "plain", opts)
+		require.NoError(t, err)
+		assert.Equal(t, `Property 'hosts.1' was updated. From 'b' to 'x'
+Property 'servers.1.port' was updated. From 5432 to 6432`, result)
+	})
+
+	t.Run("element appended and removed", func(t *testing.T) {
+		content1 := `{"hosts": ["a", "b"], "ports": [80, 443]}`
+		content2 := `{"hosts": ["a", "b", "c"], "ports": [80]}`
+
+		result, err := GenDiffString(content1, content2, "json", "plain", opts)
+		require.NoError(t, err)
+		assert.Equal(t, `Property 'hosts.2' was added with value: 'c'
+Property 'ports.1' was removed`, result)
+	})
+
+	t.Run("tree", func(t *testing.T) {
+		tree := newDiffer(opts).buildIndexArrayTree(
+			[]interface{}{1.0, []interface{}{"x"}, 3.0},
+			[]interface{}{1.0, []interface{}{"y"}, 3.0, 4.0},
+			nil,
+		)
+		require.Len(t, tree.Children, 4)
+		assert.Equal(t, &Node{Type: NodeTypeUnchanged, Index: intPtr(0), Value: 1.0}, tree.Children[0])
+		// Nested arrays are compared element by element too
+		assert.Equal(t, NodeTypeNested, tree.Children[1].Type)
+		assert.Equal(t, &Node{Type: NodeTypeUpdated, Index: intPtr(0), OldValue: "x", NewValue: "y"}, tree.Children[1].Children[0])
+		assert.Equal(t, &Node{Type: NodeTypeAdded, Index: intPtr(3), NewValue: 4.0}, tree.Children[3])
+	})
+}
+
+func TestGenDiff_SetArrays(t *testing.T) {
+	opts := Options{SetArrays: true}
+	tests := []struct {
+		name     string
+		content1 string
+		content2 string
+		expected string
+	}{
+		{"reordered", `{"hosts": ["a", "b", "c"]}`, `{"hosts": ["c", "b", "a"]}`, ""},
+		{"added", `{"hosts": ["a", "b"]}`, `{"hosts": ["a", "b", "c"]}`, "Property 'hosts.2' was added with value: 'c'"},
+		{"removed and added", `{"hosts": ["a", "b"]}`, `{"hosts": ["c", "a"]}`, "Property 'hosts.0' was added with value: 'c'\nProperty 'hosts.1' was removed"},
+		{"duplicates ignored", `{"hosts": ["a", "a", "b"]}`, `{"hosts": ["a", "b"]}`, ""},
+		{"duplicate added", `{"hosts": ["a"]}`, `{"hosts": ["b", "a", "b"]}`, "Property 'hosts.0' was added with value: 'b'"},
+		{"objects", `{"rules": [{"port": 80}, {"port": 443}]}`, `{"rules": [{"port": 443}, {"port": 80}]}`, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := GenDiffString(tt.content1, tt.content2, "json", "plain", opts)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+
+	// Reordering still counts as a change without the option
+	result, err := GenDiffString(`{"hosts": ["a", "b"]}`, `{"hosts": ["b", "a"]}`, "json", "plain", Options{})
+	require.NoError(t, err)
+	assert.Contains(t, result, "Property 'hosts' was updated")
+}
+
+func TestBuildSetArrayTree_Unchanged(t *testing.T) {
+	tree := newDiffer(Options{}).buildSetArrayTree([]interface{}{"a", "a", "b"}, []interface{}{"b", "a"})
+	assert.Equal(t, Stats{Unchanged: 2}, tree.Stats())
+}
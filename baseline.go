@@ -0,0 +1,62 @@
+package code
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// LoadBaseline читает ранее сохранённый дифф в json формате, изменения из которого
+// считаются принятыми и подавляются через Options.Baseline
+func LoadBaseline(path string) (*Node, error) {
+	// nolint:gosec // Читаем сохранённый дифф, указанный пользователем
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read baseline %s: %w", path, err)
+	}
+
+	var baseline Node
+	if err := json.Unmarshal(content, &baseline); err != nil {
+		return nil, fmt.Errorf("failed to parse baseline %s: %w", path, err)
+	}
+	return &baseline, nil
+}
+
+// suppressBaseline возвращает копию дерева без изменений, которые уже есть в baseline
+// с тем же путём и типом
+func suppressBaseline(node, baseline *Node) *Node {
+	known := make(map[string]string)
+	walkChanges(baseline, nil, func(nodePath []string, child *Node) {
+		known[baselineKey(nodePath)] = child.Type
+	})
+	return suppressChildren(node, nil, known)
+}
+
+// suppressChildren рекурсивно отбрасывает изменения, известные по baseline
+func suppressChildren(node *Node, nodePath []string, known map[string]string) *Node {
+	result := *node
+	result.Children = make([]*Node, 0, len(node.Children))
+
+	for _, child := range node.Children {
+		childPath := appendPath(nodePath, child.name())
+		switch child.Type {
+		case NodeTypeNested:
+			result.Children = append(result.Children, suppressChildren(child, childPath, known))
+		case NodeTypeAdded, NodeTypeRemoved, NodeTypeUpdated:
+			if known[baselineKey(childPath)] == child.Type {
+				continue
+			}
+			result.Children = append(result.Children, child)
+		default:
+			result.Children = append(result.Children, child)
+		}
+	}
+
+	return &result
+}
+
+// baselineKey строит ключ пути, не зависящий от точек внутри имён ключей
+func baselineKey(nodePath []string) string {
+	return strings.Join(nodePath, "\x00")
+}
@@ -0,0 +1,38 @@
+package code
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenDiff_Baseline(t *testing.T) {
+	dir := t.TempDir()
+
+	// The accepted diff: port changed and debug was added
+	saved, err := GenDiffString(`{"port": 80, "db": {"host": "a"}}`, `{"port": 8080, "db": {"host": "a"}, "debug": true}`, "json", "json", Options{})
+	require.NoError(t, err)
+	baselinePath := writeTestFile(t, dir, "baseline.json", saved)
+
+	baseline, err := LoadBaseline(baselinePath)
+	require.NoError(t, err)
+
+	content1 := `{"port": 80, "db": {"host": "a"}}`
+	content2 := `{"port": 9090, "db": {"host": "b"}, "debug": false}`
+	result, err := GenDiffString(content1, content2, "json", "plain", Options{Baseline: baseline})
+	require.NoError(t, err)
+
+	// Known port update and debug addition are suppressed even though values differ
+	assert.Equal(t, "Property 'db.host' was updated. From 'a' to 'b'", result)
+}
+
+func TestLoadBaseline_Errors(t *testing.T) {
+	dir := t.TempDir()
+
+	_, err := LoadBaseline(dir + "/missing.json")
+	assert.ErrorContains(t, err, "failed to read baseline")
+
+	_, err = LoadBaseline(writeTestFile(t, dir, "bad.json", "not json"))
+	assert.ErrorContains(t, err, "failed to parse baseline")
+}
@@ -0,0 +1,73 @@
+package code
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Candidate содержит результат сравнения базового файла с одним из кандидатов
+type Candidate struct {
+	Path  string
+	Tree  *Node
+	Stats Stats
+}
+
+// RankCandidates сравнивает базовый файл с каждым кандидатом и возвращает результаты,
+// упорядоченные по количеству изменений: первым идёт ближайший кандидат.
+// При равном количестве изменений сохраняется исходный порядок.
+func RankCandidates(base string, candidates []string, opts Options) ([]Candidate, error) {
+	results, err := compareCandidates(base, candidates, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Stats.Changes() < results[j].Stats.Changes()
+	})
+	return results, nil
+}
+
+// GenDiffCandidates сравнивает базовый файл с каждым кандидатом и выводит отдельную секцию
+// для каждого из них, завершая вывод строкой с ближайшим кандидатом
+func GenDiffCandidates(base string, candidates []string, format string, opts Options) (string, error) {
+	if len(candidates) == 0 {
+		return "", fmt.Errorf("at least one candidate is required")
+	}
+
+	results, err := compareCandidates(base, candidates, opts)
+	if err != nil {
+		return "", err
+	}
+
+	sections := make([]string, 0, len(results)+1)
+	closest := results[0]
+	for _, candidate := range results {
+		formatted, err := formatDiff(candidate.Tree, format, opts)
+		if err != nil {
+			return "", fmt.Errorf("failed to format diff: %w", err)
+		}
+		sections = append(sections, fmt.Sprintf("=== %s ===\n%s", candidate.Path, formatted))
+
+		if candidate.Stats.Changes() < closest.Stats.Changes() {
+			closest = candidate
+		}
+	}
+
+	sections = append(sections, fmt.Sprintf("Closest candidate: %s (%d changes)", closest.Path, closest.Stats.Changes()))
+	return strings.Join(sections, "\n\n"), nil
+}
+
+// compareCandidates строит деревья различий для всех кандидатов в исходном порядке
+func compareCandidates(base string, candidates []string, opts Options) ([]Candidate, error) {
+	results := make([]Candidate, 0, len(candidates))
+	for _, path := range candidates {
+		tree, warnings, err := buildTreeFromFiles(base, path, opts, nil)
+		if err != nil {
+			return nil, err
+		}
+		logWarnings(opts, warnings)
+		results = append(results, Candidate{Path: path, Tree: tree, Stats: tree.Stats()})
+	}
+	return results, nil
+}
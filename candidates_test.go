@@ -0,0 +1,39 @@
+package code
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRankCandidates(t *testing.T) {
+	base := createTempFile(t, `{"host":"hexlet.io","timeout":50,"proxy":"123.234.53.22"}`)
+	far := createTempFile(t, `{"host":"example.com","timeout":20}`)
+	closest := createTempFile(t, `{"host":"hexlet.io","timeout":50,"proxy":"123.234.53.22","follow":false}`)
+	middle := createTempFile(t, `{"host":"hexlet.io","timeout":20,"proxy":"123.234.53.23"}`)
+	defer func() {
+		for _, file := range []string{base, far, closest, middle} {
+			if err := os.Remove(file); err != nil {
+				t.Logf("failed to remove temp file %s: %v", file, err)
+			}
+		}
+	}()
+
+	results, err := RankCandidates(base, []string{far, closest, middle}, Options{})
+	require.NoError(t, err)
+	require.Len(t, results, 3)
+
+	assert.Equal(t, closest, results[0].Path)
+	assert.Equal(t, 1, results[0].Stats.Changes())
+	assert.Equal(t, middle, results[1].Path)
+	assert.Equal(t, 2, results[1].Stats.Changes())
+	assert.Equal(t, far, results[2].Path)
+	assert.Equal(t, 3, results[2].Stats.Changes())
+
+	result, err := GenDiffCandidates(base, []string{far, closest, middle}, "plain", Options{})
+	require.NoError(t, err)
+	assert.Contains(t, result, "=== "+far+" ===\nProperty 'host' was updated")
+	assert.Contains(t, result, "Closest candidate: "+closest+" (1 changes)")
+}
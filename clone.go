@@ -0,0 +1,50 @@
+package code
+
+// Clone возвращает глубокую копию узла: копируются дочерние узлы, индекс и значения,
+// включая вложенные карты и массивы, так что изменения копии не затрагивают оригинал
+func (n *Node) Clone() *Node {
+	if n == nil {
+		return nil
+	}
+
+	clone := *n
+	if n.Index != nil {
+		index := *n.Index
+		clone.Index = &index
+	}
+	if n.Path != nil {
+		clone.Path = append([]string(nil), n.Path...)
+	}
+	clone.Value = cloneValue(n.Value)
+	clone.OldValue = cloneValue(n.OldValue)
+	clone.NewValue = cloneValue(n.NewValue)
+	clone.rightValue = cloneValue(n.rightValue)
+
+	if n.Children != nil {
+		clone.Children = make([]*Node, len(n.Children))
+		for i, child := range n.Children {
+			clone.Children[i] = child.Clone()
+		}
+	}
+	return &clone
+}
+
+// cloneValue рекурсивно копирует карты и массивы; скаляры возвращаются как есть
+func cloneValue(v interface{}) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		cloned := make(map[string]interface{}, len(val))
+		for key, item := range val {
+			cloned[key] = cloneValue(item)
+		}
+		return cloned
+	case []interface{}:
+		cloned := make([]interface{}, len(val))
+		for i, item := range val {
+			cloned[i] = cloneValue(item)
+		}
+		return cloned
+	default:
+		return v
+	}
+}
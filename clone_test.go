@@ -0,0 +1,56 @@
+package code
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNode_Clone(t *testing.T) {
+	data1 := map[string]interface{}{
+		"common": map[string]interface{}{"setting": map[string]interface{}{"list": []interface{}{1, 2}}, "items": []interface{}{1, 2}},
+		"host":   "a.io",
+		"extra":  map[string]interface{}{"deep": []interface{}{map[string]interface{}{"a": 1}}},
+	}
+	data2 := map[string]interface{}{
+		"common": map[string]interface{}{"setting": map[string]interface{}{"list": []interface{}{1, 3}}, "items": []interface{}{1, 2, 3}},
+		"host":   "b.io",
+	}
+	tree, _ := buildTree(data1, data2, Options{})
+	before, err := formatDiff(tree, "json", Options{})
+	require.NoError(t, err)
+
+	clone := tree.Clone()
+	require.Equal(t, tree, clone)
+
+	// Mutate every layer of the clone: nodes, indexes and nested values
+	walkNodes(clone, func(node *Node) {
+		node.Key += "_x"
+		if node.Index != nil {
+			*node.Index += 10
+		}
+		for _, value := range []interface{}{node.Value, node.OldValue, node.NewValue} {
+			if m, ok := value.(map[string]interface{}); ok {
+				m["injected"] = true
+			}
+			if s, ok := value.([]interface{}); ok && len(s) > 0 {
+				s[0] = "changed"
+			}
+		}
+	})
+	clone.Children = clone.Children[:1]
+
+	after, err := formatDiff(tree, "json", Options{})
+	require.NoError(t, err)
+	assert.Equal(t, before, after)
+	assert.Nil(t, (*Node)(nil).Clone())
+}
+
+// walkNodes calls fn for the node and all of its descendants
+func walkNodes(node *Node, fn func(*Node)) {
+	fn(node)
+	for _, child := range node.Children {
+		walkNodes(child, fn)
+	}
+}
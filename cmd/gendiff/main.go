@@ -3,48 +3,539 @@ package main
 import (
 	"code"
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/urfave/cli/v3"
 )
 
 func main() {
-	cmd := &cli.Command{
-		Name:  "gendiff",
-		Usage: "Compares two configuration files and shows a difference.",
+	code.RegisterSource("consul", code.ConsulSource{})
+	code.RegisterEvaluator(".jsonnet", code.CommandEvaluator("jsonnet"))
+	code.RegisterEvaluator(".cue", code.CommandEvaluator("cue", "export", "--out", "json"))
+
+	cmd := newCommand()
+	if err := cmd.Run(context.Background(), os.Args); err != nil {
+		// With --exit-code status 1 means "files differ", so errors use status 2
+		if cmd.Bool("exit-code") {
+			log.Print(err)
+			os.Exit(2)
+		}
+		log.Fatal(err)
+	}
+}
+
+// newCommand builds the gendiff command with all of its flags and the action
+func newCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "gendiff",
+		Usage:     "Compares two configuration files and shows a difference.",
+		ArgsUsage: "<file1> <file2> [file3...] | <dir1> <dir2> | --source <url> <file> | --since <date> <file> (\"-\" reads a file from stdin)",
 		Flags: []cli.Flag{
-			&cli.StringFlag{
+			&cli.StringSliceFlag{
 				Name:    "format",
 				Aliases: []string{"f"},
-				Value:   "stylish",
-				Usage:   "output format (default: \"stylish\")",
+				Value:   []string{"stylish"},
+				Usage:   "output format (default: \"stylish\"); repeat to produce several formats from one diff",
+			},
+			&cli.StringSliceFlag{
+				Name:    "output",
+				Aliases: []string{"o"},
+				Usage:   "write the output to the given file instead of stdout; with several --format, one per format (\"-\" for stdout)",
+			},
+			&cli.BoolFlag{
+				Name:  "drifted",
+				Usage: "list only keys present in both files with different values (same as --format drifted)",
+			},
+			&cli.StringFlag{
+				Name:  "profile",
+				Usage: "start from a preset of options: " + strings.Join(code.ProfileNames(), ", "),
+			},
+			&cli.StringSliceFlag{
+				Name:  "array-key",
+				Usage: "match elements of object arrays by the given field (repeatable, overrides the profile)",
+			},
+			&cli.StringFlag{
+				Name:  "baseline",
+				Usage: "suppress changes already present in the given diff saved with --format json",
+			},
+			&cli.StringSliceFlag{
+				Name:  "include",
+				Usage: "report only changes under the given dotted path (glob segments allowed, repeatable)",
+			},
+			&cli.StringSliceFlag{
+				Name:  "ignore",
+				Usage: "drop keys before comparing: a key name at any depth, a glob (metadata.*) or a /regex/ over the dotted path (repeatable)",
+			},
+			&cli.StringFlag{
+				Name:  "only",
+				Usage: "show only changes of the given comma-separated types: added, removed, updated, unchanged",
+			},
+			&cli.BoolFlag{
+				Name:  "changes-only",
+				Usage: "hide unchanged keys and sections without changes",
+			},
+			&cli.StringFlag{
+				Name:  "source",
+				Usage: "compare a config fetched from the given URL (e.g. consul://host/key) against a local file",
+			},
+			&cli.BoolFlag{
+				Name:  "literal",
+				Usage: "treat both arguments as config contents instead of file paths (requires --input-format)",
+			},
+			&cli.StringFlag{
+				Name:  "input-format",
+				Usage: "format of the --literal arguments or of stdin (\"-\"): json, yaml, toml, ini, xml, env, properties or hcl",
+			},
+			&cli.BoolFlag{
+				Name:  "nest-properties",
+				Usage: "expand dotted keys of .properties files (server.port) into nested objects",
+			},
+			&cli.BoolFlag{
+				Name:  "index-yaml-documents",
+				Usage: "compare YAML files with several documents (---) as doc0, doc1, ... instead of failing",
+			},
+			&cli.StringFlag{
+				Name:  "since",
+				Usage: "compare a file against its copy in the snapshot taken on the given date (YYYY-MM-DD)",
+			},
+			&cli.StringFlag{
+				Name:  "snapshots-dir",
+				Value: "snapshots",
+				Usage: "directory with dated snapshots used by --since",
+			},
+			&cli.BoolFlag{
+				Name:    "quiet",
+				Aliases: []string{"q"},
+				Usage:   "print nothing and exit with status 1 if the files differ",
+			},
+			&cli.BoolFlag{
+				Name:  "fail-if-identical",
+				Usage: "exit with status 1 if the files have no differences and 0 if they differ",
+			},
+			&cli.BoolFlag{
+				Name:  "exit-code",
+				Usage: "exit with status 1 if the files differ and 2 on errors, like diff",
+			},
+			&cli.StringFlag{
+				Name:  "left-root",
+				Usage: "compare only the given top-level key of the first file",
+			},
+			&cli.StringFlag{
+				Name:  "right-root",
+				Usage: "compare only the given top-level key of the second file",
+			},
+			&cli.IntFlag{
+				Name:  "float-precision",
+				Value: -1,
+				Usage: "round numbers to the given number of decimals when comparing and printing (-1 keeps full precision)",
+			},
+			&cli.FloatFlag{
+				Name:  "float-epsilon",
+				Usage: "treat numbers differing by at most the given amount as equal (0 requires an exact match)",
+			},
+			&cli.StringFlag{
+				Name:  "number-locale",
+				Usage: "compare strings formatted as numbers in the given locale (en, de, fr) numerically",
+			},
+			&cli.IntFlag{
+				Name:  "max-changes",
+				Usage: "report at most the given number of changes (0 reports all)",
+			},
+			&cli.StringFlag{
+				Name:  "json-order",
+				Value: code.JSONChildOrderKey,
+				Usage: "order of children in json output: key or type",
+			},
+			&cli.StringFlag{
+				Name:  "color",
+				Value: "auto",
+				Usage: "colorize stylish output: auto (only on a terminal without NO_COLOR), always or never",
+			},
+			&cli.StringFlag{
+				Name:  "plain-separator",
+				Value: ".",
+				Usage: "separator of property paths in plain output; keys containing it are shown in brackets",
+			},
+			&cli.BoolFlag{
+				Name:  "expand-complex",
+				Usage: "show objects and arrays in plain output as inline JSON instead of [complex value]",
+			},
+			&cli.IntFlag{
+				Name:  "indent",
+				Value: 4,
+				Usage: "width of one indentation level in stylish output (at least 2)",
+			},
+			&cli.BoolFlag{
+				Name:  "guides",
+				Usage: "draw vertical guides at each indentation level in stylish output",
+			},
+			&cli.BoolFlag{
+				Name:  "timing",
+				Usage: "print how long parsing, diffing and formatting took to stderr (only when comparing two files)",
+			},
+			&cli.IntFlag{
+				Name:  "wrap",
+				Usage: "wrap long values in stylish output at the given column (0 disables wrapping)",
 			},
 		},
 		Action: func(ctx context.Context, cmd *cli.Command) error {
-			// Validate arguments
-			if cmd.NArg() != 2 {
-				return fmt.Errorf("exactly two file paths are required")
+			formats := cmd.StringSlice("format")
+			if cmd.Bool("drifted") {
+				formats = []string{"drifted"}
+			}
+			outputs := cmd.StringSlice("output")
+			format := formats[0]
+			// A single --output redirects the result of any mode to a file
+			var output string
+			if len(formats) == 1 && len(outputs) == 1 {
+				output, outputs = outputs[0], nil
+			}
+			multiple := len(formats) > 1 || len(outputs) > 0
+			if len(outputs) > 0 && len(outputs) != len(formats) {
+				return fmt.Errorf("each --format needs a matching --output")
 			}
 
-			path1 := cmd.Args().Get(0)
-			path2 := cmd.Args().Get(1)
-			format := cmd.String("format")
+			// A profile provides the base options; explicit flags override its fields
+			opts := code.Options{}
+			var err error
+			if profile := cmd.String("profile"); profile != "" {
+				if opts, err = code.Profile(profile); err != nil {
+					return err
+				}
+			}
+			opts.IncludePaths = cmd.StringSlice("include")
+			opts.IgnoreKeys = cmd.StringSlice("ignore")
+			opts.ChangesOnly = cmd.Bool("changes-only")
+			if only := cmd.String("only"); only != "" {
+				if opts.OnlyTypes, err = parseOnly(only); err != nil {
+					return err
+				}
+			}
+			if precision := cmd.Int("float-precision"); precision >= 0 {
+				opts.FloatPrecision = &precision
+			}
+			opts.JSONChildOrder = cmd.String("json-order")
+			opts.FloatEpsilon = cmd.Float("float-epsilon")
+			opts.NumberLocale = cmd.String("number-locale")
+			opts.MaxChanges = cmd.Int("max-changes")
+			opts.LeftRoot = cmd.String("left-root")
+			opts.RightRoot = cmd.String("right-root")
+			opts.Guides = cmd.Bool("guides")
+			opts.IndentWidth = cmd.Int("indent")
+			opts.PlainSeparator = cmd.String("plain-separator")
+			opts.PlainExpandComplex = cmd.Bool("expand-complex")
+			// Colors only make sense when the diff goes to stdout alone, not to --output files
+			if opts.Color, err = useColor(cmd.String("color"), os.Stdout); err != nil {
+				return err
+			}
+			opts.Color = opts.Color && len(outputs) == 0 && (output == "" || output == "-")
+			opts.Wrap = cmd.Int("wrap")
+			opts.NestPropertiesKeys = cmd.Bool("nest-properties")
+			opts.IndexYAMLDocuments = cmd.Bool("index-yaml-documents")
+			if cmd.IsSet("array-key") {
+				opts.ArrayKeyFields = cmd.StringSlice("array-key")
+			}
+			if baseline := cmd.String("baseline"); baseline != "" {
+				if opts.Baseline, err = code.LoadBaseline(baseline); err != nil {
+					return err
+				}
+			}
 
 			// Generate diff using the library function
-			result, err := code.GenDiff(path1, path2, format)
+			var result string
+			stdin := cmd.Args().Get(0) == "-" || cmd.Args().Get(1) == "-"
+			switch source := cmd.String("source"); {
+			case multiple && (source != "" || cmd.Bool("literal") || cmd.String("since") != "" ||
+				cmd.Bool("quiet") || cmd.NArg() != 2 || stdin || bothDirs(cmd.Args().Get(0), cmd.Args().Get(1))):
+				return fmt.Errorf("multiple formats and --output are only supported when comparing two files")
+			case cmd.Bool("timing") && (source != "" || cmd.Bool("literal") || cmd.Bool("quiet") || cmd.NArg() > 2 || stdin):
+				return fmt.Errorf("--timing is only supported when comparing two files")
+			case source != "":
+				// With --source the only argument is the local file
+				if cmd.NArg() != 1 {
+					return fmt.Errorf("exactly one file path is required with --source")
+				}
+				result, err = code.GenDiffSource(ctx, source, cmd.Args().First(), format, opts)
+			case cmd.Bool("literal"):
+				// Arguments are the config contents themselves
+				if cmd.NArg() != 2 {
+					return fmt.Errorf("exactly two config contents are required with --literal")
+				}
+				if cmd.String("input-format") == "" {
+					return fmt.Errorf("--input-format is required with --literal")
+				}
+				result, err = code.GenDiffString(cmd.Args().Get(0), cmd.Args().Get(1), cmd.String("input-format"), format, opts)
+			case cmd.String("since") != "":
+				// With --since the only argument is the current file
+				if cmd.NArg() != 1 {
+					return fmt.Errorf("exactly one file path is required with --since")
+				}
+				var snapshot string
+				snapshot, err = code.FindSnapshot(cmd.String("snapshots-dir"), cmd.String("since"), cmd.Args().First())
+				if err != nil {
+					return err
+				}
+				var diff *code.Result
+				if diff, err = generate(snapshot, cmd.Args().First(), format, opts, cmd.Bool("timing")); err == nil {
+					result = diff.Output
+				}
+			case cmd.NArg() < 2:
+				return fmt.Errorf("at least two file paths are required")
+			case stdin:
+				// One of the two configs is piped in; its format comes from --input-format or the content
+				if cmd.NArg() != 2 || cmd.Bool("quiet") {
+					return fmt.Errorf("stdin (\"-\") is only supported when comparing two configs without --quiet")
+				}
+				result, err = diffStdin(cmd.Args().Get(0), cmd.Args().Get(1), cmd.String("input-format"), format, opts)
+			case cmd.NArg() == 2 && bothDirs(cmd.Args().Get(0), cmd.Args().Get(1)):
+				// Two directories are walked and their config files compared pairwise
+				if cmd.Bool("quiet") || cmd.Bool("exit-code") || cmd.Bool("fail-if-identical") || cmd.Bool("timing") {
+					return fmt.Errorf("--quiet, --exit-code, --fail-if-identical and --timing are not supported for directories")
+				}
+				result, err = code.GenDiffDir(cmd.Args().Get(0), cmd.Args().Get(1), format, opts)
+			case cmd.Bool("quiet"):
+				// Fast path: stop at the first difference and report it via the exit status
+				differ, err := code.FilesDiffer(cmd.Args().Get(0), cmd.Args().Get(1), opts)
+				if err != nil {
+					return fmt.Errorf("failed to generate diff: %w", err)
+				}
+				if cmd.Bool("fail-if-identical") {
+					return identicalExit(differ)
+				}
+				if differ {
+					return cli.Exit("", 1)
+				}
+				return nil
+			case cmd.NArg() > 2:
+				// The first file is compared against each of the others
+				result, err = code.GenDiffCandidates(cmd.Args().First(), cmd.Args().Tail(), format, opts)
+			case multiple:
+				// The diff is computed once and rendered in every requested format
+				return writeOutputs(cmd.Args().Get(0), cmd.Args().Get(1), formats, outputs, opts, cmd.Bool("timing"))
+			case cmd.Bool("exit-code"):
+				// diff-like exit status: 0 for identical files, 1 for differences
+				if cmd.Bool("fail-if-identical") {
+					return fmt.Errorf("--exit-code and --fail-if-identical cannot be used together")
+				}
+				result, err = exitCode(cmd.Args().Get(0), cmd.Args().Get(1), format, opts, cmd.Bool("timing"))
+				if err != nil && !errors.As(err, new(cli.ExitCoder)) {
+					return fmt.Errorf("failed to generate diff: %w", err)
+				}
+				if writeErr := writeResult(output, result); writeErr != nil {
+					return writeErr
+				}
+				return err
+			case cmd.Bool("fail-if-identical"):
+				// Inverse exit status: identical files are the failure
+				result, err = failIfIdentical(cmd.Args().Get(0), cmd.Args().Get(1), format, opts, cmd.Bool("timing"))
+				if err != nil && !errors.As(err, new(cli.ExitCoder)) {
+					return fmt.Errorf("failed to generate diff: %w", err)
+				}
+				if writeErr := writeResult(output, result); writeErr != nil {
+					return writeErr
+				}
+				return err
+			case cmd.Bool("timing"):
+				var diff *code.Result
+				if diff, err = generate(cmd.Args().Get(0), cmd.Args().Get(1), format, opts, true); err == nil {
+					result = diff.Output
+				}
+			default:
+				result, err = code.GenDiffWithOptions(cmd.Args().Get(0), cmd.Args().Get(1), format, opts)
+			}
 			if err != nil {
 				return fmt.Errorf("failed to generate diff: %w", err)
 			}
 
 			// Output the result
-			fmt.Print(result)
-			return nil
+			return writeResult(output, result)
 		},
 	}
+}
 
-	if err := cmd.Run(context.Background(), os.Args); err != nil {
-		log.Fatal(err)
+// writeOutputs renders a single diff in each of the formats, writing it to the paired
+// output file or to stdout
+func writeOutputs(filepath1, filepath2 string, formats, outputs []string, opts code.Options, timing bool) error {
+	result, err := generate(filepath1, filepath2, formats[0], opts, timing)
+	if err != nil {
+		return fmt.Errorf("failed to generate diff: %w", err)
+	}
+
+	printed := false
+	for i, format := range formats {
+		output, err := result.Format(format)
+		if err != nil {
+			return err
+		}
+		target := ""
+		if i < len(outputs) {
+			target = outputs[i]
+		}
+		// Outputs sent to stdout are separated by a newline, with none after the last,
+		// the same as a single result printed by writeResult
+		if target == "" || target == "-" {
+			if printed {
+				fmt.Println()
+			}
+			printed = true
+		}
+		if err := writeResult(target, output); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeResult prints the result to stdout, or writes it to the output file
+// (truncating it) when one is given
+func writeResult(output, result string) error {
+	if output == "" || output == "-" {
+		fmt.Print(result)
+		return nil
+	}
+	if err := os.WriteFile(output, []byte(result), 0o644); err != nil {
+		return fmt.Errorf("failed to write output file %s: %w", output, err)
+	}
+	return nil
+}
+
+// diffStdin compares two configs where "-" stands for stdin; a regular file keeps
+// the format of its extension
+func diffStdin(filepath1, filepath2, inputFormat, format string, opts code.Options) (string, error) {
+	if filepath1 == "-" && filepath2 == "-" {
+		return "", fmt.Errorf("only one of the configs can be read from stdin")
+	}
+
+	open := func(path string) (io.Reader, string, func() error, error) {
+		if path == "-" {
+			return os.Stdin, inputFormat, func() error { return nil }, nil
+		}
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, "", nil, err
+		}
+		return file, filepath.Ext(path), file.Close, nil
+	}
+
+	r1, format1, close1, err := open(filepath1)
+	if err != nil {
+		return "", err
+	}
+	defer close1()
+	r2, format2, close2, err := open(filepath2)
+	if err != nil {
+		return "", err
+	}
+	defer close2()
+
+	return code.GenDiffReader(r1, r2, format1, format2, format, opts)
+}
+
+// bothDirs reports whether both paths are existing directories
+func bothDirs(path1, path2 string) bool {
+	for _, path := range []string{path1, path2} {
+		info, err := os.Stat(path)
+		if err != nil || !info.IsDir() {
+			return false
+		}
+	}
+	return true
+}
+
+// useColor resolves the --color mode: auto enables colors only when out is a terminal
+// and the NO_COLOR environment variable is not set
+func useColor(mode string, out *os.File) (bool, error) {
+	switch mode {
+	case "always":
+		return true, nil
+	case "never":
+		return false, nil
+	case "auto":
+		if os.Getenv("NO_COLOR") != "" {
+			return false, nil
+		}
+		info, err := out.Stat()
+		return err == nil && info.Mode()&os.ModeCharDevice != 0, nil
+	default:
+		return false, fmt.Errorf("unsupported --color value %q (use auto, always or never)", mode)
+	}
+}
+
+// printWarnings writes collected warnings to stderr
+func printWarnings(warnings []string) {
+	for _, warning := range warnings {
+		fmt.Fprintf(os.Stderr, "warning: %s\n", warning)
+	}
+}
+
+// generate compares two files, printing warnings and, with timing, the duration
+// of each phase to stderr
+func generate(filepath1, filepath2, format string, opts code.Options, timing bool) (*code.Result, error) {
+	result, err := code.GenDiffResult(filepath1, filepath2, format, opts)
+	if err != nil {
+		return nil, err
+	}
+	printWarnings(result.Warnings)
+	if timing {
+		printTiming(result, filepath1, filepath2)
+	}
+	return result, nil
+}
+
+// printTiming writes the duration of each diff phase to stderr
+func printTiming(result *code.Result, filepath1, filepath2 string) {
+	for _, line := range result.Timing.Lines(filepath1, filepath2) {
+		fmt.Fprintf(os.Stderr, "timing: %s\n", line)
+	}
+}
+
+// failIfIdentical returns the diff output together with an exit error when the
+// files have no differences
+func failIfIdentical(filepath1, filepath2, format string, opts code.Options, timing bool) (string, error) {
+	result, err := generate(filepath1, filepath2, format, opts, timing)
+	if err != nil {
+		return "", err
+	}
+	return result.Output, identicalExit(result.Tree.HasChanges())
+}
+
+// exitCode returns the diff output together with an exit error when the files differ
+func exitCode(filepath1, filepath2, format string, opts code.Options, timing bool) (string, error) {
+	result, err := generate(filepath1, filepath2, format, opts, timing)
+	if err != nil {
+		return "", err
+	}
+	if result.Tree.HasChanges() {
+		return result.Output, cli.Exit("", 1)
+	}
+	return result.Output, nil
+}
+
+// parseOnly splits the --only value into node types, rejecting unknown ones
+func parseOnly(value string) ([]string, error) {
+	var types []string
+	for _, item := range strings.Split(value, ",") {
+		nodeType := strings.TrimSpace(item)
+		switch nodeType {
+		case code.NodeTypeAdded, code.NodeTypeRemoved, code.NodeTypeUpdated, code.NodeTypeUnchanged:
+			types = append(types, nodeType)
+		default:
+			return nil, fmt.Errorf("unsupported --only type %q (use added, removed, updated or unchanged)", nodeType)
+		}
+	}
+	return types, nil
+}
+
+// identicalExit maps the absence of changes to exit status 1
+func identicalExit(differ bool) error {
+	if !differ {
+		return cli.Exit("files are identical", 1)
 	}
+	return nil
 }
@@ -0,0 +1,274 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"code"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli/v3"
+)
+
+func writeFile(t *testing.T, name, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	return path
+}
+
+func TestFailIfIdentical(t *testing.T) {
+	file1 := writeFile(t, "file1.json", `{"host":"a","port":80}`)
+	same := writeFile(t, "same.json", `{"port":80,"host":"a"}`)
+	changed := writeFile(t, "changed.json", `{"host":"a","port":8080}`)
+
+	t.Run("identical fails", func(t *testing.T) {
+		_, err := failIfIdentical(file1, same, "stylish", code.Options{}, false)
+		var exitErr cli.ExitCoder
+		require.True(t, errors.As(err, &exitErr))
+		assert.Equal(t, 1, exitErr.ExitCode())
+	})
+
+	t.Run("differing passes", func(t *testing.T) {
+		output, err := failIfIdentical(file1, changed, "plain", code.Options{}, false)
+		require.NoError(t, err)
+		assert.Equal(t, "Property 'port' was updated. From 80 to 8080", output)
+	})
+}
+
+func TestExitCode(t *testing.T) {
+	file1 := writeFile(t, "file1.json", `{"host":"a","port":80}`)
+	same := writeFile(t, "same.json", `{"port":80,"host":"a"}`)
+	changed := writeFile(t, "changed.json", `{"host":"a","port":8080}`)
+
+	t.Run("identical exits 0", func(t *testing.T) {
+		output, err := exitCode(file1, same, "plain", code.Options{}, false)
+		require.NoError(t, err)
+		assert.Empty(t, output)
+	})
+
+	t.Run("differing exits 1", func(t *testing.T) {
+		output, err := exitCode(file1, changed, "plain", code.Options{}, false)
+		var exitErr cli.ExitCoder
+		require.True(t, errors.As(err, &exitErr))
+		assert.Equal(t, 1, exitErr.ExitCode())
+		assert.Equal(t, "Property 'port' was updated. From 80 to 8080", output)
+	})
+
+	t.Run("missing file is an error", func(t *testing.T) {
+		_, err := exitCode(file1, filepath.Join(t.TempDir(), "missing.json"), "plain", code.Options{}, false)
+		require.Error(t, err)
+		assert.False(t, errors.As(err, new(cli.ExitCoder)))
+	})
+}
+
+func TestBothDirs(t *testing.T) {
+	dir1 := t.TempDir()
+	dir2 := t.TempDir()
+	file := writeFile(t, "file.json", `{}`)
+
+	assert.True(t, bothDirs(dir1, dir2))
+	assert.False(t, bothDirs(dir1, file))
+	assert.False(t, bothDirs(filepath.Join(dir1, "missing"), dir2))
+}
+
+func TestOutputFile(t *testing.T) {
+	file1 := writeFile(t, "file1.json", `{"host":"a","port":80}`)
+	file2 := writeFile(t, "file2.json", `{"host":"a","port":8080}`)
+
+	t.Run("writes result and truncates", func(t *testing.T) {
+		output := writeFile(t, "diff.txt", strings.Repeat("stale content\n", 10))
+		err := newCommand().Run(context.Background(), []string{"gendiff", "-f", "plain", "-o", output, file1, file2})
+		require.NoError(t, err)
+
+		content, err := os.ReadFile(output)
+		require.NoError(t, err)
+		assert.Equal(t, "Property 'port' was updated. From 80 to 8080", string(content))
+	})
+
+	t.Run("unwritable path is an error", func(t *testing.T) {
+		output := filepath.Join(t.TempDir(), "missing", "diff.txt")
+		err := newCommand().Run(context.Background(), []string{"gendiff", "--output", output, file1, file2})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to write output file "+output)
+	})
+}
+
+// captureStdout runs fn and returns everything it wrote to stdout
+func captureStdout(t *testing.T, fn func()) string {
+	return capture(t, &os.Stdout, fn)
+}
+
+// capture runs fn with *target replaced by a pipe and returns what was written to it
+func capture(t *testing.T, target **os.File, fn func()) string {
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+	old := *target
+	*target = w
+	defer func() { *target = old }()
+
+	fn()
+	require.NoError(t, w.Close())
+	content, err := io.ReadAll(r)
+	require.NoError(t, err)
+	return string(content)
+}
+
+func TestMultipleOutputs(t *testing.T) {
+	file1 := writeFile(t, "file1.json", `{"host":"a","port":80}`)
+	file2 := writeFile(t, "file2.json", `{"host":"a","port":8080}`)
+	stylish, err := code.GenDiffWithOptions(file1, file2, "stylish", code.Options{})
+	require.NoError(t, err)
+	jsonOutput, err := code.GenDiffWithOptions(file1, file2, "json", code.Options{})
+	require.NoError(t, err)
+
+	t.Run("one run writes every format to its file", func(t *testing.T) {
+		dir := t.TempDir()
+		a, b := filepath.Join(dir, "a.txt"), filepath.Join(dir, "b.json")
+		stdout := captureStdout(t, func() {
+			err := newCommand().Run(context.Background(),
+				[]string{"gendiff", "-f", "stylish", "-f", "json", "-o", a, "-o", b, file1, file2})
+			require.NoError(t, err)
+		})
+		assert.Empty(t, stdout)
+
+		content, err := os.ReadFile(a)
+		require.NoError(t, err)
+		assert.Equal(t, stylish, string(content))
+		content, err = os.ReadFile(b)
+		require.NoError(t, err)
+		assert.Equal(t, jsonOutput, string(content))
+	})
+
+	t.Run("stdout matches the single format output", func(t *testing.T) {
+		b := filepath.Join(t.TempDir(), "b.json")
+		multi := captureStdout(t, func() {
+			err := newCommand().Run(context.Background(),
+				[]string{"gendiff", "-f", "stylish", "-f", "json", "-o", "-", "-o", b, file1, file2})
+			require.NoError(t, err)
+		})
+		single := captureStdout(t, func() {
+			err := newCommand().Run(context.Background(), []string{"gendiff", "-f", "stylish", "--color", "never", file1, file2})
+			require.NoError(t, err)
+		})
+		assert.Equal(t, stylish, multi)
+		assert.Equal(t, single, multi)
+	})
+
+	t.Run("outputs on stdout are separated by one newline", func(t *testing.T) {
+		stdout := captureStdout(t, func() {
+			err := newCommand().Run(context.Background(),
+				[]string{"gendiff", "--color", "never", "-f", "stylish", "-f", "json", file1, file2})
+			require.NoError(t, err)
+		})
+		assert.Equal(t, stylish+"\n"+jsonOutput, stdout)
+	})
+}
+
+func TestTiming(t *testing.T) {
+	file1 := writeFile(t, "file1.json", `{"host":"a","port":80}`)
+	file2 := writeFile(t, "file2.json", `{"host":"a","port":8080}`)
+
+	run := func(args ...string) (string, error) {
+		var err error
+		stderr := capture(t, &os.Stderr, func() {
+			captureStdout(t, func() {
+				cmd := newCommand()
+				// Keep exit statuses from terminating the test binary
+				cmd.ExitErrHandler = func(context.Context, *cli.Command, error) {}
+				err = cmd.Run(context.Background(), append([]string{"gendiff", "--timing"}, args...))
+			})
+		})
+		return stderr, err
+	}
+
+	for name, args := range map[string][]string{
+		"two files":         {file1, file2},
+		"exit code":         {"--exit-code", file1, file2},
+		"fail if identical": {"--fail-if-identical", file1, file2},
+		"multiple formats":  {"-f", "plain", "-f", "json", file1, file2},
+	} {
+		t.Run(name, func(t *testing.T) {
+			stderr, err := run(args...)
+			if err != nil {
+				require.True(t, errors.As(err, new(cli.ExitCoder)), err)
+			}
+			assert.Contains(t, stderr, "timing: parse "+file1+": ")
+			assert.Contains(t, stderr, "timing: total: ")
+		})
+	}
+
+	for name, args := range map[string][]string{
+		"literal":    {"--literal", "--input-format", "json", `{"a":1}`, `{"a":2}`},
+		"quiet":      {"--quiet", file1, file2},
+		"candidates": {file1, file2, file2},
+	} {
+		t.Run(name+" is rejected", func(t *testing.T) {
+			_, err := run(args...)
+			assert.EqualError(t, err, "--timing is only supported when comparing two files")
+		})
+	}
+}
+
+func TestDiffStdin(t *testing.T) {
+	file2 := writeFile(t, "file2.json", `{"host":"a","port":8080}`)
+	stdin := writeFile(t, "stdin", "host: a\nport: 80\n")
+
+	f, err := os.Open(stdin)
+	require.NoError(t, err)
+	defer f.Close()
+	oldStdin := os.Stdin
+	os.Stdin = f
+	defer func() { os.Stdin = oldStdin }()
+
+	output, err := diffStdin("-", file2, "yaml", "plain", code.Options{})
+	require.NoError(t, err)
+	assert.Equal(t, "Property 'port' was updated. From 80 to 8080", output)
+
+	_, err = diffStdin("-", "-", "json", "plain", code.Options{})
+	assert.EqualError(t, err, "only one of the configs can be read from stdin")
+}
+
+func TestUseColor(t *testing.T) {
+	file1 := writeFile(t, "file1.json", `{"host":"a","port":80}`)
+	file2 := writeFile(t, "file2.json", `{"host":"a","port":8080}`)
+	// A regular file is never a terminal
+	out, err := os.Create(filepath.Join(t.TempDir(), "out"))
+	require.NoError(t, err)
+	defer out.Close()
+
+	for mode, colored := range map[string]bool{"always": true, "never": false, "auto": false} {
+		t.Run(mode, func(t *testing.T) {
+			color, err := useColor(mode, out)
+			require.NoError(t, err)
+			output, err := code.GenDiffWithOptions(file1, file2, "stylish", code.Options{Color: color})
+			require.NoError(t, err)
+			assert.Equal(t, colored, strings.Contains(output, "\x1b["))
+		})
+	}
+
+	t.Run("NO_COLOR", func(t *testing.T) {
+		t.Setenv("NO_COLOR", "1")
+		color, err := useColor("auto", os.Stdout)
+		require.NoError(t, err)
+		assert.False(t, color)
+	})
+
+	_, err = useColor("sometimes", out)
+	assert.EqualError(t, err, `unsupported --color value "sometimes" (use auto, always or never)`)
+}
+
+func TestParseOnly(t *testing.T) {
+	types, err := parseOnly("added, removed")
+	require.NoError(t, err)
+	assert.Equal(t, []string{code.NodeTypeAdded, code.NodeTypeRemoved}, types)
+
+	_, err = parseOnly("added,nested")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `unsupported --only type "nested"`)
+}
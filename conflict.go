@@ -0,0 +1,215 @@
+package code
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Conflict описывает путь, который две ветки изменили по-разному относительно общей базы.
+// Отсутствие значения на стороне (ключ удалён) отмечается флагом *Exists.
+type Conflict struct {
+	Path string
+	// Segments — путь по ключам; в отличие от Path не теряет ключи с точками
+	Segments     []string
+	Ours         interface{}
+	OursExists   bool
+	Theirs       interface{}
+	TheirsExists bool
+}
+
+// CleanChange описывает изменение, которое применяется без конфликта.
+// Side принимает значения "ours", "theirs" или "both".
+type CleanChange struct {
+	Path string
+	// Segments — путь по ключам; в отличие от Path не теряет ключи с точками
+	Segments []string
+	Side     string
+}
+
+// GenDiffConflicts сравнивает две ветки ours и theirs с общей базой и выводит
+// конфликтующие значения с git-маркерами, а бесконфликтные изменения — отдельным списком
+func GenDiffConflicts(basePath, oursPath, theirsPath string, opts Options) (string, error) {
+	ours, oursWarnings, err := buildTreeFromFiles(basePath, oursPath, opts, nil)
+	if err != nil {
+		return "", err
+	}
+	theirs, theirsWarnings, err := buildTreeFromFiles(basePath, theirsPath, opts, nil)
+	if err != nil {
+		return "", err
+	}
+
+	logWarnings(opts, concatWarnings(oursWarnings, theirsWarnings))
+	return FormatConflicts(ours, theirs), nil
+}
+
+// FindConflicts сопоставляет два дерева различий от общей базы. Изменения конфликтуют,
+// если затрагивают один путь (или один путь лежит внутри другого) и дают разные значения.
+func FindConflicts(ours, theirs *Node) ([]Conflict, []CleanChange) {
+	oursPaths := changedPaths(ours)
+	theirsPaths := changedPaths(theirs)
+	oursDoc := newDocument(ours)
+	theirsDoc := newDocument(theirs)
+
+	var conflicts []Conflict
+	seen := make(map[string]bool)
+	overlapped := make(map[string]string)
+
+	for _, p1 := range oursPaths {
+		for _, p2 := range theirsPaths {
+			shorter := p1
+			if len(p2) < len(p1) {
+				shorter = p2
+			}
+			if !isPathPrefix(shorter, p1) || !isPathPrefix(shorter, p2) {
+				continue
+			}
+
+			name := strings.Join(shorter, ".")
+			oursValue, oursExists := valueAt(oursDoc, shorter)
+			theirsValue, theirsExists := valueAt(theirsDoc, shorter)
+			if oursExists == theirsExists && reflect.DeepEqual(oursValue, theirsValue) {
+				overlapped[pathKey(p1)] = "both"
+				overlapped[pathKey(p2)] = "both"
+				continue
+			}
+
+			overlapped[pathKey(p1)] = "conflict"
+			overlapped[pathKey(p2)] = "conflict"
+			if !seen[pathKey(shorter)] {
+				seen[pathKey(shorter)] = true
+				conflicts = append(conflicts, Conflict{
+					Path:         name,
+					Segments:     shorter,
+					Ours:         oursValue,
+					OursExists:   oursExists,
+					Theirs:       theirsValue,
+					TheirsExists: theirsExists,
+				})
+			}
+		}
+	}
+
+	var clean []CleanChange
+	addClean := func(paths [][]string, side string) {
+		for _, p := range paths {
+			changeSide := side
+			switch overlapped[pathKey(p)] {
+			case "conflict":
+				continue
+			case "both":
+				changeSide = "both"
+			}
+			clean = append(clean, CleanChange{Path: strings.Join(p, "."), Segments: p, Side: changeSide})
+		}
+	}
+	addClean(oursPaths, "ours")
+	addClean(theirsPaths, "theirs")
+
+	sort.Slice(conflicts, func(i, j int) bool { return conflicts[i].Path < conflicts[j].Path })
+	clean = uniqueCleanChanges(clean)
+	return conflicts, clean
+}
+
+// FormatConflicts выводит каждый конфликт в виде блока с маркерами
+// <<<<<<< ours / ======= / >>>>>>> theirs, а затем список бесконфликтных изменений
+func FormatConflicts(ours, theirs *Node) string {
+	conflicts, clean := FindConflicts(ours, theirs)
+
+	blocks := make([]string, 0, len(conflicts)+1)
+	for _, conflict := range conflicts {
+		lines := []string{"<<<<<<< ours"}
+		if conflict.OursExists {
+			lines = append(lines, fmt.Sprintf("%s: %s", conflict.Path, rowValue(conflict.Ours)))
+		}
+		lines = append(lines, "=======")
+		if conflict.TheirsExists {
+			lines = append(lines, fmt.Sprintf("%s: %s", conflict.Path, rowValue(conflict.Theirs)))
+		}
+		lines = append(lines, ">>>>>>> theirs")
+		blocks = append(blocks, strings.Join(lines, "\n"))
+	}
+
+	if len(clean) > 0 {
+		lines := []string{"Non-conflicting changes:"}
+		for _, change := range clean {
+			lines = append(lines, fmt.Sprintf("  %s: %s", change.Side, change.Path))
+		}
+		blocks = append(blocks, strings.Join(lines, "\n"))
+	}
+
+	return strings.Join(blocks, "\n\n")
+}
+
+// changedPaths возвращает пути всех изменённых узлов дерева
+func changedPaths(node *Node) [][]string {
+	var paths [][]string
+	walkChanges(node, nil, func(nodePath []string, _ *Node) {
+		paths = append(paths, nodePath)
+	})
+	return paths
+}
+
+// pathKey возвращает ключ пути для карт; в отличие от записи через точку он различает
+// ключ "a.b" и путь a → b
+func pathKey(nodePath []string) string {
+	return strings.Join(nodePath, "\x00")
+}
+
+// isPathPrefix проверяет, что prefix является началом пути
+func isPathPrefix(prefix, nodePath []string) bool {
+	if len(prefix) > len(nodePath) {
+		return false
+	}
+	for i := range prefix {
+		if prefix[i] != nodePath[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// valueAt возвращает значение по пути внутри документа
+func valueAt(doc interface{}, valuePath []string) (interface{}, bool) {
+	current := doc
+	for _, segment := range valuePath {
+		switch val := current.(type) {
+		case map[string]interface{}:
+			next, ok := val[segment]
+			if !ok {
+				return nil, false
+			}
+			current = next
+		case []interface{}:
+			index, err := strconv.Atoi(segment)
+			if err != nil || index < 0 || index >= len(val) {
+				return nil, false
+			}
+			current = val[index]
+		default:
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+// uniqueCleanChanges сортирует изменения по пути и убирает повторы изменений,
+// сделанных одинаково в обеих ветках
+func uniqueCleanChanges(changes []CleanChange) []CleanChange {
+	sort.SliceStable(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	result := make([]CleanChange, 0, len(changes))
+	for _, change := range changes {
+		if len(result) > 0 && isSameChange(result[len(result)-1], change) {
+			continue
+		}
+		result = append(result, change)
+	}
+	return result
+}
+
+// isSameChange сравнивает изменения по сегментам пути и стороне
+func isSameChange(a, b CleanChange) bool {
+	return a.Side == b.Side && pathKey(a.Segments) == pathKey(b.Segments)
+}
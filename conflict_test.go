@@ -0,0 +1,60 @@
+package code
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func conflictTrees(t *testing.T, base, ours, theirs string) (*Node, *Node) {
+	baseData, err := parseJSON([]byte(base))
+	require.NoError(t, err)
+	oursData, err := parseJSON([]byte(ours))
+	require.NoError(t, err)
+	theirsData, err := parseJSON([]byte(theirs))
+	require.NoError(t, err)
+
+	oursTree, _ := buildTree(baseData, oursData, Options{})
+	theirsTree, _ := buildTree(baseData, theirsData, Options{})
+	return oursTree, theirsTree
+}
+
+func TestFormatConflicts(t *testing.T) {
+	base := `{"db": {"port": 5432, "host": "a"}, "timeout": 10, "debug": false, "legacy": 1}`
+	ours := `{"db": {"port": 5433, "host": "a"}, "timeout": 10, "debug": true}`
+	theirs := `{"db": {"port": 5434, "host": "a"}, "timeout": 20, "debug": true, "legacy": 2}`
+
+	oursTree, theirsTree := conflictTrees(t, base, ours, theirs)
+
+	expected := `<<<<<<< ours
+db.port: 5433
+=======
+db.port: 5434
+>>>>>>> theirs
+
+<<<<<<< ours
+=======
+legacy: 2
+>>>>>>> theirs
+
+Non-conflicting changes:
+  both: debug
+  theirs: timeout`
+	assert.Equal(t, expected, FormatConflicts(oursTree, theirsTree))
+}
+
+func TestFindConflicts_NestedOverlap(t *testing.T) {
+	base := `{"db": {"port": 5432}}`
+	ours := `{"db": "sqlite"}`
+	theirs := `{"db": {"port": 5433}}`
+
+	oursTree, theirsTree := conflictTrees(t, base, ours, theirs)
+	conflicts, clean := FindConflicts(oursTree, theirsTree)
+
+	require.Len(t, conflicts, 1)
+	assert.Equal(t, "db", conflicts[0].Path)
+	assert.Equal(t, "sqlite", conflicts[0].Ours)
+	assert.Equal(t, map[string]interface{}{"port": 5433.0}, conflicts[0].Theirs)
+	assert.Empty(t, clean)
+}
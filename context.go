@@ -0,0 +1,43 @@
+package code
+
+import (
+	"bytes"
+	"context"
+	"io"
+)
+
+// GenDiffContext сравнивает два конфигурационных файла, прерываясь при отмене ctx:
+// отмена проверяется перед чтением каждого файла, периодически при сравнении и при
+// записи вывода. После отмены возвращается ctx.Err().
+func GenDiffContext(ctx context.Context, filepath1, filepath2, format string) (string, error) {
+	var buf bytes.Buffer
+	if err := GenDiffToWithOptions(&buf, filepath1, filepath2, format, Options{ctx: ctx}); err != nil {
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// ctxErr возвращает ошибку отмены opts.ctx; без контекста отмены не бывает
+func (o Options) ctxErr() error {
+	if o.ctx == nil {
+		return nil
+	}
+	return o.ctx.Err()
+}
+
+// ctxWriter отказывается писать после отмены ctx. Форматтеры пишут через bufio.Writer,
+// поэтому отмена проверяется при сбросе каждого заполненного буфера.
+type ctxWriter struct {
+	ctx context.Context
+	w   io.Writer
+}
+
+func (c *ctxWriter) Write(p []byte) (int, error) {
+	if err := c.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return c.w.Write(p)
+}
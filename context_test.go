@@ -0,0 +1,89 @@
+package code
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countdownContext reports cancellation once Err has been called more than remaining times
+type countdownContext struct {
+	context.Context
+	remaining int
+}
+
+func (c *countdownContext) Err() error {
+	c.remaining--
+	if c.remaining < 0 {
+		return context.Canceled
+	}
+	return nil
+}
+
+func largeConfigs(t *testing.T) (string, string) {
+	var left, right strings.Builder
+	left.WriteString("{")
+	right.WriteString("{")
+	for i := 0; i < 20000; i++ {
+		if i > 0 {
+			left.WriteString(",")
+			right.WriteString(",")
+		}
+		fmt.Fprintf(&left, `"section%05d": {"host": "a", "port": %d}`, i, i)
+		fmt.Fprintf(&right, `"section%05d": {"host": "b", "port": %d}`, i, i)
+	}
+	left.WriteString("}")
+	right.WriteString("}")
+
+	dir := t.TempDir()
+	return writeTestFile(t, dir, "left.json", left.String()), writeTestFile(t, dir, "right.json", right.String())
+}
+
+func TestGenDiffContext(t *testing.T) {
+	file1, file2 := largeConfigs(t)
+
+	t.Run("completes without cancellation", func(t *testing.T) {
+		expected, err := GenDiff(file1, file2, "plain")
+		require.NoError(t, err)
+
+		result, err := GenDiffContext(context.Background(), file1, file2, "plain")
+		require.NoError(t, err)
+		assert.Equal(t, expected, result)
+	})
+
+	t.Run("already cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err := GenDiffContext(ctx, file1, file2, "stylish")
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+
+	// Count the checks of a full run to cancel at its very last one, during the output
+	counter := &countdownContext{Context: context.Background(), remaining: 1 << 30}
+	_, err := GenDiffContext(counter, file1, file2, "stylish")
+	require.NoError(t, err)
+	total := 1<<30 - counter.remaining
+
+	// The first two checks happen before reading each file, later ones while
+	// building the tree and writing the output
+	for _, checks := range []int{1, 2, 5, 30, total - 1} {
+		t.Run(fmt.Sprintf("cancelled after %d checks", checks), func(t *testing.T) {
+			ctx := &countdownContext{Context: context.Background(), remaining: checks}
+
+			result, err := GenDiffContext(ctx, file1, file2, "stylish")
+			assert.Equal(t, context.Canceled, err)
+			assert.Empty(t, result)
+		})
+	}
+}
+
+func TestGenDiffContext_Errors(t *testing.T) {
+	_, err := GenDiffContext(context.Background(), filepath.Join(t.TempDir(), "missing.json"), "b.json", "plain")
+	assert.ErrorContains(t, err, "file not found")
+}
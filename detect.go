@@ -0,0 +1,42 @@
+package code
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+)
+
+// magicCommentPattern распознаёт комментарий с форматом в первой строке файла:
+// "# format: yaml" или "// format: json"
+var magicCommentPattern = regexp.MustCompile(`^\s*(?:#|//)\s*format:\s*([A-Za-z0-9_-]+)\s*$`)
+
+// yamlKeyPattern распознаёт строку вида "key: value" при угадывании YAML по содержимому
+var yamlKeyPattern = regexp.MustCompile(`^["']?[\w.-]+["']?\s*:(\s|$)`)
+
+// detectFormat определяет формат файла без расширения. Сначала проверяется комментарий
+// с форматом в первой строке (он заменяется пустой строкой, чтобы не мешать JSON и не
+// сдвигать номера строк), затем содержимое: объект JSON начинается с '{', а YAML —
+// со строки вида "key: value". Формат возвращается с ведущей точкой, как расширение.
+func detectFormat(content []byte) (string, []byte, bool) {
+	firstLine, rest, _ := bytes.Cut(content, []byte("\n"))
+	if match := magicCommentPattern.FindSubmatch(bytes.TrimSuffix(firstLine, []byte("\r"))); match != nil {
+		stripped := append([]byte("\n"), rest...)
+		return "." + strings.ToLower(string(match[1])), stripped, true
+	}
+
+	trimmed := bytes.TrimSpace(content)
+	if bytes.HasPrefix(trimmed, []byte("{")) {
+		return ".json", content, true
+	}
+	for _, line := range strings.Split(string(trimmed), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") || line == "---" {
+			continue
+		}
+		if yamlKeyPattern.MatchString(line) {
+			return ".yaml", content, true
+		}
+		break
+	}
+	return "", content, false
+}
@@ -0,0 +1,41 @@
+package code
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenDiff_MagicCommentFormat(t *testing.T) {
+	dir := t.TempDir()
+	file1 := writeTestFile(t, dir, "app-config", "# format: yaml\nhost: hexlet.io\ntimeout: 50\n")
+	file2 := writeTestFile(t, dir, "app-config-new", "// format: json\n{\"host\": \"hexlet.io\", \"timeout\": 20}\n")
+
+	result, err := GenDiff(file1, file2, "plain")
+	require.NoError(t, err)
+	assert.Equal(t, "Property 'timeout' was updated. From 50 to 20", result)
+}
+
+func TestDetectFormat(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		format  string
+		ok      bool
+	}{
+		{"magic yaml", "# format: yaml\na: 1", ".yaml", true},
+		{"magic uppercase", "#format: JSON\r\n{}", ".json", true},
+		{"sniff json", "\n  {\"a\": 1}", ".json", true},
+		{"sniff yaml", "# comment\n---\nkey: value", ".yaml", true},
+		{"unknown", "just text", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			format, _, ok := detectFormat([]byte(tt.content))
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.format, format)
+		})
+	}
+}
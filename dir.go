@@ -0,0 +1,115 @@
+package code
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// configExtensions — расширения встроенных форматов; файлы с ними сравниваются при обходе каталогов
+var configExtensions = map[string]bool{
+	".json": true, ".yml": true, ".yaml": true, ".toml": true, ".ini": true, ".xml": true,
+	".env": true, ".properties": true, ".hcl": true, ".tf": true, ".tfvars": true,
+}
+
+// GenDiffDir рекурсивно сравнивает два каталога. Файлы конфигурации сопоставляются
+// по относительному пути: для различающихся пар выводится diff в формате format,
+// для файлов, которые есть только в одном каталоге, — строка "Only in <dir>: <path>".
+// Одинаковые файлы и файлы других форматов пропускаются.
+func GenDiffDir(dir1, dir2, format string, opts Options) (string, error) {
+	files1, err := configFiles(dir1)
+	if err != nil {
+		return "", err
+	}
+	files2, err := configFiles(dir2)
+	if err != nil {
+		return "", err
+	}
+
+	paths := make([]string, 0, len(files1)+len(files2))
+	for rel := range files1 {
+		paths = append(paths, rel)
+	}
+	for rel := range files2 {
+		if !files1[rel] {
+			paths = append(paths, rel)
+		}
+	}
+	sort.Strings(paths)
+
+	var sections []string
+	for _, rel := range paths {
+		switch {
+		case !files2[rel]:
+			sections = append(sections, fmt.Sprintf("Only in %s: %s", dir1, rel))
+		case !files1[rel]:
+			sections = append(sections, fmt.Sprintf("Only in %s: %s", dir2, rel))
+		default:
+			tree, warnings, err := buildTreeFromFiles(filepath.Join(dir1, rel), filepath.Join(dir2, rel), opts, nil)
+			if err != nil {
+				return "", err
+			}
+			logWarnings(opts, warnings)
+			if tree.Stats().Changes() == 0 {
+				continue
+			}
+			formatted, err := formatDiff(tree, format, opts)
+			if err != nil {
+				return "", fmt.Errorf("failed to format diff: %w", err)
+			}
+			sections = append(sections, fmt.Sprintf("=== %s ===\n%s", rel, formatted))
+		}
+	}
+	return strings.Join(sections, "\n\n"), nil
+}
+
+// configFiles возвращает относительные пути (через "/") файлов конфигурации в каталоге dir
+func configFiles(dir string) (map[string]bool, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read directory %s: %w", dir, err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", dir)
+	}
+
+	files := make(map[string]bool)
+	err = filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() || !isConfigFile(entry.Name()) {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		files[filepath.ToSlash(rel)] = true
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read directory %s: %w", dir, err)
+	}
+	return files, nil
+}
+
+// isConfigFile сообщает, сравнивается ли файл с таким именем при обходе каталогов:
+// расширение (без .gz) должно быть известным форматом или иметь парсер или вычислитель
+func isConfigFile(name string) bool {
+	if strings.EqualFold(filepath.Ext(name), ".gz") {
+		name = name[:len(name)-len(".gz")]
+	}
+	ext := strings.ToLower(filepath.Ext(name))
+	if configExtensions[ext] {
+		return true
+	}
+	if _, ok := lookupParser(ext); ok {
+		return true
+	}
+	_, ok := lookupEvaluator(ext)
+	return ok
+}
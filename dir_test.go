@@ -0,0 +1,45 @@
+package code
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenDiffDir(t *testing.T) {
+	dir1 := t.TempDir()
+	dir2 := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir1, "services"), 0o755))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir2, "services"), 0o755))
+
+	writeTestFile(t, dir1, "app.json", `{"host": "a", "port": 80}`)
+	writeTestFile(t, dir2, "app.json", `{"host": "a", "port": 80}`)
+	writeTestFile(t, filepath.Join(dir1, "services"), "db.yml", "port: 5432\n")
+	writeTestFile(t, filepath.Join(dir2, "services"), "db.yml", "port: 5433\n")
+	writeTestFile(t, dir2, "cache.json", `{"ttl": 60}`)
+	writeTestFile(t, dir1, "legacy.toml", "enabled = true\n")
+	writeTestFile(t, dir2, "README.txt", "not a config")
+
+	result, err := GenDiffDir(dir1, dir2, "plain", Options{})
+	require.NoError(t, err)
+
+	expected := "Only in " + dir2 + ": cache.json\n\n" +
+		"Only in " + dir1 + ": legacy.toml\n\n" +
+		"=== services/db.yml ===\n" +
+		"Property 'port' was updated. From 5432 to 5433"
+	assert.Equal(t, expected, result)
+}
+
+func TestGenDiffDir_NotDirectory(t *testing.T) {
+	dir := t.TempDir()
+	file := writeTestFile(t, dir, "a.json", `{}`)
+
+	_, err := GenDiffDir(filepath.Join(dir, "missing"), dir, "stylish", Options{})
+	assert.ErrorContains(t, err, "failed to read directory")
+
+	_, err = GenDiffDir(dir, file, "stylish", Options{})
+	assert.ErrorContains(t, err, "is not a directory")
+}
@@ -0,0 +1,40 @@
+package code
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// parseDotEnv парсит содержимое .env файла в плоскую карту строк. Пустые строки
+// и комментарии (#) пропускаются, префикс export отбрасывается, а парные кавычки
+// вокруг значения снимаются. Как и в shell, при повторе ключа побеждает последнее
+// значение, а о повторе сообщается предупреждением.
+func parseDotEnv(content []byte) (map[string]interface{}, []string, error) {
+	result := make(map[string]interface{})
+	var warnings []string
+
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimSpace(strings.TrimPrefix(line, "export "))
+
+		key, value, ok := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, nil, fmt.Errorf("failed to parse .env: line %d: expected KEY=VALUE", lineNum)
+		}
+		if _, exists := result[key]; exists {
+			warnings = append(warnings, fmt.Sprintf("duplicate key '%s' at line %d", key, lineNum))
+		}
+		result[key] = iniValue(strings.TrimSpace(value))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse .env: %w", err)
+	}
+	return result, warnings, nil
+}
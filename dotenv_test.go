@@ -0,0 +1,53 @@
+package code
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenDiff_DotEnv(t *testing.T) {
+	dir := t.TempDir()
+	staging := writeTestFile(t, dir, "staging.env", `# staging settings
+APP_ENV=staging
+DATABASE_URL="postgres://db/app?sslmode=disable"
+export LOG_LEVEL=debug
+EMPTY=
+`)
+	production := writeTestFile(t, dir, "production.env", `APP_ENV=production
+DATABASE_URL='postgres://db/app?sslmode=disable'
+export LOG_LEVEL=debug
+EMPTY=""
+PORT=8080
+`)
+
+	result, err := GenDiff(staging, production, "plain")
+	require.NoError(t, err)
+	assert.Equal(t, `Property 'APP_ENV' was updated. From 'staging' to 'production'
+Property 'PORT' was added with value: '8080'`, result)
+}
+
+func TestParseDotEnv(t *testing.T) {
+	data, warnings, err := parseDotEnv([]byte(`# comment
+
+export FOO=bar
+QUOTED="a=b=c"
+SINGLE='x y'
+EMPTY=
+SPACED = value
+FOO=baz
+`))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"FOO":    "baz",
+		"QUOTED": "a=b=c",
+		"SINGLE": "x y",
+		"EMPTY":  "",
+		"SPACED": "value",
+	}, data)
+	assert.Equal(t, []string{"duplicate key 'FOO' at line 8"}, warnings)
+
+	_, _, err = parseDotEnv([]byte("NO_VALUE\n"))
+	assert.EqualError(t, err, "failed to parse .env: line 1: expected KEY=VALUE")
+}
@@ -0,0 +1,33 @@
+package code
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// formatDrifted выводит только ключи, которые есть в обоих файлах, но имеют разные
+// значения: по строке "путь: старое -> новое" на каждый изменившийся лист
+func formatDrifted(node *Node) string {
+	var lines []string
+	walkChanges(node, nil, func(nodePath []string, child *Node) {
+		if child.Type != NodeTypeUpdated {
+			return
+		}
+		lines = append(lines, fmt.Sprintf("%s: %s -> %s",
+			strings.Join(nodePath, "."), driftValue(child.OldValue), driftValue(child.NewValue)))
+	})
+	return strings.Join(lines, "\n")
+}
+
+// driftValue выводит скаляры как в plain формате, а карты и массивы — компактным JSON
+func driftValue(v interface{}) string {
+	switch v.(type) {
+	case map[string]interface{}, []interface{}:
+		data, err := json.Marshal(v)
+		if err == nil {
+			return string(data)
+		}
+	}
+	return renderScalar(v, scalarStylePlain)
+}
@@ -0,0 +1,34 @@
+package code
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenDiff_DriftedFormat(t *testing.T) {
+	content1 := `{
+  "host": "a",
+  "port": 80,
+  "legacy": true,
+  "db": {"user": "x", "pool": {"size": 5, "idle": 1}, "old": 1},
+  "limits": {"cpu": 1}
+}`
+	content2 := `{
+  "host": "a",
+  "port": 8080,
+  "db": {"user": "y", "pool": {"size": 10, "idle": 1}, "ssl": true},
+  "limits": "none",
+  "extra": 1
+}`
+
+	result, err := GenDiffString(content1, content2, "json", "drifted", Options{})
+	require.NoError(t, err)
+
+	expected := `db.pool.size: 5 -> 10
+db.user: 'x' -> 'y'
+limits: {"cpu":1} -> 'none'
+port: 80 -> 8080`
+	assert.Equal(t, expected, result)
+}
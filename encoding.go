@@ -0,0 +1,58 @@
+package code
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Метки порядка байтов, по которым распознаётся кодировка файла
+var (
+	bomUTF8    = []byte{0xEF, 0xBB, 0xBF}
+	bomUTF16LE = []byte{0xFF, 0xFE}
+	bomUTF16BE = []byte{0xFE, 0xFF}
+)
+
+// decodeText приводит содержимое файла к UTF-8: UTF-16 LE/BE с BOM перекодируется,
+// а BOM UTF-8 отбрасывается. Содержимое без BOM возвращается как есть.
+func decodeText(content []byte) ([]byte, error) {
+	switch {
+	case bytes.HasPrefix(content, bomUTF8):
+		return content[len(bomUTF8):], nil
+	case bytes.HasPrefix(content, bomUTF16LE):
+		return decodeUTF16(content[len(bomUTF16LE):], binary.LittleEndian)
+	case bytes.HasPrefix(content, bomUTF16BE):
+		return decodeUTF16(content[len(bomUTF16BE):], binary.BigEndian)
+	default:
+		return content, nil
+	}
+}
+
+// decodeUTF16 перекодирует UTF-16 с указанным порядком байтов в UTF-8. Непарные
+// суррогаты считаются ошибкой, а не заменяются на U+FFFD.
+func decodeUTF16(content []byte, order binary.ByteOrder) ([]byte, error) {
+	if len(content)%2 != 0 {
+		return nil, errors.New("invalid UTF-16 content: odd number of bytes")
+	}
+
+	decoded := make([]byte, 0, len(content)/2)
+	for i := 0; i < len(content); i += 2 {
+		unit := order.Uint16(content[i:])
+		r := rune(unit)
+		if utf16.IsSurrogate(r) {
+			if i+2 >= len(content) {
+				return nil, fmt.Errorf("invalid UTF-16 content: unpaired surrogate at byte %d", i)
+			}
+			r = utf16.DecodeRune(r, rune(order.Uint16(content[i+2:])))
+			if r == utf8.RuneError {
+				return nil, fmt.Errorf("invalid UTF-16 content: unpaired surrogate at byte %d", i)
+			}
+			i += 2
+		}
+		decoded = utf8.AppendRune(decoded, r)
+	}
+	return decoded, nil
+}
@@ -0,0 +1,78 @@
+package code
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+	"unicode/utf16"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// encodeUTF16 encodes text as UTF-16 with a byte order mark
+func encodeUTF16(text string, order binary.AppendByteOrder) []byte {
+	encoded := order.AppendUint16(nil, 0xFEFF)
+	for _, unit := range utf16.Encode([]rune(text)) {
+		encoded = order.AppendUint16(encoded, unit)
+	}
+	return encoded
+}
+
+func TestGenDiff_UTF16AgainstUTF8(t *testing.T) {
+	content := `{"host": "hexlet.io", "greeting": "привет 👋", "nested": {"timeout": 50}}`
+	dir := t.TempDir()
+	utf8File := writeTestFile(t, dir, "utf8.json", content)
+
+	tests := []struct {
+		name  string
+		order binary.AppendByteOrder
+	}{
+		{"little endian", binary.LittleEndian},
+		{"big endian", binary.BigEndian},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			utf16File := filepath.Join(dir, "utf16.json")
+			require.NoError(t, os.WriteFile(utf16File, encodeUTF16(content, tt.order), 0o644))
+
+			result, err := GenDiff(utf16File, utf8File, "plain")
+			require.NoError(t, err)
+			assert.Empty(t, result)
+		})
+	}
+}
+
+func TestDecodeText(t *testing.T) {
+	decoded, err := decodeText([]byte("\xEF\xBB\xBFkey: value"))
+	require.NoError(t, err)
+	assert.Equal(t, "key: value", string(decoded))
+
+	decoded, err = decodeText([]byte("plain"))
+	require.NoError(t, err)
+	assert.Equal(t, "plain", string(decoded))
+
+	_, err = decodeText([]byte{0xFF, 0xFE, 'a'})
+	assert.EqualError(t, err, "invalid UTF-16 content: odd number of bytes")
+}
+
+func TestDecodeText_UnpairedSurrogates(t *testing.T) {
+	// A valid pair decodes to one rune
+	decoded, err := decodeText(encodeUTF16("emoji: 😀", binary.LittleEndian))
+	require.NoError(t, err)
+	assert.Equal(t, "emoji: 😀", string(decoded))
+
+	// A high surrogate followed by a regular unit
+	_, err = decodeText([]byte{0xFF, 0xFE, 0x3D, 0xD8, 'a', 0x00})
+	assert.EqualError(t, err, "invalid UTF-16 content: unpaired surrogate at byte 0")
+
+	// A lone low surrogate
+	_, err = decodeText([]byte{0xFE, 0xFF, 0x00, 'a', 0xDE, 0x00})
+	assert.EqualError(t, err, "invalid UTF-16 content: unpaired surrogate at byte 2")
+
+	// A high surrogate at the end of the content
+	_, err = decodeText([]byte{0xFF, 0xFE, 'a', 0x00, 0x3D, 0xD8})
+	assert.EqualError(t, err, "invalid UTF-16 content: unpaired surrogate at byte 2")
+}
@@ -0,0 +1,64 @@
+package code
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// EnvelopeVersion — версия формата envelope
+const EnvelopeVersion = 1
+
+// envelope — документ с плоским списком изменений и метаданными
+type envelope struct {
+	Version     int            `json:"version"`
+	GeneratedAt string         `json:"generatedAt"`
+	Files       envelopeFiles  `json:"files"`
+	Counts      envelopeCounts `json:"counts"`
+	Changes     []changeRecord `json:"changes"`
+}
+
+// envelopeFiles содержит имена сравниваемых файлов; пустые для строковых входов
+type envelopeFiles struct {
+	Left  string `json:"left"`
+	Right string `json:"right"`
+}
+
+// envelopeCounts содержит количество изменений по типам; Total равен длине changes
+type envelopeCounts struct {
+	Added   int `json:"added"`
+	Removed int `json:"removed"`
+	Updated int `json:"updated"`
+	Total   int `json:"total"`
+}
+
+// formatEnvelope форматирует изменения в envelope формате. Время генерации берётся
+// из opts.Now, если она задана, иначе используется текущее время.
+func formatEnvelope(node *Node, opts Options) (string, error) {
+	changes, err := changeRecords(node)
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now
+	if opts.Now != nil {
+		now = opts.Now
+	}
+
+	stats := node.Stats()
+	data, err := json.MarshalIndent(envelope{
+		Version:     EnvelopeVersion,
+		GeneratedAt: now().UTC().Format(time.RFC3339),
+		Files:       envelopeFiles{Left: opts.files[0], Right: opts.files[1]},
+		Counts: envelopeCounts{
+			Added:   stats.Added,
+			Removed: stats.Removed,
+			Updated: stats.Updated,
+			Total:   stats.Changes(),
+		},
+		Changes: changes,
+	}, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
@@ -0,0 +1,64 @@
+package code
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenDiff_EnvelopeFormat(t *testing.T) {
+	file1 := createTempFile(t, `{"a": 1, "b": {"c": "x"}, "d": true}`)
+	file2 := createTempFile(t, `{"a": 2, "b": {"c": "y", "e": null}}`)
+	defer func() {
+		if err := os.Remove(file1); err != nil {
+			t.Logf("failed to remove temp file %s: %v", file1, err)
+		}
+		if err := os.Remove(file2); err != nil {
+			t.Logf("failed to remove temp file %s: %v", file2, err)
+		}
+	}()
+
+	now := func() time.Time { return time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC) }
+	result, err := GenDiffWithOptions(file1, file2, "envelope", Options{Now: now})
+	require.NoError(t, err)
+
+	var doc struct {
+		Version     int    `json:"version"`
+		GeneratedAt string `json:"generatedAt"`
+		Files       struct {
+			Left  string `json:"left"`
+			Right string `json:"right"`
+		} `json:"files"`
+		Counts struct {
+			Added   int `json:"added"`
+			Removed int `json:"removed"`
+			Updated int `json:"updated"`
+			Total   int `json:"total"`
+		} `json:"counts"`
+		Changes []map[string]interface{} `json:"changes"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(result), &doc))
+
+	assert.Equal(t, 1, doc.Version)
+	assert.Equal(t, "2024-03-01T12:30:00Z", doc.GeneratedAt)
+	assert.Equal(t, file1, doc.Files.Left)
+	assert.Equal(t, file2, doc.Files.Right)
+	assert.Equal(t, 1, doc.Counts.Added)
+	assert.Equal(t, 1, doc.Counts.Removed)
+	assert.Equal(t, 2, doc.Counts.Updated)
+	assert.Equal(t, len(doc.Changes), doc.Counts.Total)
+
+	assert.Equal(t, map[string]interface{}{"path": "a", "type": "updated", "old": 1.0, "new": 2.0}, doc.Changes[0])
+	assert.Equal(t, map[string]interface{}{"path": "b.e", "type": "added", "new": nil}, doc.Changes[2])
+}
+
+func TestGenDiff_EnvelopeEmpty(t *testing.T) {
+	result, err := GenDiffString(`{"a": 1}`, `{"a": 1}`, "json", "envelope", Options{Now: time.Now})
+	require.NoError(t, err)
+	assert.Contains(t, result, `"changes": []`)
+	assert.Contains(t, result, `"total": 0`)
+}
@@ -0,0 +1,57 @@
+package code
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+	"sync"
+)
+
+// Evaluator вычисляет конфигурацию, записанную на языке шаблонов (Jsonnet, CUE),
+// и возвращает результат в виде JSON. filePath передаётся для разрешения импортов.
+type Evaluator func(content []byte, filePath string) ([]byte, error)
+
+var (
+	evaluatorsMu sync.RWMutex
+	evaluators   = make(map[string]Evaluator)
+)
+
+// RegisterEvaluator регистрирует вычислитель для расширения файла (например, ".jsonnet").
+// Файлы с этим расширением сначала вычисляются, а затем сравниваются как JSON.
+// Ошибка вычисления возвращается как ошибка парсинга с сообщением инструмента.
+func RegisterEvaluator(ext string, evaluator Evaluator) {
+	evaluatorsMu.Lock()
+	defer evaluatorsMu.Unlock()
+	evaluators[strings.ToLower(ext)] = evaluator
+}
+
+// lookupEvaluator возвращает вычислитель, зарегистрированный для расширения
+func lookupEvaluator(ext string) (Evaluator, bool) {
+	evaluatorsMu.RLock()
+	defer evaluatorsMu.RUnlock()
+	evaluator, ok := evaluators[ext]
+	return evaluator, ok
+}
+
+// CommandEvaluator возвращает вычислитель, запускающий внешний инструмент с путём
+// к файлу последним аргументом, например CommandEvaluator("jsonnet") или
+// CommandEvaluator("cue", "export", "--out", "json"). Инструмент должен быть в PATH
+// и печатать JSON в stdout.
+func CommandEvaluator(name string, args ...string) Evaluator {
+	return func(_ []byte, filePath string) ([]byte, error) {
+		var stdout, stderr bytes.Buffer
+		// nolint:gosec // Команду задаёт вызывающий код при регистрации, а не пользовательский ввод
+		cmd := exec.Command(name, append(args, filePath)...)
+		cmd.Stdout = &stdout
+		cmd.Stderr = &stderr
+
+		if err := cmd.Run(); err != nil {
+			if message := strings.TrimSpace(stderr.String()); message != "" {
+				return nil, fmt.Errorf("%s: %s", name, message)
+			}
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		return stdout.Bytes(), nil
+	}
+}
@@ -0,0 +1,64 @@
+package code
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegisterEvaluator(t *testing.T) {
+	// A fake evaluator: "key = value" lines become a JSON object
+	RegisterEvaluator(".jsonnet", func(content []byte, _ string) ([]byte, error) {
+		if strings.Contains(string(content), "error") {
+			return nil, errors.New("RUNTIME ERROR: boom")
+		}
+		var fields []string
+		for _, line := range strings.Split(strings.TrimSpace(string(content)), "\n") {
+			parts := strings.SplitN(line, "=", 2)
+			fields = append(fields, `"`+strings.TrimSpace(parts[0])+`":`+strings.TrimSpace(parts[1]))
+		}
+		return []byte("{" + strings.Join(fields, ",") + "}"), nil
+	})
+
+	file1 := createTempFileWithExt(t, ".jsonnet", "host = \"hexlet.io\"\ntimeout = 50")
+	file2 := createTempFileWithExt(t, ".jsonnet", "host = \"hexlet.io\"\ntimeout = 20")
+	broken := createTempFileWithExt(t, ".jsonnet", "error")
+	defer func() {
+		for _, file := range []string{file1, file2, broken} {
+			if err := os.Remove(file); err != nil {
+				t.Logf("failed to remove temp file %s: %v", file, err)
+			}
+		}
+	}()
+
+	result, err := GenDiff(file1, file2, "plain")
+	require.NoError(t, err)
+	assert.Equal(t, "Property 'timeout' was updated. From 50 to 20", result)
+
+	// Evaluation errors surface as parse errors with the tool's message
+	_, err = GenDiff(broken, file2, "plain")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to parse "+broken)
+	assert.Contains(t, err.Error(), "RUNTIME ERROR: boom")
+}
+
+func TestCommandEvaluator(t *testing.T) {
+	file := createTempFile(t, `{"a":1}`)
+	defer func() {
+		if err := os.Remove(file); err != nil {
+			t.Logf("failed to remove temp file %s: %v", file, err)
+		}
+	}()
+
+	output, err := CommandEvaluator("cat")(nil, file)
+	require.NoError(t, err)
+	assert.Equal(t, `{"a":1}`, string(output))
+
+	_, err = CommandEvaluator("ls")(nil, file+".missing")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "ls:")
+}
@@ -0,0 +1,266 @@
+package code
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// includePaths возвращает копию дерева, в которой оставлены только узлы, лежащие
+// под одним из шаблонов, и вложенные узлы на пути к ним
+func includePaths(node *Node, patterns []string) *Node {
+	splitPatterns := make([][]string, 0, len(patterns))
+	for _, pattern := range patterns {
+		splitPatterns = append(splitPatterns, strings.Split(pattern, "."))
+	}
+	return includeChildren(node, nil, splitPatterns)
+}
+
+// includeChildren рекурсивно отбирает дочерние узлы, подходящие под шаблоны
+func includeChildren(node *Node, nodePath []string, patterns [][]string) *Node {
+	result := *node
+	result.Children = []*Node{}
+
+	for _, child := range node.Children {
+		childPath := appendPath(nodePath, child.name())
+
+		included, descend := false, false
+		for _, pattern := range patterns {
+			if matchPathPrefix(pattern, childPath) {
+				included = true
+				break
+			}
+			if len(pattern) > len(childPath) && matchPathPrefix(pattern[:len(childPath)], childPath) {
+				descend = true
+			}
+		}
+
+		switch {
+		case included:
+			result.Children = append(result.Children, child)
+		case descend && child.Type == NodeTypeNested:
+			filtered := includeChildren(child, childPath, patterns)
+			if len(filtered.Children) > 0 {
+				result.Children = append(result.Children, filtered)
+			}
+		}
+	}
+
+	return &result
+}
+
+// matchPathPrefix проверяет, что шаблон совпадает с началом пути посегментно
+func matchPathPrefix(pattern, nodePath []string) bool {
+	if len(pattern) > len(nodePath) {
+		return false
+	}
+	for i, segment := range pattern {
+		matched, err := path.Match(segment, nodePath[i])
+		if err != nil || !matched {
+			return false
+		}
+	}
+	return true
+}
+
+// ignorePaths возвращает копию дерева без узлов, лежащих под одним из шаблонов.
+// Вложенные узлы, у которых не осталось детей, тоже удаляются.
+func ignorePaths(node *Node, patterns []string) *Node {
+	splitPatterns := make([][]string, 0, len(patterns))
+	for _, pattern := range patterns {
+		splitPatterns = append(splitPatterns, strings.Split(pattern, "."))
+	}
+	return ignoreChildren(node, nil, splitPatterns)
+}
+
+// ignoreChildren рекурсивно отбрасывает дочерние узлы, подходящие под шаблоны
+func ignoreChildren(node *Node, nodePath []string, patterns [][]string) *Node {
+	result := *node
+	result.Children = []*Node{}
+
+	for _, child := range node.Children {
+		childPath := appendPath(nodePath, child.name())
+
+		ignored := false
+		for _, pattern := range patterns {
+			if matchPathPrefix(pattern, childPath) {
+				ignored = true
+				break
+			}
+		}
+		if ignored {
+			continue
+		}
+
+		if child.Type == NodeTypeNested {
+			filtered := ignoreChildren(child, childPath, patterns)
+			if len(filtered.Children) > 0 {
+				result.Children = append(result.Children, filtered)
+			}
+			continue
+		}
+		result.Children = append(result.Children, child)
+	}
+
+	return &result
+}
+
+// hidePaths возвращает копию данных без ключей, лежащих под одним из шаблонов.
+// Данные скрываются до сравнения, поэтому скрытые ветки не попадают ни в узлы
+// дерева, ни в значения добавленных, удалённых и изменённых ключей.
+func hidePaths(data map[string]interface{}, patterns []string) map[string]interface{} {
+	splitPatterns := make([][]string, 0, len(patterns))
+	for _, pattern := range patterns {
+		splitPatterns = append(splitPatterns, strings.Split(pattern, "."))
+	}
+	hidden, _ := hideValue(data, nil, splitPatterns).(map[string]interface{})
+	return hidden
+}
+
+// hideValue рекурсивно копирует значение, отбрасывая ключи и элементы массивов,
+// путь к которым совпадает с шаблоном
+func hideValue(v interface{}, nodePath []string, patterns [][]string) interface{} {
+	isHidden := func(childPath []string) bool {
+		for _, pattern := range patterns {
+			if matchPathPrefix(pattern, childPath) {
+				return true
+			}
+		}
+		return false
+	}
+
+	switch val := v.(type) {
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(val))
+		for key, item := range val {
+			childPath := appendPath(nodePath, key)
+			if !isHidden(childPath) {
+				result[key] = hideValue(item, childPath, patterns)
+			}
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, 0, len(val))
+		for i, item := range val {
+			childPath := appendPath(nodePath, strconv.Itoa(i))
+			if !isHidden(childPath) {
+				result = append(result, hideValue(item, childPath, patterns))
+			}
+		}
+		return result
+	default:
+		return v
+	}
+}
+
+// onlyTypes возвращает копию дерева, в которой оставлены только узлы указанных типов.
+// Вложенные узлы обходятся всегда и остаются, если в них нашлись подходящие узлы.
+func onlyTypes(node *Node, types []string) *Node {
+	result := *node
+	result.Children = []*Node{}
+
+	for _, child := range node.Children {
+		if child.Type == NodeTypeNested {
+			filtered := onlyTypes(child, types)
+			if len(filtered.Children) > 0 {
+				result.Children = append(result.Children, filtered)
+			}
+			continue
+		}
+		if slices.Contains(types, child.Type) {
+			result.Children = append(result.Children, child)
+		}
+	}
+
+	return &result
+}
+
+// keyMatcher проверяет ключи по списку IgnoreKeys: простые имена совпадают с ключом
+// на любой глубине, glob-шаблоны и регулярные выражения в слешах (/.*_id/) —
+// с полным путём через точку. Шаблоны компилируются один раз на сравнение.
+type keyMatcher struct {
+	names   map[string]bool
+	globs   []string
+	regexps []*regexp.Regexp
+}
+
+// newKeyMatcher разбирает шаблоны; некорректные шаблоны пропускаются с предупреждением
+func newKeyMatcher(patterns []string) (*keyMatcher, []string) {
+	matcher := &keyMatcher{names: make(map[string]bool)}
+	var warnings []string
+	for _, pattern := range patterns {
+		switch {
+		case len(pattern) > 2 && strings.HasPrefix(pattern, "/") && strings.HasSuffix(pattern, "/"):
+			re, err := regexp.Compile("^(?:" + pattern[1:len(pattern)-1] + ")$")
+			if err != nil {
+				warnings = append(warnings, fmt.Sprintf("invalid ignore pattern %s: %v", pattern, err))
+				continue
+			}
+			matcher.regexps = append(matcher.regexps, re)
+		case strings.ContainsAny(pattern, ".*?["):
+			if _, err := path.Match(pattern, ""); err != nil {
+				warnings = append(warnings, fmt.Sprintf("invalid ignore pattern %s: %v", pattern, err))
+				continue
+			}
+			matcher.globs = append(matcher.globs, pattern)
+		default:
+			matcher.names[pattern] = true
+		}
+	}
+	return matcher, warnings
+}
+
+// matches проверяет ключ key, лежащий по пути nodePath
+func (m *keyMatcher) matches(key string, nodePath []string) bool {
+	if m.names[key] {
+		return true
+	}
+	if len(m.globs) == 0 && len(m.regexps) == 0 {
+		return false
+	}
+	dotted := strings.Join(nodePath, ".")
+	for _, glob := range m.globs {
+		if matched, _ := path.Match(glob, dotted); matched {
+			return true
+		}
+	}
+	for _, re := range m.regexps {
+		if re.MatchString(dotted) {
+			return true
+		}
+	}
+	return false
+}
+
+// dropKeys возвращает копию данных без ключей, подходящих под matcher, на любой
+// глубине, включая объекты внутри массивов
+func dropKeys(data map[string]interface{}, matcher *keyMatcher) map[string]interface{} {
+	dropped, _ := dropKeyValue(data, nil, matcher).(map[string]interface{})
+	return dropped
+}
+
+// dropKeyValue рекурсивно копирует значение, отбрасывая подходящие ключи
+func dropKeyValue(v interface{}, nodePath []string, matcher *keyMatcher) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(val))
+		for key, item := range val {
+			childPath := appendPath(nodePath, key)
+			if !matcher.matches(key, childPath) {
+				result[key] = dropKeyValue(item, childPath, matcher)
+			}
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(val))
+		for i, item := range val {
+			result[i] = dropKeyValue(item, appendPath(nodePath, strconv.Itoa(i)), matcher)
+		}
+		return result
+	default:
+		return v
+	}
+}
@@ -0,0 +1,187 @@
+package code
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenDiff_IncludePaths(t *testing.T) {
+	file1 := createTempFile(t, `{
+  "metadata": {"name": "web", "labels": {"app": "web", "tier": "front"}},
+  "spec": {"replicas": 2, "image": "web:1", "ports": {"http": 80}},
+  "status": {"ready": 1}
+}`)
+	file2 := createTempFile(t, `{
+  "metadata": {"name": "web-v2", "labels": {"app": "web", "tier": "back"}},
+  "spec": {"replicas": 3, "image": "web:2", "ports": {"http": 8080}},
+  "status": {"ready": 3}
+}`)
+	defer func() {
+		if err := os.Remove(file1); err != nil {
+			t.Logf("failed to remove temp file %s: %v", file1, err)
+		}
+		if err := os.Remove(file2); err != nil {
+			t.Logf("failed to remove temp file %s: %v", file2, err)
+		}
+	}()
+
+	opts := Options{IncludePaths: []string{"spec.replicas", "metadata.labels.t*"}}
+
+	result, err := GenDiffWithOptions(file1, file2, "plain", opts)
+	require.NoError(t, err)
+	assert.Equal(t, "Property 'metadata.labels.tier' was updated. From 'front' to 'back'\n"+
+		"Property 'spec.replicas' was updated. From 2 to 3", result)
+
+	result, err = GenDiffWithOptions(file1, file2, "stylish", opts)
+	require.NoError(t, err)
+	assert.NotContains(t, result, "status")
+	assert.NotContains(t, result, "image")
+	assert.NotContains(t, result, "app")
+}
+
+func TestMatchPathPrefix(t *testing.T) {
+	assert.True(t, matchPathPrefix([]string{"spec"}, []string{"spec", "replicas"}))
+	assert.True(t, matchPathPrefix([]string{"spec", "*"}, []string{"spec", "replicas"}))
+	assert.False(t, matchPathPrefix([]string{"spec", "replicas"}, []string{"spec"}))
+	assert.False(t, matchPathPrefix([]string{"status"}, []string{"spec", "replicas"}))
+}
+
+func TestGenDiff_IgnorePaths(t *testing.T) {
+	content1 := `{"meta": {"updated": "mon", "owner": "a"}, "items": {"x": {"ts": 1, "v": 1}, "y": {"ts": 2}}}`
+	content2 := `{"meta": {"updated": "tue", "owner": "b"}, "items": {"x": {"ts": 3, "v": 2}, "y": {"ts": 4}}}`
+
+	result, err := GenDiffString(content1, content2, "json", "plain", Options{
+		IgnorePaths: []string{"meta.updated", "items.*.ts"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "Property 'items.x.v' was updated. From 1 to 2\nProperty 'meta.owner' was updated. From 'a' to 'b'", result)
+}
+
+func TestGenDiff_HiddenPaths(t *testing.T) {
+	content1 := `{
+		"db": {"host": "db1", "password": "hunter1"},
+		"secrets": {"api": {"key": "k-1"}},
+		"users": [{"name": "ann", "token": "tok-1"}]
+	}`
+	content2 := `{
+		"db": {"host": "db2", "password": "hunter2"},
+		"secrets": {"api": {"key": "k-2"}},
+		"users": [{"name": "bob", "token": "tok-2"}],
+		"vault": {"region": "eu", "token": "tok-3"}
+	}`
+	opts := Options{HiddenPaths: []string{"db.password", "secrets", "*.token", "users.*.token"}}
+
+	for _, format := range []string{"stylish", "plain", "json", "patch", "csv", "ndjson", "envelope", "drifted", "section-stats"} {
+		t.Run(format, func(t *testing.T) {
+			result, err := GenDiffString(content1, content2, "json", format, opts)
+			require.NoError(t, err)
+			assert.Contains(t, result, "db")
+			for _, hidden := range []string{"password", "hunter", "secrets", "k-1", "token", "tok-"} {
+				assert.NotContains(t, result, hidden)
+			}
+		})
+	}
+
+	// Unlike IgnorePaths, hidden keys are also removed from values of added keys
+	result, err := GenDiffString(content1, content2, "json", "stylish", opts)
+	require.NoError(t, err)
+	assert.Contains(t, result, "  + vault: {\n        region: eu\n    }")
+}
+
+func TestGenDiff_IgnoreKeys(t *testing.T) {
+	content1 := `{"timestamp": 1, "name": "api", "build": {"buildId": "a1", "tag": "v1"}, "jobs": [{"buildId": "x"}]}`
+	content2 := `{"timestamp": 2, "name": "api", "build": {"buildId": "b2", "tag": "v2"}, "jobs": [{"buildId": "y"}]}`
+	opts := Options{IgnoreKeys: []string{"timestamp", "buildId"}}
+
+	result, err := GenDiffString(content1, content2, "json", "plain", opts)
+	require.NoError(t, err)
+	assert.Equal(t, "Property 'build.tag' was updated. From 'v1' to 'v2'", result)
+
+	// Only ignored keys changed
+	result, err = GenDiffString(`{"timestamp": 1, "name": "api"}`, `{"timestamp": 2, "name": "api"}`, "json", "plain", opts)
+	require.NoError(t, err)
+	assert.Empty(t, result)
+}
+
+func TestGenDiff_IgnoreKeyPatterns(t *testing.T) {
+	content1 := `{"metadata": {"uid": "a", "labels": {"app": "web"}}, "user_id": 1, "db": {"owner_id": 2, "host": "h1"}, "id_list": [1]}`
+	content2 := `{"metadata": {"uid": "b", "labels": {"app": "api"}}, "user_id": 3, "db": {"owner_id": 4, "host": "h2"}, "id_list": [2]}`
+
+	t.Run("regex matches nested paths", func(t *testing.T) {
+		result, err := GenDiffString(content1, content2, "json", "plain", Options{IgnoreKeys: []string{"/.*_id/"}})
+		require.NoError(t, err)
+		assert.NotContains(t, result, "user_id")
+		assert.NotContains(t, result, "owner_id")
+		assert.Contains(t, result, "Property 'db.host' was updated")
+		assert.Contains(t, result, "Property 'id_list' was updated")
+	})
+
+	t.Run("regex matches leaf keys only", func(t *testing.T) {
+		result, err := GenDiffString(content1, content2, "json", "plain", Options{IgnoreKeys: []string{`/db\.[a-z]+_id/`}})
+		require.NoError(t, err)
+		// The pattern is matched against the full path, so the top-level user_id stays
+		assert.NotContains(t, result, "owner_id")
+		assert.Contains(t, result, "Property 'user_id' was updated")
+		assert.Contains(t, result, "Property 'db.host' was updated")
+	})
+
+	t.Run("glob drops a family of keys", func(t *testing.T) {
+		result, err := GenDiffString(content1, content2, "json", "plain", Options{IgnoreKeys: []string{"metadata.*"}})
+		require.NoError(t, err)
+		assert.NotContains(t, result, "metadata")
+		assert.Contains(t, result, "Property 'user_id' was updated")
+	})
+
+	t.Run("invalid pattern warns", func(t *testing.T) {
+		var logs strings.Builder
+		_, err := GenDiffString(content1, content2, "json", "plain", Options{
+			IgnoreKeys: []string{"/(/"},
+			Logger:     log.New(&logs, "", 0),
+		})
+		require.NoError(t, err)
+		assert.Contains(t, logs.String(), "invalid ignore pattern /(/")
+	})
+}
+
+func TestGenDiff_OnlyTypes(t *testing.T) {
+	content1 := `{"host": "a", "port": 80, "db": {"user": "x", "pool": 5, "ssl": {"mode": "on"}}}`
+	content2 := `{"host": "a", "port": 8080, "db": {"user": "x", "ssl": {"mode": "on", "cert": "c"}}, "debug": true}`
+
+	result, err := GenDiffString(content1, content2, "json", "stylish", Options{OnlyTypes: []string{NodeTypeAdded, NodeTypeRemoved}})
+	require.NoError(t, err)
+	assert.Equal(t, "{\n    db: {\n      - pool: 5\n        ssl: {\n          + cert: c\n        }\n    }\n  + debug: true\n}", result)
+	assert.NotContains(t, result, "host")
+	assert.NotContains(t, result, "port")
+
+	result, err = GenDiffString(content1, content2, "json", "plain", Options{OnlyTypes: []string{NodeTypeRemoved}})
+	require.NoError(t, err)
+	assert.Equal(t, "Property 'db.pool' was removed", result)
+}
+
+func TestGenDiff_ChangesOnly(t *testing.T) {
+	file1 := filepath.Join("testdata", "fixture", "file1.json")
+	file2 := filepath.Join("testdata", "fixture", "file2.json")
+
+	result, err := GenDiffWithOptions(file1, file2, "stylish", Options{ChangesOnly: true})
+	require.NoError(t, err)
+	for _, unchanged := range []string{"setting1", "    key: value\n          + ops", "foo: bar", "language"} {
+		assert.NotContains(t, result, unchanged)
+	}
+	assert.Contains(t, result, "          + ops: vops")
+	assert.Contains(t, result, "      - baz: bas\n      + baz: bars\n      - nest: {")
+
+	// A section where nothing changed disappears entirely
+	result, err = GenDiffString(
+		`{"app": {"name": "web", "port": 80}, "db": {"host": "a", "user": "x"}}`,
+		`{"app": {"name": "web", "port": 8080}, "db": {"host": "a", "user": "x"}}`,
+		"json", "stylish", Options{ChangesOnly: true},
+	)
+	require.NoError(t, err)
+	assert.Equal(t, "{\n    app: {\n      - port: 80\n      + port: 8080\n    }\n}", result)
+}
@@ -0,0 +1,32 @@
+package code
+
+import (
+	"strings"
+	"sync"
+)
+
+// Formatter выводит дерево различий в пользовательском формате
+type Formatter func(node *Node) (string, error)
+
+var (
+	formattersMu sync.RWMutex
+	formatters   = make(map[string]Formatter)
+)
+
+// RegisterFormatter регистрирует формат вывода с именем name (регистр не важен).
+// Зарегистрированные форматы проверяются раньше встроенных, поэтому имя встроенного
+// формата заменяет его; повторная регистрация заменяет прежний форматтер. Вывод
+// форматтера собирается в строку целиком, пути узлов (Node.Path) в дереве заполнены.
+func RegisterFormatter(name string, fn Formatter) {
+	formattersMu.Lock()
+	defer formattersMu.Unlock()
+	formatters[strings.ToLower(name)] = fn
+}
+
+// lookupFormatter возвращает форматтер, зарегистрированный под именем формата
+func lookupFormatter(name string) (Formatter, bool) {
+	formattersMu.RLock()
+	defer formattersMu.RUnlock()
+	formatter, ok := formatters[strings.ToLower(name)]
+	return formatter, ok
+}
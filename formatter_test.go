@@ -0,0 +1,46 @@
+package code
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func unregisterFormatter(t *testing.T, name string) {
+	t.Cleanup(func() {
+		formattersMu.Lock()
+		defer formattersMu.Unlock()
+		delete(formatters, name)
+	})
+}
+
+func TestRegisterFormatter(t *testing.T) {
+	// A trivial custom format: the number of changes
+	RegisterFormatter("Count", func(node *Node) (string, error) {
+		return fmt.Sprintf("%d changes", node.Stats().Changes()), nil
+	})
+	unregisterFormatter(t, "count")
+
+	dir := t.TempDir()
+	file1 := writeTestFile(t, dir, "a.json", `{"host": "a", "port": 80}`)
+	file2 := writeTestFile(t, dir, "b.json", `{"host": "b", "port": 80, "debug": true}`)
+
+	result, err := GenDiff(file1, file2, "count")
+	require.NoError(t, err)
+	assert.Equal(t, "2 changes", result)
+
+	result, err = GenDiff(file1, file2, "COUNT")
+	require.NoError(t, err)
+	assert.Equal(t, "2 changes", result)
+
+	RegisterFormatter("failing", func(*Node) (string, error) {
+		return "", errors.New("boom")
+	})
+	unregisterFormatter(t, "failing")
+
+	_, err = GenDiff(file1, file2, "failing")
+	assert.ErrorContains(t, err, "boom")
+}
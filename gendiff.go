@@ -1,12 +1,22 @@
 package code
 
 import (
+	"bufio"
+	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
+	"io"
+	"log"
 	"os"
 	"path/filepath"
+	"slices"
 	"sort"
+	"strconv"
 	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
 
 	"gopkg.in/yaml.v3"
 )
@@ -21,109 +31,626 @@ const (
 	NullValue         = "null"
 )
 
-// Node представляет узел в дереве различий
+// Порядок дочерних узлов в json формате
+const (
+	// JSONChildOrderKey упорядочивает узлы по ключам
+	JSONChildOrderKey = "key"
+	// JSONChildOrderType группирует узлы по типу: removed, added, updated, nested, unchanged
+	JSONChildOrderType = "type"
+)
+
+// Node представляет узел в дереве различий.
+// Узлы ключей карты заполняют Key, узлы элементов массива — Index.
 type Node struct {
-	Type     string      `json:"type"`
-	Key      string      `json:"key,omitempty"`
-	Value    interface{} `json:"value,omitempty"`
-	OldValue interface{} `json:"oldValue,omitempty"`
-	NewValue interface{} `json:"newValue,omitempty"`
-	Children []*Node     `json:"children,omitempty"`
+	Type     string      `json:"type" yaml:"type"`
+	Key      string      `json:"key,omitempty" yaml:"key,omitempty"`
+	Index    *int        `json:"index,omitempty" yaml:"index,omitempty"`
+	Value    interface{} `json:"value,omitempty" yaml:"value,omitempty"`
+	OldValue interface{} `json:"oldValue,omitempty" yaml:"oldValue,omitempty"`
+	NewValue interface{} `json:"newValue,omitempty" yaml:"newValue,omitempty"`
+	Children []*Node     `json:"children,omitempty" yaml:"children,omitempty"`
+	// Path — полный путь к узлу от корня: ключи карт и индексы элементов массивов.
+	// В json и yaml форматах выводится только с Options.NodePaths.
+	Path []string `json:"path,omitempty" yaml:"path,omitempty"`
+
+	// keyField — поле, по которому сопоставлены элементы массива объектов (ArrayKeyFields):
+	// дочерние узлы такого массива названы значениями поля, а не индексами
+	keyField string
+	// rightValue — значение из второго файла у неизменённого узла. Оно может отличаться
+	// от Value видом (например, "50" и 50 с LooseTypes); читается в TypeChanges и не выводится.
+	rightValue interface{}
+}
+
+// name возвращает имя узла в пути: ключ карты или индекс элемента массива
+func (n *Node) name() string {
+	if n.Index != nil {
+		return strconv.Itoa(*n.Index)
+	}
+	return n.Key
+}
+
+// isArrayNode проверяет, описывает ли вложенный узел различия элементов массива
+func isArrayNode(n *Node) bool {
+	return len(n.Children) > 0 && n.Children[0].Index != nil
+}
+
+// Options задаёт дополнительные параметры сравнения
+type Options struct {
+	// ValueSetDiff сравнивает только мультимножества скалярных значений, игнорируя ключи и пути
+	ValueSetDiff bool
+	// TrimStrings сравнивает строки без учёта пробелов в начале и в конце
+	TrimStrings bool
+	// WarnOnWhitespaceOnly работает как TrimStrings, но сообщает о каждой строке,
+	// совпавшей только после обрезки пробелов
+	WarnOnWhitespaceOnly bool
+	// Logger получает предупреждения; по умолчанию они пишутся в stderr
+	Logger *log.Logger
+	// LCSArrays сравнивает массивы скаляров по наибольшей общей подпоследовательности,
+	// так что вставка элемента даёт один added узел вместо каскада updated
+	LCSArrays bool
+	// LooseTypes сравнивает значения разных типов по строковому представлению, так что
+	// число 1 и строка "1" считаются равными; по умолчанию разный вид значения — изменение
+	LooseTypes bool
+	// SetArrays сравнивает массивы как множества: порядок и повторы элементов не важны,
+	// так что [a, b, c] и [c, b, a] равны, а [a, b] и [a, b, c] дают один added узел
+	SetArrays bool
+	// IndexArrays сравнивает массивы поэлементно по индексу: каждый элемент даёт
+	// отдельный узел (added, removed, updated или unchanged), а объекты внутри массивов
+	// сравниваются рекурсивно. Без опции массив при любом изменении выводится целиком.
+	IndexArrays bool
+	// ShouldDescend вызывается перед рекурсивным сравнением вложенных карт, существующих
+	// в обоих файлах; если функция возвращает false, ветка пропускается и не даёт узлов
+	ShouldDescend func(path []string) bool
+	// IncludePaths ограничивает вывод изменениями под указанными путями; пути задаются
+	// через точку, каждый сегмент может быть glob-шаблоном (например, spec.*.replicas)
+	IncludePaths []string
+	// IgnorePaths исключает из вывода изменения под указанными путями; пути задаются
+	// через точку, сегменты могут быть glob-шаблонами
+	IgnorePaths []string
+	// IgnoreKeys убирает из обоих входов ключи до сравнения. Простое имя (timestamp)
+	// совпадает с ключом на любой глубине, glob-шаблон (metadata.*) и регулярное
+	// выражение в слешах (/.*_id/) — с полным путём через точку
+	IgnoreKeys []string
+	// OnlyTypes оставляет в выводе только узлы указанных типов (например, NodeTypeAdded
+	// и NodeTypeRemoved); вложенные узлы обходятся, чтобы не потерять глубокие изменения
+	OnlyTypes []string
+	// ChangesOnly убирает из вывода неизменённые узлы; вложенные узлы, в которых
+	// не осталось изменений, тоже убираются
+	ChangesOnly bool
+	// HiddenPaths полностью скрывает ключи под указанными путями (сегменты могут быть
+	// glob-шаблонами): в отличие от IgnorePaths они удаляются и из значений
+	// добавленных, удалённых и изменённых объектов, так что не видны ни в одном формате
+	HiddenPaths []string
+	// ArrayKeyFields задаёт поля, по которым сопоставляются элементы массивов объектов
+	// (например, name у контейнеров Kubernetes); используется первое поле, которое есть
+	// во всех элементах обоих массивов
+	ArrayKeyFields []string
+	// Baseline — ранее сохранённый дифф (см. LoadBaseline); изменения с тем же путём
+	// и типом считаются принятыми и не выводятся
+	Baseline *Node
+	// StopAtFirstChange прекращает построение дерева на первом найденном изменении;
+	// дерево получается неполным, но HasChanges для него остаётся корректным.
+	// С фильтрами IncludePaths, IgnorePaths, Baseline, OnlyTypes и ChangesOnly дерево
+	// строится полностью: первое изменение может быть отброшено фильтром.
+	StopAtFirstChange bool
+	// FloatPrecision задаёт число знаков после запятой, до которого округляются числа
+	// при сравнении и выводе; 0 округляет до целых, nil сохраняет полную точность
+	FloatPrecision *int
+	// FloatEpsilon считает числа равными, если они отличаются не больше чем на epsilon
+	// (3.14 и 3.1400000001 при 1e-9); целые и дробные сравниваются одинаково. 0 отключает допуск
+	FloatEpsilon float64
+	// SignificantFigures задаёт число значащих цифр, до которого округляются числа
+	// при сравнении (12345 и 12300 равны при 3); 0 отключает округление
+	SignificantFigures int
+	// StringSimilarityThreshold считает строки равными, если их сходство по Левенштейну
+	// (1 - расстояние / длина более длинной строки) не меньше порога; 0 и 1 требуют
+	// точного совпадения
+	StringSimilarityThreshold float64
+	// ShowNumericDelta дописывает к обновлённым числовым значениям в stylish и plain
+	// форматах абсолютное и относительное изменение: "(-30, -60%)"
+	ShowNumericDelta bool
+	// StrictTypes возвращает ошибку, если в данных встретилось значение типа, который
+	// сравнение не обрабатывает явно (например, time.Time или произвольная структура),
+	// вместо того чтобы вывести его через %v
+	StrictTypes bool
+	// NestPropertiesKeys раскрывает ключи .properties файлов через точку (server.port)
+	// во вложенные объекты, чтобы stylish формат группировал их
+	NestPropertiesKeys bool
+	// Color окрашивает в stylish формате добавленные строки в зелёный, а удалённые —
+	// в красный с помощью ANSI-последовательностей; неизменённые строки не окрашиваются.
+	// Решение, поддерживает ли вывод цвет, принимает вызывающий код.
+	Color bool
+	// ForcePatch разрешает ApplyPatchWithOptions записывать новые значения, даже если
+	// документ не совпадает со старыми значениями из дерева различий
+	ForcePatch bool
+	// NodePaths добавляет в json, yaml и envelope форматы поле path с полным путём
+	// к каждому узлу; в дереве, возвращаемом BuildDiff и GenDiffTree, пути есть всегда
+	NodePaths bool
+	// PlainExpandComplex выводит в plain формате объекты и массивы однострочным JSON
+	// вместо [complex value]
+	PlainExpandComplex bool
+	// PlainSeparator задаёт разделитель сегментов пути в plain формате (по умолчанию ".");
+	// ключи, содержащие разделитель, заключаются в квадратные скобки
+	PlainSeparator string
+	// IndentWidth задаёт ширину уровня отступа stylish формата (по умолчанию 4, значения
+	// меньше 2 игнорируются); маркеры +/- остаются на два символа левее ключа
+	IndentWidth int
+	// Guides рисует в stylish формате вертикальные направляющие (│) на каждом уровне отступа
+	Guides bool
+	// JSONChildOrder задаёт порядок дочерних узлов в json формате: JSONChildOrderKey
+	// (по умолчанию) или JSONChildOrderType
+	JSONChildOrder string
+	// TreatNumericKeysAsNumbers сравнивает и сортирует целочисленные ключи как числа,
+	// так что "01" и "1" считаются одним ключом
+	TreatNumericKeysAsNumbers bool
+	// NumberLocale включает разбор строк с числами в формате локали (например, "de":
+	// "1.234,56") и их численное сравнение; пустое значение отключает разбор
+	NumberLocale string
+	// MaxChanges ограничивает количество изменений в выводе; остальные заменяются
+	// строкой "... and M more". 0 снимает ограничение
+	MaxChanges int
+	// BoolAsInt считает числа 1 и 0 равными true и false соответственно
+	BoolAsInt bool
+	// ValueTransformers нормализуют значения перед сравнением. Ключ — путь через точку,
+	// каждый сегмент может быть glob-шаблоном; функция применяется к обоим значениям,
+	// а в выводе остаются исходные
+	ValueTransformers map[string]func(interface{}) interface{}
+	// Symbols переопределяет маркеры строк в stylish формате; пустые поля
+	// заменяются маркерами по умолчанию (+, - и пробел)
+	Symbols StylishSymbols
+	// LeftRoot и RightRoot задают ключ верхнего уровня, внутрь которого нужно спуститься
+	// в первом и во втором файле соответственно перед сравнением (например, prod и production)
+	LeftRoot  string
+	RightRoot string
+	// NormalizeMultilineStrings сравнивает многострочные строки построчно, отбрасывая
+	// пробелы в конце строк и схлопывая подряд идущие пустые строки
+	NormalizeMultilineStrings bool
+	// Now возвращает время генерации для envelope формата; по умолчанию time.Now
+	Now func() time.Time
+	// IndexYAMLDocuments разбирает YAML файлы с несколькими документами ("---") в карту
+	// с ключами doc0, doc1 и т.д.; без опции такие файлы дают ошибку
+	IndexYAMLDocuments bool
+	// Wrap задаёт колонку, по которой переносятся длинные значения в stylish формате;
+	// 0 отключает перенос
+	Wrap int
+
+	// files — имена сравниваемых файлов для envelope формата
+	files [2]string
+	// ctx прерывает чтение, сравнение и форматирование (см. GenDiffContext)
+	ctx context.Context
 }
 
 // GenDiff сравнивает два конфигурационных файла и возвращает различия в виде строки
 func GenDiff(filepath1, filepath2, format string) (string, error) {
-	// Читаем и парсим первый файл
-	data1, err := parseFile(filepath1)
+	return GenDiffWithOptions(filepath1, filepath2, format, Options{})
+}
+
+// BuildDiff строит дерево различий двух уже разобранных конфигураций, чтобы вызывающий
+// код мог обойти его и отрисовать самостоятельно
+func BuildDiff(data1, data2 map[string]interface{}) *Node {
+	diffTree, _ := buildTree(data1, data2, Options{})
+	return diffTree
+}
+
+// GenDiffTree сравнивает два конфигурационных файла и возвращает дерево различий
+// без форматирования. Предупреждения пишутся в stderr.
+func GenDiffTree(filepath1, filepath2 string) (*Node, error) {
+	diffTree, warnings, err := buildTreeFromFiles(filepath1, filepath2, Options{}, nil)
 	if err != nil {
-		return "", fmt.Errorf("failed to parse %s: %w", filepath1, err)
+		return nil, err
 	}
+	logWarnings(Options{}, warnings)
+	return diffTree, nil
+}
 
-	// Читаем и парсим второй файл
-	data2, err := parseFile(filepath2)
+// Result содержит результат сравнения: отформатированный вывод, дерево различий,
+// статистику и накопленные предупреждения (повторяющиеся ключи, строки,
+// различающиеся только пробелами, и т.п.)
+type Result struct {
+	Output   string
+	Tree     *Node
+	Warnings []string
+	Stats    Stats
+	Timing   Timing
+
+	opts Options
+}
+
+// Format форматирует уже построенное дерево различий в другом формате, не разбирая
+// файлы повторно
+func (r *Result) Format(format string) (string, error) {
+	output, err := formatDiff(r.Tree, format, r.opts)
 	if err != nil {
-		return "", fmt.Errorf("failed to parse %s: %w", filepath2, err)
+		return "", fmt.Errorf("failed to format diff: %w", err)
 	}
+	return output, nil
+}
 
-	// Строим дерево различий
-	diffTree := buildDiffTree(data1, data2)
+// GenDiffWithOptions сравнивает два конфигурационных файла с учётом переданных параметров.
+// Предупреждения пишутся в opts.Logger, а если он не задан — в stderr.
+func GenDiffWithOptions(filepath1, filepath2, format string, opts Options) (string, error) {
+	result, err := GenDiffResult(filepath1, filepath2, format, opts)
+	if err != nil {
+		return "", err
+	}
+
+	logWarnings(opts, result.Warnings)
+	return result.Output, nil
+}
+
+// GenDiffResult сравнивает два конфигурационных файла и возвращает структурированный результат.
+// Предупреждения только собираются в Result.Warnings и никуда не выводятся.
+func GenDiffResult(filepath1, filepath2, format string, opts Options) (*Result, error) {
+	opts.files = [2]string{filepath1, filepath2}
+	var timing Timing
+	diffTree, warnings, err := buildTreeFromFiles(filepath1, filepath2, opts, &timing)
+	if err != nil {
+		return nil, err
+	}
 
 	// Форматируем вывод согласно указанному формату
-	result, err := formatDiff(diffTree, format)
+	start := time.Now()
+	output, err := formatDiff(diffTree, format, opts)
 	if err != nil {
-		return "", fmt.Errorf("failed to format diff: %w", err)
+		return nil, fmt.Errorf("failed to format diff: %w", err)
 	}
+	timing.Format = time.Since(start)
+
+	return &Result{
+		Output:   output,
+		Tree:     diffTree,
+		Warnings: warnings,
+		Stats:    diffTree.Stats(),
+		Timing:   timing,
+		opts:     opts,
+	}, nil
+}
 
-	return result, nil
+// GenDiffString сравнивает две конфигурации, переданные в виде строк. Формат входных
+// данных (json, yaml) задаётся явно, поскольку расширения файла нет.
+func GenDiffString(content1, content2, inputFormat, format string, opts Options) (string, error) {
+	return GenDiffBytesWithOptions([]byte(content1), []byte(content2), inputFormat, format, opts)
+}
+
+// logWarnings выводит предупреждения в opts.Logger или, если он не задан, в stderr
+func logWarnings(opts Options, warnings []string) {
+	logger := opts.Logger
+	if logger == nil {
+		logger = log.New(os.Stderr, "warning: ", 0)
+	}
+	for _, warning := range warnings {
+		logger.Print(warning)
+	}
+}
+
+// buildTreeFromFiles читает оба файла и строит по ним дерево различий общим
+// конвейером buildTreeFromInputs. Если timing не nil, в него записывается длительность
+// этапов; чтение файла входит в этап его разбора.
+func buildTreeFromFiles(filepath1, filepath2 string, opts Options, timing *Timing) (*Node, []string, error) {
+	if timing == nil {
+		timing = &Timing{}
+	}
+
+	inputs := [2]configInput{}
+	durations := [2]*time.Duration{&timing.Parse1, &timing.Parse2}
+	for i, filePath := range []string{filepath1, filepath2} {
+		if err := opts.ctxErr(); err != nil {
+			return nil, nil, err
+		}
+		start := time.Now()
+		var err error
+		if inputs[i], err = readInput(filePath); err != nil {
+			return nil, nil, fmt.Errorf("failed to parse %s: %w", filePath, err)
+		}
+		*durations[i] = time.Since(start)
+	}
+	return buildTreeFromInputs(inputs[0], inputs[1], opts, timing)
+}
+
+// selectRoots спускается в ключи opts.LeftRoot и opts.RightRoot, если они заданы
+func selectRoots(data1, data2 map[string]interface{}, opts Options) (map[string]interface{}, map[string]interface{}, error) {
+	var err error
+	if opts.LeftRoot != "" {
+		if data1, err = selectRoot(data1, opts.LeftRoot); err != nil {
+			return nil, nil, fmt.Errorf("left root: %w", err)
+		}
+	}
+	if opts.RightRoot != "" {
+		if data2, err = selectRoot(data2, opts.RightRoot); err != nil {
+			return nil, nil, fmt.Errorf("right root: %w", err)
+		}
+	}
+	return data1, data2, nil
+}
+
+// selectRoot возвращает карту, лежащую под ключом верхнего уровня
+func selectRoot(data map[string]interface{}, key string) (map[string]interface{}, error) {
+	value, exists := data[key]
+	if !exists {
+		return nil, fmt.Errorf("key '%s' not found", key)
+	}
+	root, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("key '%s' is not a mapping", key)
+	}
+	return root, nil
 }
 
-// parseFile читает и парсит файл на основе его расширения
-func parseFile(filePath string) (map[string]interface{}, error) {
-	// Проверяем, существует ли файл
-	if _, err := os.Stat(filePath); os.IsNotExist(err) {
-		return nil, fmt.Errorf("file not found: %s", filePath)
+// buildTree строит дерево различий двух структур данных с учётом параметров
+// и возвращает его вместе с предупреждениями, возникшими при сравнении
+func buildTree(data1, data2 map[string]interface{}, opts Options) (*Node, []string) {
+	// Скрытые ветки убираются до сравнения
+	if len(opts.HiddenPaths) > 0 {
+		data1 = hidePaths(data1, opts.HiddenPaths)
+		data2 = hidePaths(data2, opts.HiddenPaths)
+	}
+	var patternWarnings []string
+	if len(opts.IgnoreKeys) > 0 {
+		var matcher *keyMatcher
+		matcher, patternWarnings = newKeyMatcher(opts.IgnoreKeys)
+		data1 = dropKeys(data1, matcher)
+		data2 = dropKeys(data2, matcher)
+	}
+
+	// Фильтры применяются к готовому дереву, поэтому остановка на первом изменении
+	// могла бы пропустить изменения, которые фильтр оставляет
+	if opts.hasTreeFilters() {
+		opts.StopAtFirstChange = false
+	}
+
+	// Строим дерево различий
+	if opts.ValueSetDiff {
+		return buildValueSetTree(data1, data2), patternWarnings
 	}
+	d := newDiffer(opts)
+	diffTree := d.buildDiffTree(data1, data2, nil)
+	setPaths(diffTree, nil)
 
-	// Читаем содержимое файла
-	// nolint:gosec // Мы читаем только конфигурационные файлы, а не пользовательский ввод
-	content, err := os.ReadFile(filePath)
+	// Оставляем только запрошенные ветки
+	if len(opts.IncludePaths) > 0 {
+		diffTree = includePaths(diffTree, opts.IncludePaths)
+	}
+	if len(opts.IgnorePaths) > 0 {
+		diffTree = ignorePaths(diffTree, opts.IgnorePaths)
+	}
+	if opts.Baseline != nil {
+		diffTree = suppressBaseline(diffTree, opts.Baseline)
+	}
+	if len(opts.OnlyTypes) > 0 {
+		diffTree = onlyTypes(diffTree, opts.OnlyTypes)
+	}
+	if opts.ChangesOnly {
+		diffTree = onlyTypes(diffTree, []string{NodeTypeAdded, NodeTypeRemoved, NodeTypeUpdated})
+	}
+
+	// Округляем числа для вывода
+	if precision, ok := opts.floatPrecision(); ok {
+		roundNodeFloats(diffTree, precision)
+	}
+
+	return diffTree, concatWarnings(patternWarnings, d.warnings)
+}
+
+// hasTreeFilters проверяет, задан ли хотя бы один фильтр готового дерева различий
+func (o Options) hasTreeFilters() bool {
+	return len(o.IncludePaths) > 0 || len(o.IgnorePaths) > 0 || o.Baseline != nil ||
+		len(o.OnlyTypes) > 0 || o.ChangesOnly
+}
+
+// prefixWarnings дополняет предупреждения указанием источника
+func prefixWarnings(source string, warnings []string) []string {
+	result := make([]string, 0, len(warnings))
+	for _, warning := range warnings {
+		result = append(result, fmt.Sprintf("%s: %s", source, warning))
+	}
+	return result
+}
+
+// concatWarnings объединяет несколько списков предупреждений
+func concatWarnings(lists ...[]string) []string {
+	var result []string
+	for _, list := range lists {
+		result = append(result, list...)
+	}
+	return result
+}
+
+// parseFile читает и парсит конфигурационный файл или адрес http(s)://
+func parseFile(filePath string, opts Options) (map[string]interface{}, []string, error) {
+	in, err := readInput(filePath)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read file %s: %w", filePath, err)
+		return nil, nil, err
 	}
+	return parseInput(in, opts)
+}
 
-	// Определяем формат по расширению
-	ext := strings.ToLower(filepath.Ext(filePath))
-	if ext == "" {
-		return nil, fmt.Errorf("cannot determine file format for %s", filePath)
+// parseContent парсит содержимое в указанном формате; формат допускается как
+// с ведущей точкой (".json"), так и без неё ("json"). Помимо данных возвращаются
+// предупреждения парсера, например о повторяющихся ключах.
+func parseContent(content []byte, format string, opts Options) (map[string]interface{}, []string, error) {
+	if parser, ok := lookupParser(format); ok {
+		data, err := parser(content)
+		return data, nil, err
 	}
 
 	// Парсим в зависимости от формата
-	switch ext {
-	case ".json":
-		return parseJSON(content)
-	case ".yml", ".yaml":
-		return parseYAML(content)
+	switch strings.TrimPrefix(strings.ToLower(format), ".") {
+	case "json":
+		data, err := parseJSON(content)
+		return data, nil, err
+	case "yml", "yaml":
+		return parseYAML(content, opts)
+	case "toml":
+		data, err := parseTOML(content)
+		return data, nil, err
+	case "ini":
+		data, err := parseINI(content)
+		return data, nil, err
+	case "xml":
+		data, err := parseXML(content)
+		return data, nil, err
+	case "env":
+		return parseDotEnv(content)
+	case "properties":
+		return parseProperties(content)
+	case "hcl", "tf", "tfvars":
+		data, err := parseHCL(content)
+		return data, nil, err
 	default:
-		return nil, fmt.Errorf("unsupported file format: %s", ext)
+		return nil, nil, fmt.Errorf("unsupported file format: %s", format)
 	}
 }
 
-// parseJSON парсит JSON содержимое
+// parseJSON парсит JSON содержимое. Числа, запись которых float64 не сохраняет
+// (большие целые, 10000000000, 1.50), остаются json.Number с исходным текстом.
 func parseJSON(content []byte) (map[string]interface{}, error) {
 	var result map[string]interface{}
-	if err := json.Unmarshal(content, &result); err != nil {
+	if err := unmarshalJSON(content, &result); err != nil {
 		return nil, fmt.Errorf("failed to parse JSON: %w", err)
 	}
+	for key, value := range result {
+		result[key] = normalizeJSONNumbers(value)
+	}
 	return result, nil
 }
 
-// parseYAML парсит YAML содержимое
-func parseYAML(content []byte) (map[string]interface{}, error) {
-	var result map[string]interface{}
-	if err := yaml.Unmarshal(content, &result); err != nil {
-		return nil, fmt.Errorf("failed to parse YAML: %w", err)
+// parseYAML парсит YAML содержимое. Повторяющиеся ключи не считаются ошибкой:
+// побеждает последнее значение, а о повторе сообщается предупреждением.
+// Содержимое не связано с локальным файлом, поэтому !include в нём запрещён.
+func parseYAML(content []byte, opts Options) (map[string]interface{}, []string, error) {
+	return parseYAMLFile(content, "", opts)
+}
+
+// parseYAMLFile парсит YAML содержимое файла filePath, разрешая !include
+// относительно каталога этого файла; с пустым filePath !include даёт ошибку. Несколько документов, разделённых "---",
+// дают ошибку, а с opts.IndexYAMLDocuments — карту с ключами doc0, doc1 и т.д.
+func parseYAMLFile(content []byte, filePath string, opts Options) (map[string]interface{}, []string, error) {
+	documents, err := yamlDocuments(content)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse YAML: %w", err)
 	}
-	return result, nil
+	if len(documents) > 1 && !opts.IndexYAMLDocuments {
+		return nil, nil, fmt.Errorf("failed to parse YAML: found %d documents separated by '---' "+
+			"(set IndexYAMLDocuments to compare them as doc0, doc1, ...)", len(documents))
+	}
+
+	decoder := &yamlDecoder{file: filePath}
+	if filePath != "" {
+		if absPath, err := filepath.Abs(filePath); err == nil {
+			decoder.stack = []string{absPath}
+		}
+	}
+
+	if len(documents) > 1 {
+		result := make(map[string]interface{}, len(documents))
+		for i, document := range documents {
+			value, err := decoder.nodeValue(document)
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to parse YAML document %d: %w", i, err)
+			}
+			result[fmt.Sprintf("doc%d", i)] = value
+		}
+		return result, decoder.warnings, nil
+	}
+
+	var document yaml.Node
+	if len(documents) == 1 {
+		document = *documents[0]
+	}
+	value, err := decoder.nodeValue(&document)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse YAML: %w", err)
+	}
+	if value == nil {
+		return nil, decoder.warnings, nil
+	}
+
+	result, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, nil, fmt.Errorf("failed to parse YAML: top-level value must be a mapping")
+	}
+	return result, decoder.warnings, nil
+}
+
+// differ строит дерево различий с учётом параметров сравнения
+type differ struct {
+	opts Options
+	// warnings накапливает предупреждения, возникшие при сравнении
+	warnings []string
+	// changed отмечает, что найдено хотя бы одно изменение
+	changed bool
+	// visited считает обработанные ключи для периодической проверки opts.ctx
+	visited int
+	// cancelled отмечает, что сравнение прервано отменой opts.ctx
+	cancelled bool
+}
+
+// newDiffer создаёт построитель дерева различий для указанных параметров
+func newDiffer(opts Options) *differ {
+	return &differ{opts: opts}
+}
+
+// warn добавляет предупреждение к результату сравнения
+func (d *differ) warn(format string, args ...interface{}) {
+	d.warnings = append(d.warnings, fmt.Sprintf(format, args...))
 }
 
 // buildDiffTree строит дерево, представляющее различия между двумя структурами данных
-func buildDiffTree(data1, data2 map[string]interface{}) *Node {
+func (d *differ) buildDiffTree(data1, data2 map[string]interface{}, path []string) *Node {
 	root := &Node{Type: NodeTypeRoot, Children: []*Node{}}
 
 	// Получаем все уникальные ключи и сортируем их
+	if d.opts.TreatNumericKeysAsNumbers {
+		data2 = alignNumericKeys(data1, data2)
+	}
 	keys := getUniqueKeys(data1, data2)
+	if d.opts.TreatNumericKeysAsNumbers {
+		sortNumericKeys(keys)
+	}
 
 	// Обрабатываем каждый ключ
 	for _, key := range keys {
-		childNode := processKey(key, data1, data2)
+		childNode := d.processKey(key, data1, data2, path)
 		if childNode != nil {
 			root.Children = append(root.Children, childNode)
 		}
+
+		// Для быстрой проверки остальная часть дерева не нужна
+		if d.opts.StopAtFirstChange && d.changed {
+			break
+		}
+		if d.interrupted() {
+			break
+		}
 	}
 
 	return root
 }
 
+// ctxCheckInterval — через сколько ключей сравнение проверяет отмену opts.ctx
+const ctxCheckInterval = 1000
+
+// interrupted сообщает, что сравнение нужно прервать: раз в ctxCheckInterval ключей
+// проверяется отмена opts.ctx
+func (d *differ) interrupted() bool {
+	if d.cancelled {
+		return true
+	}
+	d.visited++
+	if d.visited%ctxCheckInterval == 0 && d.opts.ctxErr() != nil {
+		d.cancelled = true
+	}
+	return d.cancelled
+}
+
+// setPaths записывает в узлы их полные пути от корня
+func setPaths(node *Node, nodePath []string) {
+	for _, child := range node.Children {
+		child.Path = appendPath(nodePath, child.name())
+		setPaths(child, child.Path)
+	}
+}
+
 // getUniqueKeys возвращает отсортированный список всех уникальных ключей из двух карт
 func getUniqueKeys(data1, data2 map[string]interface{}) []string {
 	allKeys := make(map[string]bool)
@@ -143,12 +670,13 @@ func getUniqueKeys(data1, data2 map[string]interface{}) []string {
 }
 
 // processKey обрабатывает отдельный ключ и возвращает узел, представляющий его состояние
-func processKey(key string, data1, data2 map[string]interface{}) *Node {
+func (d *differ) processKey(key string, data1, data2 map[string]interface{}, path []string) *Node {
 	value1, exists1 := data1[key]
 	value2, exists2 := data2[key]
 
 	if !exists1 && exists2 {
 		// Ключ был добавлен
+		d.changed = true
 		return &Node{
 			Type:     NodeTypeAdded,
 			Key:      key,
@@ -156,36 +684,79 @@ func processKey(key string, data1, data2 map[string]interface{}) *Node {
 		}
 	} else if exists1 && !exists2 {
 		// Ключ был удален
+		d.changed = true
 		return &Node{
 			Type:     NodeTypeRemoved,
 			Key:      key,
 			OldValue: value1,
 		}
 	} else if exists1 && exists2 {
-		return processExistingKey(key, value1, value2)
+		return d.processExistingKey(key, value1, value2, appendPath(path, key))
 	}
 
 	return nil
 }
 
 // processExistingKey обрабатывает ключ, который существует в обеих структурах данных
-func processExistingKey(key string, value1, value2 interface{}) *Node {
-	if isEqual(value1, value2) {
+func (d *differ) processExistingKey(key string, value1, value2 interface{}, path []string) *Node {
+	// Пропускаем ветки, в которые не нужно спускаться, ещё до глубокого сравнения
+	if d.opts.ShouldDescend != nil && isMap(value1) && isMap(value2) && !d.opts.ShouldDescend(path) {
+		return nil
+	}
+
+	if d.isEqual(d.transform(path, value1), d.transform(path, value2)) {
 		// Значения равны
-		return &Node{
+		if d.opts.WarnOnWhitespaceOnly {
+			d.warnWhitespaceOnly(value1, value2, path)
+		}
+		node := &Node{
 			Type:  NodeTypeUnchanged,
 			Key:   key,
 			Value: value1,
 		}
+		// Второе значение сохраняется, чтобы TypeChanges мог сравнить виды (например, "50"
+		// и 50). Листья контейнеров здесь не обходятся: это делает сам TypeChanges.
+		if kind := valueKind(value1); kind != valueKind(value2) || kind == KindObject || kind == KindArray {
+			node.rightValue = value2
+		}
+		return node
 	} else if isMap(value1) && isMap(value2) {
 		// Оба значения являются картами, рекурсивно обрабатываем
-		childNode := buildDiffTree(value1.(map[string]interface{}), value2.(map[string]interface{}))
+		childNode := d.buildDiffTree(value1.(map[string]interface{}), value2.(map[string]interface{}), path)
+		childNode.Key = key
+		childNode.Type = NodeTypeNested
+		return childNode
+	} else if field, ok := d.arrayKeyField(value1, value2); ok {
+		// Оба значения являются массивами объектов с общим ключевым полем
+		childNode := d.buildKeyedArrayTree(value1.([]interface{}), value2.([]interface{}), field, path)
+		childNode.Key = key
+		childNode.Type = NodeTypeNested
+		return childNode
+	} else if d.opts.SetArrays && isSlice(value1) && isSlice(value2) {
+		// Оба значения являются массивами, сравниваем их как множества
+		childNode := d.buildSetArrayTree(value1.([]interface{}), value2.([]interface{}))
+		if !childNode.HasChanges() {
+			return &Node{Type: NodeTypeUnchanged, Key: key, Value: value1}
+		}
+		childNode.Key = key
+		childNode.Type = NodeTypeNested
+		return childNode
+	} else if d.opts.LCSArrays && isScalarSlice(value1) && isScalarSlice(value2) {
+		// Оба значения являются массивами скаляров, сравниваем поэлементно
+		childNode := d.buildLCSArrayTree(value1.([]interface{}), value2.([]interface{}))
+		childNode.Key = key
+		childNode.Type = NodeTypeNested
+		return childNode
+	} else if d.opts.IndexArrays && isSlice(value1) && isSlice(value2) {
+		// Оба значения являются массивами, сравниваем элементы с одинаковыми индексами
+		childNode := d.buildIndexArrayTree(value1.([]interface{}), value2.([]interface{}), path)
 		childNode.Key = key
 		childNode.Type = NodeTypeNested
 		return childNode
 	}
 
 	// Значения различаются - возвращаем updated узел (независимо от типов)
+	d.changed = true
 	return &Node{
 		Type:     NodeTypeUpdated,
 		Key:      key,
@@ -194,8 +765,15 @@ func processExistingKey(key string, value1, value2 interface{}) *Node {
 	}
 }
 
+// appendPath возвращает новый путь, не разделяющий память с исходным
+func appendPath(path []string, key string) []string {
+	result := make([]string, len(path), len(path)+1)
+	copy(result, path)
+	return append(result, key)
+}
+
 // isEqual проверяет равенство двух значений с помощью глубокого сравнения
-func isEqual(a, b interface{}) bool {
+func (d *differ) isEqual(a, b interface{}) bool {
 	if a == nil && b == nil {
 		return true
 	}
@@ -205,20 +783,154 @@ func isEqual(a, b interface{}) bool {
 
 	// Для мапов используем собственную функцию глубокого сравнения
 	if isMap(a) && isMap(b) {
-		return mapsEqual(a.(map[string]interface{}), b.(map[string]interface{}))
+		return d.mapsEqual(a.(map[string]interface{}), b.(map[string]interface{}))
+	}
+
+	// Многострочные строки при необходимости сравниваем без шума в пробелах и пустых строках
+	if d.opts.NormalizeMultilineStrings {
+		strA, okA := a.(string)
+		strB, okB := b.(string)
+		if okA && okB && (strings.Contains(strA, "\n") || strings.Contains(strB, "\n")) {
+			return normalizeMultiline(strA) == normalizeMultiline(strB)
+		}
+	}
+
+	// Строки при необходимости сравниваем без учёта окружающих пробелов
+	if d.opts.TrimStrings || d.opts.WarnOnWhitespaceOnly {
+		strA, okA := a.(string)
+		strB, okB := b.(string)
+		if okA && okB {
+			return strings.TrimSpace(strA) == strings.TrimSpace(strB)
+		}
 	}
 
-	// Для остальных типов используем обычное сравнение
-	return fmt.Sprintf("%v", a) == fmt.Sprintf("%v", b)
+	// Булевы значения при необходимости сравниваем с числами 0 и 1
+	if d.opts.BoolAsInt {
+		if equal, ok := boolIntEqual(a, b); ok {
+			return equal
+		}
+	}
+
+	// Числа при необходимости считаем равными, если они отличаются не больше чем на epsilon
+	if d.opts.FloatEpsilon > 0 {
+		if equal, ok := numbersWithin(a, b, d.opts.FloatEpsilon); ok {
+			return equal
+		}
+	}
+
+	// Строки с числами в формате локали сравниваем как числа
+	if d.opts.NumberLocale != "" {
+		numA, okA := localeNumber(a, d.opts.NumberLocale)
+		numB, okB := localeNumber(b, d.opts.NumberLocale)
+		if okA && okB {
+			if precision, ok := d.opts.floatPrecision(); ok {
+				return floatsEqual(roundFloat(numA, precision), roundFloat(numB, precision))
+			}
+			return floatsEqual(numA, numB)
+		}
+	}
+
+	// Похожие строки при необходимости считаем равными
+	if d.opts.StringSimilarityThreshold > 0 && d.opts.StringSimilarityThreshold < 1 {
+		strA, okA := a.(string)
+		strB, okB := b.(string)
+		if okA && okB {
+			return stringSimilarity(strA, strB) >= d.opts.StringSimilarityThreshold
+		}
+	}
+
+	// Числа при необходимости сравниваем с заданным числом значащих цифр
+	if d.opts.SignificantFigures > 0 {
+		numA, okA := toFloat(a)
+		numB, okB := toFloat(b)
+		if okA && okB {
+			return floatsEqual(roundSignificant(numA, d.opts.SignificantFigures), roundSignificant(numB, d.opts.SignificantFigures))
+		}
+	}
+
+	// Числа при необходимости сравниваем с заданной точностью
+	if precision, ok := d.opts.floatPrecision(); ok {
+		numA, okA := toFloat(a)
+		numB, okB := toFloat(b)
+		if okA && okB {
+			return floatsEqual(roundFloat(numA, precision), roundFloat(numB, precision))
+		}
+	}
+
+	// Числа, сохранённые в исходной записи, без округления сравниваем точно
+	if equal, ok := exactNumbersEqual(a, b); ok {
+		return equal
+	}
+
+	// В нестрогом режиме значения разных типов сравниваются по строковому представлению
+	if d.opts.LooseTypes {
+		return fmt.Sprintf("%v", a) == fmt.Sprintf("%v", b)
+	}
+	return d.typedEqual(a, b)
+}
+
+// typedEqual сравнивает значения с учётом вида: значения разных видов (число 1 и
+// строка "1") не равны. Числа сравниваются по величине независимо от типа Go, чтобы
+// int из YAML и float64 из JSON совпадали, массивы — поэлементно.
+func (d *differ) typedEqual(a, b interface{}) bool {
+	kind := valueKind(a)
+	if kind != valueKind(b) {
+		return false
+	}
+
+	switch kind {
+	case KindNumber:
+		numA, _ := toFloat(a)
+		numB, _ := toFloat(b)
+		return floatsEqual(numA, numB)
+	case KindArray:
+		itemsA, itemsB := a.([]interface{}), b.([]interface{})
+		if len(itemsA) != len(itemsB) {
+			return false
+		}
+		for i := range itemsA {
+			if !d.isEqual(itemsA[i], itemsB[i]) {
+				return false
+			}
+		}
+		return true
+	case KindBool, KindString:
+		return a == b
+	default:
+		return fmt.Sprintf("%v", a) == fmt.Sprintf("%v", b)
+	}
+}
+
+// normalizeMultiline убирает пробелы в конце строк, схлопывает подряд идущие
+// пустые строки и отбрасывает пустые строки в начале и в конце
+func normalizeMultiline(s string) string {
+	lines := strings.Split(strings.ReplaceAll(s, "\r\n", "\n"), "\n")
+	result := make([]string, 0, len(lines))
+	for _, line := range lines {
+		line = strings.TrimRight(line, " \t")
+		if line == "" && (len(result) == 0 || result[len(result)-1] == "") {
+			continue
+		}
+		result = append(result, line)
+	}
+	if len(result) > 0 && result[len(result)-1] == "" {
+		result = result[:len(result)-1]
+	}
+	return strings.Join(result, "\n")
 }
 
 // mapsEqual рекурсивно сравнивает две карты на равенство
-func mapsEqual(a, b map[string]interface{}) bool {
+func (d *differ) mapsEqual(a, b map[string]interface{}) bool {
 	// Если разное количество ключей, то карты не равны
 	if len(a) != len(b) {
 		return false
 	}
 
+	// Ключи "01" и "1" считаются одним ключом так же, как при построении дерева
+	if d.opts.TreatNumericKeysAsNumbers {
+		b = alignNumericKeys(a, b)
+	}
+
 	// Проверяем каждый ключ
 	for key, valueA := range a {
 		valueB, exists := b[key]
@@ -227,7 +939,7 @@ func mapsEqual(a, b map[string]interface{}) bool {
 		}
 
 		// Рекурсивно сравниваем значения
-		if !isEqual(valueA, valueB) {
+		if !d.isEqual(valueA, valueB) {
 			return false
 		}
 	}
@@ -235,60 +947,231 @@ func mapsEqual(a, b map[string]interface{}) bool {
 	return true
 }
 
+// warnWhitespaceOnly предупреждает о строках, совпавших только после обрезки пробелов
+func (d *differ) warnWhitespaceOnly(a, b interface{}, path []string) {
+	if mapA, ok := a.(map[string]interface{}); ok {
+		mapB, _ := b.(map[string]interface{})
+		for _, key := range getSortedKeys(mapA) {
+			d.warnWhitespaceOnly(mapA[key], mapB[key], appendPath(path, key))
+		}
+		return
+	}
+
+	strA, okA := a.(string)
+	strB, okB := b.(string)
+	if okA && okB && strA != strB {
+		d.warn("values of '%s' differ only in whitespace: %q vs %q", strings.Join(path, "."), strA, strB)
+	}
+}
+
 // isMap проверяет, является ли значение картой
 func isMap(v interface{}) bool {
 	_, ok := v.(map[string]interface{})
 	return ok
 }
 
-// formatDiff форматирует дерево различий согласно указанному формату
-func formatDiff(diffTree *Node, format string) (string, error) {
+// formatDiff форматирует дерево различий согласно указанному формату.
+// Если различий нет, stylish выводит неизменённые ключи (для двух пустых
+// объектов — "{\n}"), plain и patch — пустую строку, json — корневой узел
+// с пустым или неизменённым списком children, csv — только заголовок,
+// markdown — только заголовок таблицы, unified — только строки с именами файлов,
+// yaml — корневой узел с пустым списком children, section-stats — пустой объект,
+// summary — нулевые счётчики.
+func formatDiff(diffTree *Node, format string, opts Options) (string, error) {
+	var buf bytes.Buffer
+	if err := writeDiff(&buf, diffTree, format, opts); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// writeDiff пишет отформатированное дерево различий в w. Форматы stylish,
+// plain, json, ndjson, csv, markdown, html и html-tree выводятся по мере обхода дерева.
+// Целиком в памяти собираются patch, unified и envelope (заголовки содержат счётчики),
+// yaml, небольшие сводки drifted, section-stats и summary, а также вывод
+// с MaxChanges и форматов, зарегистрированных через RegisterFormatter.
+func writeDiff(w io.Writer, diffTree *Node, format string, opts Options) error {
+	if opts.ctx != nil {
+		if err := opts.ctx.Err(); err != nil {
+			return err
+		}
+		w = &ctxWriter{ctx: opts.ctx, w: w}
+	}
+
+	out := bufio.NewWriter(w)
+	if err := writeFormatted(out, diffTree, format, opts); err != nil {
+		return err
+	}
+	return out.Flush()
+}
+
+// writeFormatted выбирает форматтер для format (сначала среди зарегистрированных
+// через RegisterFormatter) и пишет его вывод в out
+func writeFormatted(out *bufio.Writer, diffTree *Node, format string, opts Options) error {
+	if opts.MaxChanges > 0 {
+		output, err := formatLimited(diffTree, format, opts)
+		if err != nil {
+			return err
+		}
+		_, err = out.WriteString(output)
+		return err
+	}
+
+	var output string
+	var err error
+	if formatter, ok := lookupFormatter(format); ok {
+		if output, err = formatter(diffTree); err != nil {
+			return err
+		}
+		_, err = out.WriteString(output)
+		return err
+	}
+
 	switch strings.ToLower(format) {
 	case "stylish":
-		return formatStylish(diffTree), nil
+		writeStylish(out, diffTree, opts)
+		return nil
 	case "plain":
-		return formatPlain(diffTree), nil
+		writePlain(out, diffTree, opts)
+		return nil
 	case "json":
-		return formatJSON(diffTree), nil
+		return writeJSON(out, diffTree, opts)
+	case "patch":
+		output, err = formatPatch(diffTree)
+	case "csv":
+		return writeCSV(&newlineTrimmer{w: out}, diffTree)
+	case "ndjson":
+		return writeNDJSON(&newlineTrimmer{w: out}, diffTree)
+	case "envelope":
+		output, err = formatEnvelope(diffTree, opts)
+	case "drifted":
+		output = formatDrifted(diffTree)
+	case "section-stats":
+		output, err = formatSectionStats(diffTree)
+	case "html":
+		writeHTML(&newlineTrimmer{w: out}, diffTree)
+		return nil
+	case "markdown":
+		writeMarkdown(out, diffTree)
+		return nil
+	case "unified":
+		output = formatUnified(diffTree, opts)
+	case "yaml":
+		output, err = formatYAML(diffTree, opts)
+	case "html-tree":
+		writeHTMLTree(out, diffTree)
+		return nil
+	case "summary":
+		output = formatSummary(diffTree)
 	default:
-		return "", fmt.Errorf("unsupported format: %s", format)
+		return fmt.Errorf("unsupported format: %s", format)
 	}
+	if err != nil {
+		return err
+	}
+	_, err = out.WriteString(output)
+	return err
 }
 
-// formatStylish форматирует различия в stylish формате
-func formatStylish(node *Node) string {
-	var result strings.Builder
+// writeStylish пишет различия в stylish формате
+func writeStylish(result textWriter, node *Node, opts Options) {
 	result.WriteString("{\n")
-	formatStylishNode(node, &result, 1)
+	formatStylishNode(node, result, 1, opts)
 	// Убираем лишний перенос строки, если нет дочерних элементов
 	if len(node.Children) > 0 {
 		result.WriteString("\n")
 	}
 	result.WriteString("}")
+}
+
+// stylishIndentWidth — ширина одного уровня отступа в stylish формате по умолчанию
+const stylishIndentWidth = 4
+
+// indentWidth возвращает ширину уровня отступа stylish формата с учётом значения по умолчанию
+func (o Options) indentWidth() int {
+	// Маркеру с пробелом нужно два символа, поэтому меньшая ширина не поддерживается
+	if o.IndentWidth >= 2 {
+		return o.IndentWidth
+	}
+	return stylishIndentWidth
+}
+
+// stylishLevelWidth возвращает ширину уровня вложенности stylish формата: маркеры шире
+// одного символа сдвигают ключи вправо, чтобы ключи и скобки всех уровней были выровнены
+func (o Options) stylishLevelWidth() int {
+	return o.indentWidth() + utf8.RuneCountInString(o.Symbols.markers().Added) - 1
+}
+
+// stylishIndent строит отступы stylish формата: уровень вложенности занимает width
+// колонок, а с guides в начале каждого уровня рисуется вертикальная направляющая (│)
+type stylishIndent struct {
+	width  int
+	guides bool
+}
+
+// indent возвращает отступ шириной cols колонок. Направляющие ставятся только на
+// колонках уровней, которые целиком помещаются в отступ, поэтому маркер строки
+// никогда ими не затирается.
+func (s stylishIndent) indent(cols int) string {
+	if !s.guides {
+		return strings.Repeat(" ", cols)
+	}
+	var result strings.Builder
+	for col := 0; col < cols; col++ {
+		if col%s.width == 0 {
+			result.WriteString("│")
+		} else {
+			result.WriteByte(' ')
+		}
+	}
 	return result.String()
 }
 
 // formatStylishNode рекурсивно форматирует узел в stylish формате
-func formatStylishNode(node *Node, result *strings.Builder, depth int) {
-	// Базовый отступ: используем формулу depth*4-2
-	baseIndent := strings.Repeat(" ", depth*4-2)
+func formatStylishNode(node *Node, result textWriter, depth int, opts Options) {
+	// Базовый отступ: ключ начинается с колонки depth*width, маркер с пробелом стоит левее
+	symbols := opts.Symbols.markers()
+	width := opts.stylishLevelWidth()
+	ind := stylishIndent{width: width, guides: opts.Guides}
+	baseCols := depth*width - utf8.RuneCountInString(symbols.Added) - 1
+	baseIndent := ind.indent(baseCols)
+	// Отступ для перенесённых строк длинных значений; направляющие в них не рисуются,
+	// чтобы продолжение значения не отличалось от его начала
+	wrapIndent := strings.Repeat(" ", (depth+1)*width)
+
+	// line формирует строку с маркером, перенося длинные скалярные значения;
+	// с opts.Color строка целиком окрашивается в цвет color
+	line := func(marker, color, key string, raw interface{}, formatted string) string {
+		prefix := fmt.Sprintf("%s %s: ", marker, key)
+		if opts.Wrap > 0 && !isMap(raw) {
+			formatted = wrapValue(formatted, baseCols+utf8.RuneCountInString(prefix), wrapIndent, opts.Wrap)
+		}
+		if opts.Color && color != "" {
+			return baseIndent + color + prefix + formatted + ansiReset
+		}
+		return baseIndent + prefix + formatted
+	}
 
 	for i, child := range node.Children {
 		switch child.Type {
 		case NodeTypeAdded:
-			fmt.Fprintf(result, "%s+ %s: %s", baseIndent, child.Key, formatValueForRemovedAdded(child.NewValue, depth))
+			result.WriteString(line(symbols.Added, ansiGreen, child.name(), child.NewValue, formatValue(child.NewValue, depth, ind)))
 		case NodeTypeRemoved:
-			fmt.Fprintf(result, "%s- %s: %s", baseIndent, child.Key, formatValueForRemovedAdded(child.OldValue, depth))
+			result.WriteString(line(symbols.Removed, ansiRed, child.name(), child.OldValue, formatValue(child.OldValue, depth, ind)))
 		case NodeTypeUpdated:
-			fmt.Fprintf(result, "%s- %s: %s\n%s+ %s: %s",
-				baseIndent, child.Key, formatValue(child.OldValue),
-				baseIndent, child.Key, formatValue(child.NewValue))
+			newValue := formatValue(child.NewValue, depth, ind)
+			if opts.ShowNumericDelta {
+				newValue += numericDelta(child.OldValue, child.NewValue)
+			}
+			fmt.Fprintf(result, "%s\n%s",
+				line(symbols.Removed, ansiRed, child.name(), child.OldValue, formatValue(child.OldValue, depth, ind)),
+				line(symbols.Added, ansiGreen, child.name(), child.NewValue, newValue))
 		case NodeTypeUnchanged:
-			fmt.Fprintf(result, "%s  %s: %s", baseIndent, child.Key, formatValue(child.Value))
+			result.WriteString(line(symbols.Unchanged, "", child.name(), child.Value, formatValue(child.Value, depth, ind)))
 		case NodeTypeNested:
-			fmt.Fprintf(result, "%s  %s: {\n", baseIndent, child.Key)
-			formatStylishNode(child, result, depth+1)
-			fmt.Fprintf(result, "\n%s  }", baseIndent)
+			fmt.Fprintf(result, "%s%s %s: {\n", baseIndent, symbols.Unchanged, child.name())
+			formatStylishNode(child, result, depth+1, opts)
+			fmt.Fprintf(result, "\n%s%s }", baseIndent, symbols.Unchanged)
 		}
 
 		// Добавляем перенос строки между элементами, кроме последнего
@@ -298,149 +1181,332 @@ func formatStylishNode(node *Node, result *strings.Builder, depth int) {
 	}
 }
 
-// formatPlain форматирует различия в plain формате
-func formatPlain(node *Node) string {
-	var result []string
-	formatPlainNode(node, &result, []string{})
-	sort.Strings(result)
-	return strings.Join(result, "\n")
+// ANSI-последовательности для окрашивания строк stylish формата
+const (
+	ansiRed   = "\x1b[31m"
+	ansiGreen = "\x1b[32m"
+	ansiReset = "\x1b[0m"
+)
+
+// StylishSymbols задаёт маркеры строк stylish формата
+type StylishSymbols struct {
+	Added     string
+	Removed   string
+	Unchanged string
 }
 
-// formatPlainNode рекурсивно форматирует узел в plain формате
-func formatPlainNode(node *Node, result *[]string, path []string) {
-	for _, child := range node.Children {
-		currentPath := append(path, child.Key)
-		pathStr := strings.Join(currentPath, ".")
+// markers возвращает маркеры с подставленными значениями по умолчанию, дополненные
+// пробелами до одинаковой ширины, чтобы ключи оставались выровненными
+func (s StylishSymbols) markers() StylishSymbols {
+	if s.Added == "" {
+		s.Added = "+"
+	}
+	if s.Removed == "" {
+		s.Removed = "-"
+	}
+	if s.Unchanged == "" {
+		s.Unchanged = " "
+	}
+
+	width := max(utf8.RuneCountInString(s.Added), utf8.RuneCountInString(s.Removed), utf8.RuneCountInString(s.Unchanged))
+	pad := func(marker string) string {
+		return marker + strings.Repeat(" ", width-utf8.RuneCountInString(marker))
+	}
+	return StylishSymbols{Added: pad(s.Added), Removed: pad(s.Removed), Unchanged: pad(s.Unchanged)}
+}
+
+// wrapValue переносит значение по словам так, чтобы строки не выходили за колонку width.
+// Первая строка начинается с позиции offset, продолжения — с отступом indent.
+// Переносы ставятся только на месте одиночных пробелов, поэтому серии пробелов,
+// табуляции и переводы строк сохраняются как есть. Слова длиннее доступной ширины
+// не разрываются. Ширина считается в символах, а не в байтах.
+func wrapValue(value string, offset int, indent string, width int) string {
+	if offset+utf8.RuneCountInString(value) <= width {
+		return value
+	}
+
+	words := wrapWords(value)
+	var result strings.Builder
+	lineLen := offset
+	for i, word := range words {
+		wordLen := utf8.RuneCountInString(word)
+		if i > 0 {
+			if lineLen+1+wordLen > width {
+				result.WriteString("\n" + indent)
+				lineLen = utf8.RuneCountInString(indent)
+			} else {
+				result.WriteString(" ")
+				lineLen++
+			}
+		}
+		result.WriteString(word)
+		lineLen += wordLen
+	}
+	return result.String()
+}
+
+// wrapWords делит строку на части по одиночным пробелам между непробельными символами
+func wrapWords(value string) []string {
+	runes := []rune(value)
+	var words []string
+	start := 0
+	for i, r := range runes {
+		if r != ' ' || i == 0 || i == len(runes)-1 {
+			continue
+		}
+		if unicode.IsSpace(runes[i-1]) || unicode.IsSpace(runes[i+1]) {
+			continue
+		}
+		words = append(words, string(runes[start:i]))
+		start = i + 1
+	}
+	return append(words, string(runes[start:]))
+}
+
+// writePlain пишет различия в plain формате, по строке на изменение
+func writePlain(result textWriter, node *Node, opts Options) {
+	first := true
+	formatPlainNode(node, func(line string) {
+		if !first {
+			result.WriteString("\n")
+		}
+		first = false
+		result.WriteString(line)
+	}, []string{}, opts)
+}
+
+// formatPlainNode рекурсивно форматирует узел в plain формате, передавая строки в emit. Строки выводятся
+// в порядке путей: ключи карт уже упорядочены в дереве, а элементы массивов
+// упорядочиваются по индексу
+func formatPlainNode(node *Node, emit func(string), path []string, opts Options) {
+	children := node.Children
+	if isArrayNode(node) {
+		children = slices.Clone(children)
+		sort.SliceStable(children, func(i, j int) bool {
+			a, b := children[i].Index, children[j].Index
+			return a != nil && b != nil && *a < *b
+		})
+	}
+
+	plainValue := formatPlainValue
+	if opts.PlainExpandComplex {
+		plainValue = formatPlainExpanded
+	}
+
+	for _, child := range children {
+		currentPath := appendPath(path, child.name())
+		pathStr := plainPath(currentPath, opts.plainSeparator())
 
 		switch child.Type {
 		case NodeTypeAdded:
-			*result = append(*result, fmt.Sprintf("Property '%s' was added with value: %s", pathStr, formatPlainValue(child.NewValue)))
+			emit(fmt.Sprintf("Property '%s' was added with value: %s", pathStr, plainValue(child.NewValue)))
 		case NodeTypeRemoved:
-			*result = append(*result, fmt.Sprintf("Property '%s' was removed", pathStr))
+			emit(fmt.Sprintf("Property '%s' was removed", pathStr))
 		case NodeTypeUpdated:
-			*result = append(*result, fmt.Sprintf("Property '%s' was updated. From %s to %s", pathStr, formatPlainValue(child.OldValue), formatPlainValue(child.NewValue)))
+			line := fmt.Sprintf("Property '%s' was updated. From %s to %s", pathStr, plainValue(child.OldValue), plainValue(child.NewValue))
+			if opts.ShowNumericDelta {
+				line += numericDelta(child.OldValue, child.NewValue)
+			}
+			emit(line)
 		case NodeTypeNested:
-			formatPlainNode(child, result, currentPath)
+			formatPlainNode(child, emit, currentPath, opts)
 		}
 	}
 }
 
-// formatJSON форматирует различия как JSON
-func formatJSON(node *Node) string {
-	jsonData, err := json.MarshalIndent(node, "", "  ")
-	if err != nil {
-		return "{}"
+// plainSeparator возвращает разделитель пути plain формата с учётом значения по умолчанию
+func (o Options) plainSeparator() string {
+	if o.PlainSeparator != "" {
+		return o.PlainSeparator
 	}
-	return string(jsonData)
+	return "."
 }
 
-// formatValue форматирует значение для stylish вывода (для вложенных и неизменённых узлов)
-func formatValue(v interface{}) string {
-	if v == nil {
-		return NullValue
+// plainPath соединяет сегменты пути разделителем; сегменты, содержащие разделитель,
+// заключаются в квадратные скобки, чтобы путь оставался однозначным: a.[b.c].d
+func plainPath(segments []string, separator string) string {
+	quoted := make([]string, len(segments))
+	for i, segment := range segments {
+		if strings.Contains(segment, separator) {
+			segment = "[" + segment + "]"
+		}
+		quoted[i] = segment
 	}
+	return strings.Join(quoted, separator)
+}
 
-	if m, ok := v.(map[string]interface{}); ok {
-		// Для вложенных объектов создаем простой вывод
-		return formatNestedMap(m)
+// writeJSON пишет различия как JSON. Значения выводятся родными JSON-типами,
+// ключи вложенных объектов сортируются, а большие числа выводятся в исходной записи.
+// Узлы пишутся по одному, поэтому документ целиком в памяти не собирается; путь узла
+// выводится только с opts.NodePaths.
+func writeJSON(w textWriter, node *Node, opts Options) error {
+	switch opts.JSONChildOrder {
+	case "", JSONChildOrderKey:
+	case JSONChildOrderType:
+		node = sortChildrenByType(node)
+	default:
+		return fmt.Errorf("unsupported JSON child order: %s", opts.JSONChildOrder)
 	}
 
-	// Для всех остальных типов используем обычное форматирование
-	return formatPrimitiveValue(v)
+	if len(node.Children) == 0 {
+		// Пустой корень выводится с явным пустым списком детей
+		data, err := json.MarshalIndent(struct {
+			Type     string  `json:"type"`
+			Children []*Node `json:"children"`
+		}{Type: node.Type, Children: []*Node{}}, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(data)
+		return err
+	}
+	return writeJSONNode(w, node, "", opts.NodePaths)
 }
 
-// formatValueForRemovedAdded форматирует значение для удалённых/добавленных узлов
-func formatValueForRemovedAdded(v interface{}, depth int) string {
-	if v == nil {
-		return NullValue
+// writeJSONNode пишет узел так же, как json.MarshalIndent с префиксом prefix: поля
+// узла кодируются без детей, а дети дописываются рекурсивно
+func writeJSONNode(w textWriter, node *Node, prefix string, withPath bool) error {
+	fields := *node
+	fields.Children, fields.Path = nil, nil
+	data, err := json.MarshalIndent(fields, prefix, "  ")
+	if err != nil {
+		return err
 	}
+	// Убираем закрывающую скобку, чтобы дописать детей и путь
+	w.Write(data[:len(data)-len("\n"+prefix+"}")])
 
-	if m, ok := v.(map[string]interface{}); ok {
-		// Для удаленных/добавленных объектов используем форматирование с учетом глубины
-		return formatSimpleMapWithDepth(m, depth)
+	if len(node.Children) > 0 {
+		w.WriteString(",\n" + prefix + "  \"children\": [")
+		for i, child := range node.Children {
+			if i > 0 {
+				w.WriteString(",")
+			}
+			w.WriteString("\n" + prefix + "    ")
+			if err := writeJSONNode(w, child, prefix+"    ", withPath); err != nil {
+				return err
+			}
+		}
+		w.WriteString("\n" + prefix + "  ]")
 	}
-
-	// Для всех остальных типов используем обычное форматирование
-	return formatPrimitiveValue(v)
+	if withPath && len(node.Path) > 0 {
+		path, err := json.MarshalIndent(node.Path, prefix+"  ", "  ")
+		if err != nil {
+			return err
+		}
+		w.WriteString(",\n" + prefix + "  \"path\": ")
+		w.Write(path)
+	}
+	_, err = w.WriteString("\n" + prefix + "}")
+	return err
 }
 
-// formatPrimitiveValue форматирует примитивные значения
-func formatPrimitiveValue(v interface{}) string {
-	switch val := v.(type) {
-	case string:
-		return val
-	case bool:
-		return fmt.Sprintf("%t", val)
-	default:
-		return fmt.Sprintf("%v", val)
+// formatYAML форматирует дерево различий как YAML с теми же полями, что и json формат;
+// путь узла выводится только с opts.NodePaths
+func formatYAML(node *Node, opts Options) (string, error) {
+	node = node.Clone()
+	yamlNumbers(node)
+	if !opts.NodePaths {
+		_ = Walk(node, func(n *Node, _ []string) error {
+			n.Path = nil
+			return nil
+		})
 	}
-}
 
-// formatNestedMap форматирует карту для вложенных объектов в stylish формате
-func formatNestedMap(m map[string]interface{}) string {
-	if len(m) == 0 {
-		return "{}"
+	var value interface{} = node
+	if len(node.Children) == 0 {
+		// Пустой корень, как и в json формате, выводится с явным пустым списком детей
+		value = struct {
+			Type     string  `yaml:"type"`
+			Children []*Node `yaml:"children"`
+		}{Type: node.Type, Children: []*Node{}}
 	}
 
-	var result strings.Builder
-	result.WriteString("{\n")
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(value); err != nil {
+		return "", fmt.Errorf("failed to marshal YAML: %w", err)
+	}
+	if err := encoder.Close(); err != nil {
+		return "", fmt.Errorf("failed to marshal YAML: %w", err)
+	}
+	return strings.TrimSuffix(buf.String(), "\n"), nil
+}
 
-	// Сортируем ключи для детерминированного вывода
-	keys := getSortedKeys(m)
-
-	// Отступ для содержимого: 12 пробелов (как в ожидаемом результате)
-	contentIndent := "            " // 12 пробелов
-	for i, key := range keys {
-		value := m[key]
-		if isMap(value) {
-			result.WriteString(fmt.Sprintf("%s%s: %s", contentIndent, key, formatNestedMapRecursive(value.(map[string]interface{}), 3)))
-		} else {
-			result.WriteString(fmt.Sprintf("%s%s: %s", contentIndent, key, formatValue(value)))
+// yamlNumbers заменяет json.Number в значениях дерева на скаляры YAML, чтобы большие
+// числа выводились числами, а не строками
+func yamlNumbers(node *Node) {
+	node.Value = yamlNumberValue(node.Value)
+	node.OldValue = yamlNumberValue(node.OldValue)
+	node.NewValue = yamlNumberValue(node.NewValue)
+	for _, child := range node.Children {
+		yamlNumbers(child)
+	}
+}
+
+func yamlNumberValue(v interface{}) interface{} {
+	switch val := v.(type) {
+	case json.Number:
+		tag := "!!int"
+		if strings.ContainsAny(val.String(), ".eE") {
+			tag = "!!float"
 		}
-		if i < len(keys)-1 {
-			result.WriteString("\n")
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: tag, Value: val.String()}
+	case map[string]interface{}:
+		for key, item := range val {
+			val[key] = yamlNumberValue(item)
 		}
+		return val
+	case []interface{}:
+		for i, item := range val {
+			val[i] = yamlNumberValue(item)
+		}
+		return val
+	default:
+		return v
 	}
+}
 
-	// Закрывающая скобка с отступом 8 пробелов (как в ожидаемом результате)
-	result.WriteString("\n        }")
-	return result.String()
+// jsonTypeOrder задаёт порядок групп при упорядочивании дочерних узлов по типу
+var jsonTypeOrder = map[string]int{
+	NodeTypeRemoved:   0,
+	NodeTypeAdded:     1,
+	NodeTypeUpdated:   2,
+	NodeTypeNested:    3,
+	NodeTypeUnchanged: 4,
 }
 
-// formatNestedMapRecursive форматирует вложенные карты с правильными отступами для вложенных объектов
-func formatNestedMapRecursive(m map[string]interface{}, depth int) string {
-	if len(m) == 0 {
-		return "{}"
+// sortChildrenByType возвращает копию дерева, в которой дочерние узлы на каждом уровне
+// сгруппированы по типу; внутри группы сохраняется порядок по ключам
+func sortChildrenByType(node *Node) *Node {
+	result := *node
+	if node.Children == nil {
+		return &result
 	}
 
-	var result strings.Builder
-	result.WriteString("{\n")
-
-	// Сортируем ключи для детерминированного вывода
-	keys := getSortedKeys(m)
-
-	// Отступ для содержимого: используем правильную формулу
-	contentIndent := strings.Repeat(" ", depth*4)
-	for i, key := range keys {
-		value := m[key]
-		if isMap(value) {
-			result.WriteString(fmt.Sprintf("%s%s: %s", contentIndent, key, formatNestedMapRecursive(value.(map[string]interface{}), depth+1)))
-		} else {
-			result.WriteString(fmt.Sprintf("%s%s: %s", contentIndent, key, formatValue(value)))
-		}
-		if i < len(keys)-1 {
-			result.WriteString("\n")
-		}
+	result.Children = make([]*Node, len(node.Children))
+	for i, child := range node.Children {
+		result.Children[i] = sortChildrenByType(child)
 	}
+	sort.SliceStable(result.Children, func(i, j int) bool {
+		return jsonTypeOrder[result.Children[i].Type] < jsonTypeOrder[result.Children[j].Type]
+	})
+	return &result
+}
 
-	// Закрывающая скобка с правильным отступом
-	result.WriteString(fmt.Sprintf("\n%s}", strings.Repeat(" ", (depth-1)*4)))
-	return result.String()
+// formatValue форматирует значение строки stylish формата, находящейся на глубине
+// depth, при ширине уровня отступа width. Карты выводятся многострочно: ключи
+// с отступом (depth+1)*width, закрывающая скобка — с отступом depth*width, так что
+// выравнивание верно на любой глубине.
+func formatValue(v interface{}, depth int, ind stylishIndent) string {
+	if m, ok := v.(map[string]interface{}); ok {
+		return formatMap(m, depth, ind)
+	}
+	return renderScalar(v, scalarStyleStylish)
 }
 
-// formatSimpleMap форматирует карту с простой структурой
-// formatSimpleMapWithDepth форматирует карту для удалённых/добавленных узлов с учётом глубины
-func formatSimpleMapWithDepth(m map[string]interface{}, depth int) string {
+// formatMap форматирует карту, являющуюся значением строки на глубине depth
+func formatMap(m map[string]interface{}, depth int, ind stylishIndent) string {
 	if len(m) == 0 {
 		return "{}"
 	}
@@ -449,68 +1515,42 @@ func formatSimpleMapWithDepth(m map[string]interface{}, depth int) string {
 	result.WriteString("{\n")
 
 	// Сортируем ключи для детерминированного вывода
-	keys := getSortedKeys(m)
-
-	// Отступ для содержимого: зависит от глубины
-	// Глубина 1: 8 пробелов, Глубина 2: 12 пробелов
-	var contentIndent string
-	var closingIndent string
-	if depth == 1 {
-		contentIndent = "        " // 8 пробелов
-		closingIndent = "    "     // 4 пробела
-	} else {
-		contentIndent = "            " // 12 пробелов
-		closingIndent = "        "     // 8 пробелов
-	}
-
-	for i, key := range keys {
-		value := m[key]
-		if isMap(value) {
-			result.WriteString(fmt.Sprintf("%s%s: %s", contentIndent, key, formatSimpleMapRecursive(value.(map[string]interface{}), depth+2)))
-		} else {
-			result.WriteString(fmt.Sprintf("%s%s: %s", contentIndent, key, formatValue(value)))
-		}
-		if i < len(keys)-1 {
-			result.WriteString("\n")
-		}
+	contentIndent := ind.indent((depth + 1) * ind.width)
+	for _, key := range getSortedKeys(m) {
+		fmt.Fprintf(&result, "%s%s: %s\n", contentIndent, key, formatValue(m[key], depth+1, ind))
 	}
 
-	result.WriteString(fmt.Sprintf("\n%s}", closingIndent))
+	result.WriteString(ind.indent(depth*ind.width) + "}")
 	return result.String()
 }
 
-// formatSimpleMapRecursive форматирует вложенные карты с правильными отступами
-func formatSimpleMapRecursive(m map[string]interface{}, depth int) string {
-	if len(m) == 0 {
-		return "{}"
-	}
-
-	var result strings.Builder
-	result.WriteString("{\n")
+// scalarStyle задаёт правила отображения скалярных значений в текстовом формате
+type scalarStyle int
 
-	// Сортируем ключи для детерминированного вывода
-	keys := getSortedKeys(m)
-
-	// Отступ для содержимого: используем правильную формулу
-	// Для глубины 2: 8 пробелов, для глубины 3: 12 пробелов
-	contentIndent := strings.Repeat(" ", depth*4)
-	closingIndent := strings.Repeat(" ", (depth-1)*4)
+const (
+	// scalarStyleStylish выводит строки как есть, без кавычек, даже если они содержат
+	// пробелы или двоеточия; это правило общее для всех значений stylish формата
+	scalarStyleStylish scalarStyle = iota
+	// scalarStylePlain заключает строки в одинарные кавычки
+	scalarStylePlain
+)
 
-	for i, key := range keys {
-		value := m[key]
-		if isMap(value) {
-			result.WriteString(fmt.Sprintf("%s%s: %s", contentIndent, key, formatSimpleMapRecursive(value.(map[string]interface{}), depth+1)))
-		} else {
-			result.WriteString(fmt.Sprintf("%s%s: %s", contentIndent, key, formatValue(value)))
-		}
-		if i < len(keys)-1 {
-			result.WriteString("\n")
+// renderScalar — единое место, где определено отображение null, булевых значений,
+// чисел и строк для всех текстовых форматов; форматы различаются только стилем строк
+func renderScalar(v interface{}, style scalarStyle) string {
+	switch val := v.(type) {
+	case nil:
+		return NullValue
+	case string:
+		if style == scalarStylePlain {
+			return fmt.Sprintf("'%s'", val)
 		}
+		return val
+	case bool:
+		return strconv.FormatBool(val)
+	default:
+		return fmt.Sprintf("%v", val)
 	}
-
-	// Закрывающая скобка с правильным отступом
-	result.WriteString(fmt.Sprintf("\n%s}", closingIndent))
-	return result.String()
 }
 
 // getSortedKeys возвращает отсортированные ключи карты
@@ -525,17 +1565,23 @@ func getSortedKeys(m map[string]interface{}) []string {
 
 // formatPlainValue форматирует значение для plain вывода
 func formatPlainValue(v interface{}) string {
-	if v == nil {
-		return NullValue
-	}
-	switch val := v.(type) {
-	case string:
-		return fmt.Sprintf("'%s'", val)
-	case bool:
-		return fmt.Sprintf("%t", val)
-	case map[string]interface{}:
+	if isMap(v) {
 		return "[complex value]"
+	}
+	return renderScalar(v, scalarStylePlain)
+}
+
+// formatPlainExpanded форматирует значение для plain вывода, показывая объекты
+// и массивы в виде однострочного JSON вместо [complex value]
+func formatPlainExpanded(v interface{}) string {
+	switch v.(type) {
+	case map[string]interface{}, []interface{}:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return "[complex value]"
+		}
+		return string(data)
 	default:
-		return fmt.Sprintf("%v", val)
+		return formatPlainValue(v)
 	}
 }
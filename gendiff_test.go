@@ -1,7 +1,12 @@
 package code
 
 import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
 	"os"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -130,6 +135,40 @@ func TestGenDiff_EmptyFiles(t *testing.T) {
 	assert.Equal(t, "{\n}", result)
 }
 
+func TestGenDiff_EmptyDiffAllFormats(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		format   string
+		expected string
+	}{
+		{"stylish empty", `{}`, "stylish", "{\n}"},
+		{"plain empty", `{}`, "plain", ""},
+		{"json empty", `{}`, "json", "{\n  \"type\": \"root\",\n  \"children\": []\n}"},
+		{"stylish identical", `{"a":1}`, "stylish", "{\n    a: 1\n}"},
+		{"plain identical", `{"a":1}`, "plain", ""},
+		{"section-stats identical", `{"a":{"b":1}}`, "section-stats", "{}"},
+		{"json identical", `{"a":1}`, "json", `{
+  "type": "root",
+  "children": [
+    {
+      "type": "unchanged",
+      "key": "a",
+      "value": 1
+    }
+  ]
+}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := GenDiffString(tt.content, tt.content, "json", tt.format, Options{})
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
 func TestGenDiff_NestedStructures(t *testing.T) {
 	file1 := createTempFile(t, `{
   "common": {
@@ -201,3 +240,740 @@ func createTempYAMLFile(t *testing.T, content string) string {
 
 	return tmpfile.Name()
 }
+
+func TestGenDiff_TrimStrings(t *testing.T) {
+	file1 := createTempFile(t, `{"name":"a ","port":"80"}`)
+	file2 := createTempFile(t, `{"name":"a","port":"81"}`)
+	defer func() {
+		if err := os.Remove(file1); err != nil {
+			t.Logf("failed to remove temp file %s: %v", file1, err)
+		}
+		if err := os.Remove(file2); err != nil {
+			t.Logf("failed to remove temp file %s: %v", file2, err)
+		}
+	}()
+
+	result, err := GenDiffWithOptions(file1, file2, "plain", Options{TrimStrings: true})
+	require.NoError(t, err)
+	assert.Equal(t, "Property 'port' was updated. From '80' to '81'", result)
+}
+
+func TestGenDiff_WarnOnWhitespaceOnly(t *testing.T) {
+	file1 := createTempFile(t, `{"name":"a ","nested":{"value":" b"}}`)
+	file2 := createTempFile(t, `{"name":"a","nested":{"value":"b"}}`)
+	defer func() {
+		if err := os.Remove(file1); err != nil {
+			t.Logf("failed to remove temp file %s: %v", file1, err)
+		}
+		if err := os.Remove(file2); err != nil {
+			t.Logf("failed to remove temp file %s: %v", file2, err)
+		}
+	}()
+
+	var warnings bytes.Buffer
+	opts := Options{
+		WarnOnWhitespaceOnly: true,
+		Logger:               log.New(&warnings, "", 0),
+	}
+
+	result, err := GenDiffWithOptions(file1, file2, "json", opts)
+	require.NoError(t, err)
+
+	// The node stays unchanged while the warning is emitted
+	assert.Contains(t, result, `"type": "unchanged"`)
+	assert.NotContains(t, result, `"type": "updated"`)
+	assert.Contains(t, warnings.String(), `values of 'name' differ only in whitespace: "a " vs "a"`)
+	assert.Contains(t, warnings.String(), `values of 'nested.value' differ only in whitespace`)
+}
+
+func TestGenDiff_Wrap(t *testing.T) {
+	file1 := createTempFile(t, `{"description":"short"}`)
+	file2 := createTempFile(t, `{"description":"a very long description that does not fit into forty columns"}`)
+	defer func() {
+		if err := os.Remove(file1); err != nil {
+			t.Logf("failed to remove temp file %s: %v", file1, err)
+		}
+		if err := os.Remove(file2); err != nil {
+			t.Logf("failed to remove temp file %s: %v", file2, err)
+		}
+	}()
+
+	result, err := GenDiffWithOptions(file1, file2, "stylish", Options{Wrap: 40})
+	require.NoError(t, err)
+
+	expected := `{
+  - description: short
+  + description: a very long description
+        that does not fit into forty
+        columns
+}`
+	assert.Equal(t, expected, result)
+	for _, line := range strings.Split(result, "\n") {
+		assert.LessOrEqual(t, len(line), 40)
+	}
+
+	// Wrapping is disabled by default
+	result, err = GenDiff(file1, file2, "stylish")
+	require.NoError(t, err)
+	assert.Contains(t, result, "+ description: a very long description that does not fit into forty columns")
+}
+
+func TestWrapValue(t *testing.T) {
+	// Runs of spaces, tabs and newlines are kept; only single spaces become breaks
+	value := "aaa   bbb\tccc ddd eee"
+	assert.Equal(t, "aaa   bbb\tccc\n  ddd eee", wrapValue(value, 0, "  ", 14))
+
+	// Width is counted in characters, so multibyte text fits the same column
+	assert.Equal(t, "ключ значение", wrapValue("ключ значение", 0, "", 13))
+	assert.Equal(t, "ключ\nзначение", wrapValue("ключ значение", 0, "", 12))
+
+	// Short values are returned untouched
+	assert.Equal(t, "a  b", wrapValue("a  b", 0, "", 40))
+}
+
+func TestGenDiff_ShouldDescend(t *testing.T) {
+	file1 := createTempFile(t, `{"common":{"a":1,"inner":{"b":2}},"skipped":{"c":3},"top":1}`)
+	file2 := createTempFile(t, `{"common":{"a":2,"inner":{"b":3}},"skipped":{"c":4},"top":2}`)
+	defer func() {
+		if err := os.Remove(file1); err != nil {
+			t.Logf("failed to remove temp file %s: %v", file1, err)
+		}
+		if err := os.Remove(file2); err != nil {
+			t.Logf("failed to remove temp file %s: %v", file2, err)
+		}
+	}()
+
+	var visited []string
+	opts := Options{
+		ShouldDescend: func(path []string) bool {
+			joined := strings.Join(path, ".")
+			visited = append(visited, joined)
+			return joined != "skipped" && joined != "common.inner"
+		},
+	}
+
+	result, err := GenDiffWithOptions(file1, file2, "plain", opts)
+	require.NoError(t, err)
+	assert.Equal(t, "Property 'common.a' was updated. From 1 to 2\nProperty 'top' was updated. From 1 to 2", result)
+	assert.Equal(t, []string{"common", "common.inner", "skipped"}, visited)
+}
+
+func TestRenderScalar_ConsistentAcrossFormats(t *testing.T) {
+	cases := []struct {
+		value   interface{}
+		stylish string
+		plain   string
+	}{
+		{nil, "null", "null"},
+		{true, "true", "true"},
+		{false, "false", "false"},
+		{50.0, "50", "50"},
+		{1.5, "1.5", "1.5"},
+		{"hexlet.io", "hexlet.io", "'hexlet.io'"},
+		{"", "", "''"},
+	}
+
+	for _, tc := range cases {
+		assert.Equal(t, tc.stylish, renderScalar(tc.value, scalarStyleStylish))
+		assert.Equal(t, tc.plain, renderScalar(tc.value, scalarStylePlain))
+
+		// The formatters delegate scalar rendering to renderScalar
+		assert.Equal(t, tc.stylish, formatValue(tc.value, 1, stylishIndent{width: stylishIndentWidth}))
+		assert.Equal(t, tc.plain, formatPlainValue(tc.value))
+	}
+}
+
+func TestGenDiffResult(t *testing.T) {
+	file1 := createTempYAMLFile(t, `host: hexlet.io
+timeout: 50
+timeout: 60
+name: "a "`)
+	file2 := createTempYAMLFile(t, `host: hexlet.io
+timeout: 20
+name: a`)
+	defer func() {
+		if err := os.Remove(file1); err != nil {
+			t.Logf("failed to remove temp file %s: %v", file1, err)
+		}
+		if err := os.Remove(file2); err != nil {
+			t.Logf("failed to remove temp file %s: %v", file2, err)
+		}
+	}()
+
+	result, err := GenDiffResult(file1, file2, "plain", Options{WarnOnWhitespaceOnly: true})
+	require.NoError(t, err)
+
+	// The last duplicate wins
+	assert.Equal(t, "Property 'timeout' was updated. From 60 to 20", result.Output)
+	assert.Equal(t, Stats{Updated: 1, Unchanged: 2}, result.Stats)
+	require.NotNil(t, result.Tree)
+	assert.Len(t, result.Tree.Children, 3)
+	assert.Equal(t, []string{
+		file1 + ": duplicate key 'timeout' at line 3",
+		`values of 'name' differ only in whitespace: "a " vs "a"`,
+	}, result.Warnings)
+}
+
+func TestGenDiff_YAMLMergeKeys(t *testing.T) {
+	file1 := createTempYAMLFile(t, `defaults: &defaults
+  timeout: 50
+  host: hexlet.io
+server:
+  <<: *defaults
+  timeout: 20`)
+	file2 := createTempYAMLFile(t, `defaults:
+  timeout: 50
+  host: hexlet.io
+server:
+  timeout: 20
+  host: hexlet.io`)
+	defer func() {
+		if err := os.Remove(file1); err != nil {
+			t.Logf("failed to remove temp file %s: %v", file1, err)
+		}
+		if err := os.Remove(file2); err != nil {
+			t.Logf("failed to remove temp file %s: %v", file2, err)
+		}
+	}()
+
+	result, err := GenDiff(file1, file2, "plain")
+	require.NoError(t, err)
+	assert.Empty(t, result)
+}
+
+func TestGenDiffString(t *testing.T) {
+	result, err := GenDiffString(`{"a":1,"b":"x"}`, `{"a":2,"b":"x"}`, "json", "plain", Options{})
+	require.NoError(t, err)
+	assert.Equal(t, "Property 'a' was updated. From 1 to 2", result)
+
+	result, err = GenDiffString("a: 1", "a: 1\nc: true", "yaml", "plain", Options{})
+	require.NoError(t, err)
+	assert.Equal(t, "Property 'c' was added with value: true", result)
+
+	_, err = GenDiffString(`{"a":1}`, `{"a":2}`, "", "plain", Options{})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "input format is required")
+
+	_, err = GenDiffString(`{"a":1}`, `{"a":`, "json", "plain", Options{})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to parse second input")
+}
+
+// Helper function to create temporary files with an arbitrary extension
+func createTempFileWithExt(t *testing.T, ext, content string) string {
+	tmpfile, err := os.CreateTemp("", "gendiff_test_*"+ext)
+	require.NoError(t, err)
+
+	_, err = tmpfile.WriteString(content)
+	require.NoError(t, err)
+
+	err = tmpfile.Close()
+	require.NoError(t, err)
+
+	return tmpfile.Name()
+}
+
+func TestGenDiff_JSONChildOrderByType(t *testing.T) {
+	file1 := createTempFile(t, `{"a":1,"b":2,"c":{"x":1,"y":2},"d":4,"e":5}`)
+	file2 := createTempFile(t, `{"a":1,"b":3,"c":{"x":2,"z":3},"e":5,"f":6}`)
+	defer func() {
+		if err := os.Remove(file1); err != nil {
+			t.Logf("failed to remove temp file %s: %v", file1, err)
+		}
+		if err := os.Remove(file2); err != nil {
+			t.Logf("failed to remove temp file %s: %v", file2, err)
+		}
+	}()
+
+	result, err := GenDiffWithOptions(file1, file2, "json", Options{JSONChildOrder: JSONChildOrderType})
+	require.NoError(t, err)
+
+	var tree Node
+	require.NoError(t, json.Unmarshal([]byte(result), &tree))
+
+	order := func(node *Node) []string {
+		var items []string
+		for _, child := range node.Children {
+			items = append(items, child.Type+":"+child.Key)
+		}
+		return items
+	}
+	assert.Equal(t, []string{"removed:d", "added:f", "updated:b", "nested:c", "unchanged:a", "unchanged:e"}, order(&tree))
+	assert.Equal(t, []string{"removed:y", "added:z", "updated:x"}, order(tree.Children[3]))
+
+	// The default order stays by key
+	result, err = GenDiff(file1, file2, "json")
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal([]byte(result), &tree))
+	assert.Equal(t, []string{"unchanged:a", "updated:b", "nested:c", "removed:d", "unchanged:e", "added:f"}, order(&tree))
+
+	_, err = GenDiffWithOptions(file1, file2, "json", Options{JSONChildOrder: "size"})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "unsupported JSON child order")
+}
+
+func TestResult_FormatMultipleOutputs(t *testing.T) {
+	file1 := createTempFile(t, `{"host":"a","port":80}`)
+	file2 := createTempFile(t, `{"host":"a","port":8080}`)
+	defer func() {
+		if err := os.Remove(file1); err != nil {
+			t.Logf("failed to remove temp file %s: %v", file1, err)
+		}
+		if err := os.Remove(file2); err != nil {
+			t.Logf("failed to remove temp file %s: %v", file2, err)
+		}
+	}()
+
+	result, err := GenDiffResult(file1, file2, "stylish", Options{})
+	require.NoError(t, err)
+
+	stylish, err := result.Format("stylish")
+	require.NoError(t, err)
+	assert.Equal(t, result.Output, stylish)
+
+	jsonOutput, err := result.Format("json")
+	require.NoError(t, err)
+	var tree Node
+	require.NoError(t, json.Unmarshal([]byte(jsonOutput), &tree))
+	require.Len(t, tree.Children, 2)
+	assert.Equal(t, NodeTypeUpdated, tree.Children[1].Type)
+
+	_, err = result.Format("unknown")
+	assert.Error(t, err)
+}
+
+func TestGenDiff_StylishSymbols(t *testing.T) {
+	content1 := `{"host": "a", "port": 80, "db": {"user": "x"}}`
+	content2 := `{"host": "a", "port": 8080, "db": {"user": "y"}, "debug": true}`
+
+	t.Run("single character", func(t *testing.T) {
+		result, err := GenDiffString(content1, content2, "json", "stylish", Options{
+			Symbols: StylishSymbols{Added: "▲", Removed: "▼"},
+		})
+		require.NoError(t, err)
+		expected := `{
+    db: {
+      ▼ user: x
+      ▲ user: y
+    }
+  ▲ debug: true
+    host: a
+  ▼ port: 80
+  ▲ port: 8080
+}`
+		assert.Equal(t, expected, result)
+	})
+
+	t.Run("words are padded to equal width", func(t *testing.T) {
+		result, err := GenDiffString(content1, content2, "json", "stylish", Options{
+			Symbols: StylishSymbols{Added: "ADD", Removed: "DEL"},
+		})
+		require.NoError(t, err)
+		assert.Contains(t, result, "  ADD debug: true\n      host: a\n  DEL port: 80\n")
+	})
+}
+
+func TestGenDiff_LeftRightRoot(t *testing.T) {
+	file1 := createTempYAMLFile(t, "prod:\n  replicas: 3\n  image: app:1\nstaging:\n  replicas: 1\n")
+	file2 := createTempYAMLFile(t, "production:\n  replicas: 5\n  image: app:1\n")
+	defer func() {
+		if err := os.Remove(file1); err != nil {
+			t.Logf("failed to remove temp file %s: %v", file1, err)
+		}
+		if err := os.Remove(file2); err != nil {
+			t.Logf("failed to remove temp file %s: %v", file2, err)
+		}
+	}()
+
+	result, err := GenDiffWithOptions(file1, file2, "plain", Options{LeftRoot: "prod", RightRoot: "production"})
+	require.NoError(t, err)
+	assert.Equal(t, "Property 'replicas' was updated. From 3 to 5", result)
+
+	_, err = GenDiffWithOptions(file1, file2, "plain", Options{LeftRoot: "prod", RightRoot: "prod"})
+	assert.EqualError(t, err, "right root: key 'prod' not found")
+
+	_, err = GenDiffString(`{"prod": 1}`, `{}`, "json", "plain", Options{LeftRoot: "prod"})
+	assert.EqualError(t, err, "left root: key 'prod' is not a mapping")
+}
+
+func TestGenDiff_NormalizeMultilineStrings(t *testing.T) {
+	content1 := `{"script": "set -e\necho start \n\n\nrun\n", "single": "a "}`
+	content2 := `{"script": "set -e\necho start\n\nrun", "single": "a"}`
+
+	result, err := GenDiffString(content1, content2, "json", "plain", Options{})
+	require.NoError(t, err)
+	assert.Contains(t, result, "Property 'script' was updated")
+
+	result, err = GenDiffString(content1, content2, "json", "plain", Options{NormalizeMultilineStrings: true})
+	require.NoError(t, err)
+	// Single-line strings are not normalized
+	assert.Equal(t, "Property 'single' was updated. From 'a ' to 'a'", result)
+
+	result, err = GenDiffString(`{"s": "a\n  b"}`, `{"s": "a\nb"}`, "json", "plain", Options{NormalizeMultilineStrings: true})
+	require.NoError(t, err)
+	// Indentation is significant
+	assert.Contains(t, result, "Property 's' was updated")
+}
+
+func TestGenDiff_JSONNumberFidelityAndSortedKeys(t *testing.T) {
+	content1 := `{"id": 12345678901234567890, "meta": {"z": 1, "a": 2}}`
+	content2 := `{"id": 12345678901234567891, "meta": {"z": 1, "a": 2}, "extra": {"y": true, "b": {"d": 1, "c": 2}}}`
+
+	result, err := GenDiffString(content1, content2, "json", "json", Options{})
+	require.NoError(t, err)
+
+	// Big integers keep their exact digits instead of being rounded through float64
+	assert.Contains(t, result, `"oldValue": 12345678901234567890`)
+	assert.Contains(t, result, `"newValue": 12345678901234567891`)
+
+	// Nested object keys are sorted
+	assert.Less(t, strings.Index(result, `"b": {`), strings.Index(result, `"y": true`))
+	assert.Less(t, strings.Index(result, `"c": 2`), strings.Index(result, `"d": 1`))
+	assert.Less(t, strings.Index(result, `"a": 2`), strings.Index(result, `"z": 1`))
+
+	// Numbers that are equal in value still compare equal
+	result, err = GenDiffString(`{"n": 1.0, "big": 12345678901234567890}`, `{"n": 1, "big": 12345678901234567890}`, "json", "plain", Options{})
+	require.NoError(t, err)
+	assert.Equal(t, "", result)
+}
+
+func TestGenDiffTree(t *testing.T) {
+	dir := t.TempDir()
+	file1 := writeTestFile(t, dir, "file1.json", `{"host": "hexlet.io", "timeout": 50, "proxy": "123.234.53.22", "db": {"port": 5432}}`)
+	file2 := writeTestFile(t, dir, "file2.yml", "host: hexlet.io\ntimeout: 20\nverbose: true\ndb:\n  port: 6432\n")
+
+	tree, err := GenDiffTree(file1, file2)
+	require.NoError(t, err)
+	assert.Equal(t, NodeTypeRoot, tree.Type)
+
+	types := make(map[string]string)
+	for _, child := range tree.Children {
+		types[child.Key] = child.Type
+	}
+	assert.Equal(t, map[string]string{
+		"db":      NodeTypeNested,
+		"host":    NodeTypeUnchanged,
+		"proxy":   NodeTypeRemoved,
+		"timeout": NodeTypeUpdated,
+		"verbose": NodeTypeAdded,
+	}, types)
+
+	db := tree.Children[0]
+	require.Len(t, db.Children, 1)
+	assert.Equal(t, "port", db.Children[0].Key)
+	assert.Equal(t, NodeTypeUpdated, db.Children[0].Type)
+
+	_, err = GenDiffTree(file1, dir+"/missing.json")
+	assert.Error(t, err)
+}
+
+func TestBuildDiff(t *testing.T) {
+	tree := BuildDiff(
+		map[string]interface{}{"a": 1, "b": map[string]interface{}{"c": true}},
+		map[string]interface{}{"a": 2, "b": map[string]interface{}{"c": true}, "d": "x"},
+	)
+
+	require.Len(t, tree.Children, 3)
+	assert.Equal(t, "a", tree.Children[0].Key)
+	assert.Equal(t, NodeTypeUpdated, tree.Children[0].Type)
+	assert.Equal(t, 1, tree.Children[0].OldValue)
+	assert.Equal(t, 2, tree.Children[0].NewValue)
+	assert.Equal(t, NodeTypeUnchanged, tree.Children[1].Type)
+	assert.Equal(t, NodeTypeAdded, tree.Children[2].Type)
+	assert.Equal(t, "x", tree.Children[2].NewValue)
+}
+
+func TestGenDiff_TypeAwareEquality(t *testing.T) {
+	content1 := `{"int": 1, "float": 1.5, "bool": true, "null": null, "list": [1, "2"]}`
+	content2 := `{"int": "1", "float": "1.5", "bool": "true", "null": "null", "list": ["1", 2]}`
+
+	result, err := GenDiffString(content1, content2, "json", "plain", Options{})
+	require.NoError(t, err)
+	assert.Equal(t, `Property 'bool' was updated. From true to 'true'
+Property 'float' was updated. From 1.5 to '1.5'
+Property 'int' was updated. From 1 to '1'
+Property 'list' was updated. From [1 2] to [1 2]
+Property 'null' was updated. From null to 'null'`, result)
+
+	// The same number decoded as int (YAML) and float64 (JSON) is still equal
+	data1 := map[string]interface{}{"count": 1, "list": []interface{}{1, 2}}
+	data2 := map[string]interface{}{"count": 1.0, "list": []interface{}{1.0, 2.0}}
+	result, err = GenDiffMaps(data1, data2, "plain", Options{})
+	require.NoError(t, err)
+	assert.Empty(t, result)
+
+	// Loose mode falls back to comparing string representations; null stays distinct
+	result, err = GenDiffString(content1, content2, "json", "plain", Options{LooseTypes: true})
+	require.NoError(t, err)
+	assert.Equal(t, "Property 'null' was updated. From null to 'null'", result)
+}
+
+func TestGenDiff_StylishColor(t *testing.T) {
+	content1 := `{"host": "hexlet.io", "timeout": 50, "proxy": "1.2.3.4"}`
+	content2 := `{"host": "hexlet.io", "timeout": 20, "verbose": true}`
+
+	result, err := GenDiffString(content1, content2, "json", "stylish", Options{Color: true})
+	require.NoError(t, err)
+	assert.Equal(t, "{\n"+
+		"    host: hexlet.io\n"+
+		"  \x1b[31m- proxy: 1.2.3.4\x1b[0m\n"+
+		"  \x1b[31m- timeout: 50\x1b[0m\n"+
+		"  \x1b[32m+ timeout: 20\x1b[0m\n"+
+		"  \x1b[32m+ verbose: true\x1b[0m\n"+
+		"}", result)
+
+	result, err = GenDiffString(content1, content2, "json", "stylish", Options{})
+	require.NoError(t, err)
+	assert.NotContains(t, result, "\x1b[")
+}
+
+func TestStylishStringQuoting(t *testing.T) {
+	content1 := `{"motd": "hello world", "url": "http://a:80", "same": "key: value", "db": {"dsn": "host=a port=1"}}`
+	content2 := `{"motd": "hello: world", "url": "http://b:80", "same": "key: value", "extra": {"note": "a: b c"}, "db": {"dsn": "host=b port=1"}}`
+
+	result, err := GenDiffString(content1, content2, "json", "stylish", Options{})
+	require.NoError(t, err)
+	// Strings are never quoted in stylish output, whatever node type holds them
+	assert.Equal(t, `{
+    db: {
+      - dsn: host=a port=1
+      + dsn: host=b port=1
+    }
+  + extra: {
+        note: a: b c
+    }
+  - motd: hello world
+  + motd: hello: world
+    same: key: value
+  - url: http://a:80
+  + url: http://b:80
+}`, result)
+	assert.NotContains(t, result, `"`)
+
+	// Plain is the only text format that quotes strings
+	result, err = GenDiffString(content1, content2, "json", "plain", Options{})
+	require.NoError(t, err)
+	assert.Contains(t, result, "Property 'motd' was updated. From 'hello world' to 'hello: world'")
+}
+
+func TestStylishDeepIndentation(t *testing.T) {
+	content1 := `{"a": {"b": {"c": {"keep": 1}}}}`
+	content2 := `{"a": {"b": {"c": {"keep": 1, "added": {"l1": {"l2": {"l3": {"l4": "deep"}}}, "x": true}}}}}`
+
+	result, err := GenDiffString(content1, content2, "json", "stylish", Options{})
+	require.NoError(t, err)
+
+	// Every level adds exactly four columns, whatever the depth of the added key
+	indent := func(depth int) string { return strings.Repeat(" ", depth*4) }
+	expected := strings.Join([]string{
+		"{",
+		indent(1) + "a: {",
+		indent(2) + "b: {",
+		indent(3) + "c: {",
+		indent(4)[2:] + "+ added: {",
+		indent(5) + "l1: {",
+		indent(6) + "l2: {",
+		indent(7) + "l3: {",
+		indent(8) + "l4: deep",
+		indent(7) + "}",
+		indent(6) + "}",
+		indent(5) + "}",
+		indent(5) + "x: true",
+		indent(4) + "}",
+		indent(4) + "keep: 1",
+		indent(3) + "}",
+		indent(2) + "}",
+		indent(1) + "}",
+		"}",
+	}, "\n")
+	assert.Equal(t, expected, result)
+
+	// Removed and unchanged maps deep in the tree use the same formula
+	result, err = GenDiffString(content2, content1, "json", "stylish", Options{})
+	require.NoError(t, err)
+	assert.Equal(t, strings.Replace(expected, "+ added", "- added", 1), result)
+}
+
+func TestStylishIndentWidth(t *testing.T) {
+	content1 := `{"host": "a", "db": {"user": "x", "pool": 5}}`
+	content2 := `{"host": "a", "db": {"user": "y", "ssl": {"mode": "on"}}}`
+
+	tests := []struct {
+		width    int
+		expected string
+	}{
+		{2, `{
+  db: {
+  - pool: 5
+  + ssl: {
+      mode: on
+    }
+  - user: x
+  + user: y
+  }
+  host: a
+}`},
+		{8, `{
+        db: {
+              - pool: 5
+              + ssl: {
+                        mode: on
+                }
+              - user: x
+              + user: y
+        }
+        host: a
+}`},
+		// Too narrow for the markers, so the default is used
+		{1, `{
+    db: {
+      - pool: 5
+      + ssl: {
+            mode: on
+        }
+      - user: x
+      + user: y
+    }
+    host: a
+}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("width %d", tt.width), func(t *testing.T) {
+			result, err := GenDiffString(content1, content2, "json", "stylish", Options{IndentWidth: tt.width})
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+
+	// Guides follow the configured width
+	result, err := GenDiffString(content1, content2, "json", "stylish", Options{IndentWidth: 2, Guides: true})
+	require.NoError(t, err)
+	assert.Contains(t, result, "│ + ssl: {\n│ │ │ mode: on")
+}
+
+func TestGenDiff_StylishGuides(t *testing.T) {
+	t.Run("wrapped and multiline values get no guides", func(t *testing.T) {
+		content1 := `{"db": {"note": "old"}}`
+		content2 := `{"db": {"note": "alpha beta gamma delta"}}`
+		result, err := GenDiffString(content1, content2, "json", "stylish", Options{Guides: true, Wrap: 24})
+		require.NoError(t, err)
+		expected := `{
+│   db: {
+│   │ - note: old
+│   │ + note: alpha beta
+            gamma delta
+│   }
+}`
+		assert.Equal(t, expected, result)
+
+		content2 = `{"db": {"note": "first\n    second"}}`
+		result, err = GenDiffString(content1, content2, "json", "stylish", Options{Guides: true})
+		require.NoError(t, err)
+		assert.Contains(t, result, "│   │ + note: first\n    second\n│   }")
+	})
+
+	t.Run("custom symbols with narrow indent", func(t *testing.T) {
+		content1 := `{"db": {"port": 1}}`
+		content2 := `{"db": {"port": 2}}`
+		result, err := GenDiffString(content1, content2, "json", "stylish", Options{
+			IndentWidth: 2,
+			Guides:      true,
+			Symbols:     StylishSymbols{Added: "ADD", Removed: "DEL", Unchanged: "."},
+		})
+		require.NoError(t, err)
+		expected := `{
+.   db: {
+│   DEL port: 1
+│   ADD port: 2
+.   }
+}`
+		assert.Equal(t, expected, result)
+	})
+}
+
+func TestPlainSeparator(t *testing.T) {
+	content1 := `{"a.b": 1, "a": {"b": 2}, "paths": {"/api/v1": {"timeout": 5}}}`
+	content2 := `{"a.b": 3, "a": {"b": 4}, "paths": {"/api/v1": {"timeout": 10}}}`
+
+	t.Run("default separator quotes dotted keys", func(t *testing.T) {
+		result, err := GenDiffString(content1, content2, "json", "plain", Options{})
+		require.NoError(t, err)
+		assert.Equal(t, `Property 'a.b' was updated. From 2 to 4
+Property '[a.b]' was updated. From 1 to 3
+Property 'paths./api/v1.timeout' was updated. From 5 to 10`, result)
+	})
+
+	t.Run("custom separator", func(t *testing.T) {
+		result, err := GenDiffString(content1, content2, "json", "plain", Options{PlainSeparator: "/"})
+		require.NoError(t, err)
+		assert.Equal(t, `Property 'a/b' was updated. From 2 to 4
+Property 'a.b' was updated. From 1 to 3
+Property 'paths/[/api/v1]/timeout' was updated. From 5 to 10`, result)
+	})
+
+	t.Run("multi-character separator", func(t *testing.T) {
+		result, err := GenDiffString(content1, content2, "json", "plain", Options{PlainSeparator: " > "})
+		require.NoError(t, err)
+		assert.Contains(t, result, "Property 'a > b' was updated. From 2 to 4")
+	})
+}
+
+func TestPlainKeyPathOrder(t *testing.T) {
+	content1 := `{"z": 1, "a": {"b": 1, "c": 2}, "m": [1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11], "k": true}`
+	content2 := `{"z": 2, "a": {"c": 3, "d": 4}, "m": [1, 2, 3, 4, 5, 6, 7, 8, 9, 0, 12], "y": null}`
+
+	result, err := GenDiffString(content1, content2, "json", "plain", Options{IndexArrays: true})
+	require.NoError(t, err)
+	// Lines follow the key path, not the alphabetical order of the sentences
+	assert.Equal(t, `Property 'a.b' was removed
+Property 'a.c' was updated. From 2 to 3
+Property 'a.d' was added with value: 4
+Property 'k' was removed
+Property 'm.9' was updated. From 10 to 0
+Property 'm.10' was updated. From 11 to 12
+Property 'y' was added with value: null
+Property 'z' was updated. From 1 to 2`, result)
+}
+
+func TestPlainExpandComplex(t *testing.T) {
+	content1 := `{"db": {"host": "a"}, "tags": ["x"]}`
+	content2 := `{"db": {"host": "a", "replica": {"host": "b", "port": 5432, "opts": {"ssl": true}}}, "tags": ["x", "y"]}`
+
+	result, err := GenDiffString(content1, content2, "json", "plain", Options{})
+	require.NoError(t, err)
+	assert.Equal(t, "Property 'db.replica' was added with value: [complex value]\nProperty 'tags' was updated. From [x] to [x y]", result)
+
+	result, err = GenDiffString(content1, content2, "json", "plain", Options{PlainExpandComplex: true})
+	require.NoError(t, err)
+	assert.Equal(t, `Property 'db.replica' was added with value: {"host":"b","opts":{"ssl":true},"port":5432}
+Property 'tags' was updated. From ["x"] to ["x","y"]`, result)
+}
+
+func TestNodePaths(t *testing.T) {
+	tree := BuildDiff(
+		map[string]interface{}{"db": map[string]interface{}{"user": "x", "pool": 5, "ssl": map[string]interface{}{"mode": "on"}}},
+		map[string]interface{}{"db": map[string]interface{}{"user": "y", "ssl": map[string]interface{}{"mode": "on", "cert": "c"}}},
+	)
+
+	db := tree.Children[0]
+	assert.Equal(t, []string{"db"}, db.Path)
+	assert.Equal(t, NodeTypeRemoved, db.Children[0].Type)
+	assert.Equal(t, []string{"db", "pool"}, db.Children[0].Path)
+	assert.Equal(t, NodeTypeAdded, db.Children[1].Children[0].Type)
+	assert.Equal(t, []string{"db", "ssl", "cert"}, db.Children[1].Children[0].Path)
+	assert.Equal(t, NodeTypeUpdated, db.Children[2].Type)
+	assert.Equal(t, []string{"db", "user"}, db.Children[2].Path)
+
+	content1 := `{"db": {"user": "x"}}`
+	content2 := `{"db": {"user": "y"}}`
+
+	// Paths stay out of the json output unless requested
+	result, err := GenDiffString(content1, content2, "json", "json", Options{})
+	require.NoError(t, err)
+	assert.NotContains(t, result, `"path"`)
+
+	result, err = GenDiffString(content1, content2, "json", "json", Options{NodePaths: true})
+	require.NoError(t, err)
+	assert.Contains(t, result, "\"path\": [\n            \"db\",\n            \"user\"\n          ]")
+}
@@ -0,0 +1,19 @@
+package code
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+)
+
+// gunzip распаковывает содержимое в формате gzip
+func gunzip(content []byte) ([]byte, error) {
+	reader, err := gzip.NewReader(bytes.NewReader(content))
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = reader.Close()
+	}()
+	return io.ReadAll(reader)
+}
@@ -0,0 +1,37 @@
+package code
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenDiff_GzipInput(t *testing.T) {
+	compressed := filepath.Join("testdata", "fixture", "file1.json.gz")
+
+	t.Run("matches plain equivalent", func(t *testing.T) {
+		result, err := GenDiff(compressed, filepath.Join("testdata", "fixture", "file1.json"), "plain")
+		require.NoError(t, err)
+		assert.Empty(t, result)
+	})
+
+	t.Run("diffs like plain file", func(t *testing.T) {
+		expected, err := os.ReadFile(filepath.Join("testdata", "fixture", "result_plain.txt"))
+		require.NoError(t, err)
+
+		result, err := GenDiff(compressed, filepath.Join("testdata", "fixture", "file2.json"), "plain")
+		require.NoError(t, err)
+		assert.Equal(t, strings.TrimSuffix(string(expected), "\n"), result)
+	})
+
+	t.Run("corrupt stream", func(t *testing.T) {
+		corrupt := writeTestFile(t, t.TempDir(), "broken.json.gz", `{"not": "gzip"}`)
+		_, err := GenDiff(corrupt, filepath.Join("testdata", "fixture", "file1.json"), "plain")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to decompress file")
+	})
+}
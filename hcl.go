@@ -0,0 +1,486 @@
+package code
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// hclNumberPattern распознаёт числовые литералы HCL
+var hclNumberPattern = regexp.MustCompile(`^-?\d+(\.\d+)?([eE][+-]?\d+)?$`)
+
+// hclParser разбирает HCL (Terraform) документ. Атрибуты становятся скалярными
+// значениями, списками и объектами, блоки — вложенными картами по типу блока и его
+// меткам: resource "aws_instance" "web" {...} даёт resource.aws_instance.web.
+// Повторяющиеся блоки собираются в массив. Выражения, которые не являются литералами
+// (ссылки, вызовы функций, условия), не вычисляются и сохраняются исходной строкой;
+// интерполяции ${...} внутри строк также остаются как есть.
+type hclParser struct {
+	src string
+	pos int
+}
+
+// parseHCL парсит HCL содержимое (.hcl, .tf, .tfvars)
+func parseHCL(content []byte) (map[string]interface{}, error) {
+	p := &hclParser{src: string(content)}
+	body, err := p.parseBody(false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse HCL: line %d: %w", p.line(), err)
+	}
+	return body, nil
+}
+
+// line возвращает номер текущей строки для сообщений об ошибках
+func (p *hclParser) line() int {
+	return strings.Count(p.src[:p.pos], "\n") + 1
+}
+
+func (p *hclParser) eof() bool {
+	return p.pos >= len(p.src)
+}
+
+func (p *hclParser) peek() byte {
+	if p.eof() {
+		return 0
+	}
+	return p.src[p.pos]
+}
+
+// skipSpace пропускает пробелы и комментарии; переводы строк пропускаются,
+// только если newlines равно true
+func (p *hclParser) skipSpace(newlines bool) {
+	for !p.eof() {
+		rest := p.src[p.pos:]
+		switch {
+		case rest[0] == ' ' || rest[0] == '\t' || rest[0] == '\r':
+			p.pos++
+		case rest[0] == '\n' && newlines:
+			p.pos++
+		case rest[0] == '#' || strings.HasPrefix(rest, "//"):
+			if end := strings.IndexByte(rest, '\n'); end >= 0 {
+				p.pos += end
+			} else {
+				p.pos = len(p.src)
+			}
+		case strings.HasPrefix(rest, "/*"):
+			if end := strings.Index(rest[2:], "*/"); end >= 0 {
+				p.pos += end + 4
+			} else {
+				p.pos = len(p.src)
+			}
+		default:
+			return
+		}
+	}
+}
+
+// parseBody разбирает атрибуты и блоки до конца документа или закрывающей скобки
+func (p *hclParser) parseBody(inBlock bool) (map[string]interface{}, error) {
+	body := make(map[string]interface{})
+	for {
+		p.skipSpace(true)
+		if p.eof() {
+			if inBlock {
+				return nil, errors.New("unexpected end of input, expected '}'")
+			}
+			return body, nil
+		}
+		if p.peek() == '}' && inBlock {
+			p.pos++
+			return body, nil
+		}
+
+		name := p.readIdentifier()
+		if name == "" {
+			return nil, fmt.Errorf("unexpected character %q", p.peek())
+		}
+		p.skipSpace(false)
+
+		if p.peek() == '=' {
+			p.pos++
+			if _, exists := body[name]; exists {
+				return nil, fmt.Errorf("duplicate attribute %q", name)
+			}
+			value, err := p.parseValue()
+			if err != nil {
+				return nil, err
+			}
+			body[name] = value
+			if err := p.expectLineEnd(); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		if err := p.parseBlock(body, name); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// parseBlock разбирает метки и тело блока и вкладывает его в body по типу и меткам
+func (p *hclParser) parseBlock(body map[string]interface{}, blockType string) error {
+	keys := []string{blockType}
+	for p.peek() != '{' {
+		var label string
+		if p.peek() == '"' {
+			value, err := p.parseString()
+			if err != nil {
+				return err
+			}
+			label = value
+		} else if label = p.readIdentifier(); label == "" {
+			return fmt.Errorf("expected '=' or block labels after %q", blockType)
+		}
+		keys = append(keys, label)
+		p.skipSpace(false)
+	}
+	p.pos++
+
+	blockBody, err := p.parseBody(true)
+	if err != nil {
+		return err
+	}
+
+	target := body
+	for _, key := range keys[:len(keys)-1] {
+		next, exists := target[key]
+		if !exists {
+			next = make(map[string]interface{})
+			target[key] = next
+		}
+		nested, ok := next.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("block %q conflicts with an attribute or repeated block", strings.Join(keys, " "))
+		}
+		target = nested
+	}
+	addRepeated(target, keys[len(keys)-1], blockBody)
+	return nil
+}
+
+// expectLineEnd проверяет, что после значения атрибута строка заканчивается
+func (p *hclParser) expectLineEnd() error {
+	p.skipSpace(false)
+	if p.eof() || p.peek() == '\n' || p.peek() == '}' {
+		return nil
+	}
+	return fmt.Errorf("unexpected character %q after attribute value", p.peek())
+}
+
+// readIdentifier читает идентификатор: буквы, цифры, "_" и "-"
+func (p *hclParser) readIdentifier() string {
+	start := p.pos
+	for !p.eof() {
+		c := p.peek()
+		if c == '_' || c == '-' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= 0x80 {
+			p.pos++
+			continue
+		}
+		break
+	}
+	return p.src[start:p.pos]
+}
+
+// parseValue разбирает значение атрибута, элемента списка или поля объекта
+func (p *hclParser) parseValue() (interface{}, error) {
+	p.skipSpace(false)
+	start := p.pos
+	rest := p.src[p.pos:]
+
+	var value interface{}
+	var err error
+	switch {
+	case strings.HasPrefix(rest, "<<"):
+		return p.parseHeredoc()
+	case rest == "":
+		return nil, errors.New("expected a value")
+	case rest[0] == '"':
+		value, err = p.parseString()
+	case rest[0] == '[' && !p.isForExpression():
+		value, err = p.parseList()
+	case rest[0] == '{' && !p.isForExpression():
+		value, err = p.parseObject()
+	default:
+		return p.parseExpression()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Литерал, за которым следует оператор, является выражением и сохраняется целиком
+	p.skipSpace(false)
+	if !p.atValueEnd() {
+		p.pos = start
+		return p.parseExpression()
+	}
+	return value, nil
+}
+
+// atValueEnd проверяет, стоит ли парсер на границе значения
+func (p *hclParser) atValueEnd() bool {
+	return p.eof() || strings.IndexByte("\n,]}", p.peek()) >= 0
+}
+
+// isForExpression проверяет, начинается ли скобка с for-выражения ([for x in ...])
+func (p *hclParser) isForExpression() bool {
+	rest := strings.TrimLeft(p.src[p.pos+1:], " \t\r\n")
+	return strings.HasPrefix(rest, "for ")
+}
+
+// parseExpression сохраняет выражение исходным текстом до конца значения, учитывая
+// вложенные скобки и строки; числа, true, false и null распознаются как литералы
+func (p *hclParser) parseExpression() (interface{}, error) {
+	start := p.pos
+	depth := 0
+	for !p.eof() {
+		c := p.peek()
+		if depth == 0 && (strings.IndexByte("\n,)]}", c) >= 0 || c == '#' || strings.HasPrefix(p.src[p.pos:], "//")) {
+			break
+		}
+		switch c {
+		case '(', '[', '{':
+			depth++
+		case ')', ']', '}':
+			depth--
+		case '"':
+			if _, err := p.parseString(); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		p.pos++
+	}
+	if depth != 0 {
+		return nil, errors.New("unbalanced brackets in expression")
+	}
+
+	raw := strings.TrimSpace(p.src[start:p.pos])
+	switch {
+	case raw == "":
+		return nil, errors.New("expected a value")
+	case raw == "true":
+		return true, nil
+	case raw == "false":
+		return false, nil
+	case raw == "null":
+		return nil, nil
+	case hclNumberPattern.MatchString(raw):
+		return strconv.ParseFloat(raw, 64)
+	}
+	return raw, nil
+}
+
+// parseString разбирает строку в кавычках. Escape-последовательности раскрываются,
+// а интерполяции ${...} и директивы %{...} сохраняются без изменений.
+func (p *hclParser) parseString() (string, error) {
+	p.pos++ // открывающая кавычка
+	var result strings.Builder
+	for !p.eof() {
+		c := p.peek()
+		switch {
+		case c == '"':
+			p.pos++
+			return result.String(), nil
+		case c == '\n':
+			return "", errors.New("unterminated string")
+		case c == '\\' && p.pos+1 < len(p.src):
+			p.pos++
+			switch esc := p.peek(); esc {
+			case 'n':
+				result.WriteByte('\n')
+			case 't':
+				result.WriteByte('\t')
+			case 'r':
+				result.WriteByte('\r')
+			case 'u':
+				if p.pos+5 > len(p.src) {
+					return "", errors.New("malformed \\u escape")
+				}
+				code, err := strconv.ParseUint(p.src[p.pos+1:p.pos+5], 16, 16)
+				if err != nil {
+					return "", errors.New("malformed \\u escape")
+				}
+				result.WriteRune(rune(code))
+				p.pos += 4
+			default:
+				result.WriteByte(esc)
+			}
+			p.pos++
+		case (c == '$' || c == '%') && strings.HasPrefix(p.src[p.pos+1:], "{"):
+			end, err := p.templateEnd()
+			if err != nil {
+				return "", err
+			}
+			result.WriteString(p.src[p.pos:end])
+			p.pos = end
+		default:
+			result.WriteByte(c)
+			p.pos++
+		}
+	}
+	return "", errors.New("unterminated string")
+}
+
+// templateEnd возвращает позицию после закрывающей скобки интерполяции ${...}
+func (p *hclParser) templateEnd() (int, error) {
+	depth := 0
+	inString := false
+	for i := p.pos + 1; i < len(p.src); i++ {
+		switch c := p.src[i]; {
+		case c == '\n':
+			return 0, errors.New("unterminated interpolation")
+		case inString && c == '\\':
+			i++
+		case c == '"':
+			inString = !inString
+		case !inString && c == '{':
+			depth++
+		case !inString && c == '}':
+			depth--
+			if depth == 0 {
+				return i + 1, nil
+			}
+		}
+	}
+	return 0, errors.New("unterminated interpolation")
+}
+
+// parseHeredoc разбирает многострочную строку <<ID или <<-ID; во втором варианте
+// общий отступ строк убирается
+func (p *hclParser) parseHeredoc() (string, error) {
+	p.pos += 2
+	indented := p.peek() == '-'
+	if indented {
+		p.pos++
+	}
+	marker := p.readIdentifier()
+	if marker == "" {
+		return "", errors.New("expected heredoc marker")
+	}
+	newline := strings.IndexByte(p.src[p.pos:], '\n')
+	if newline < 0 {
+		return "", errors.New("unterminated heredoc")
+	}
+	p.pos += newline + 1
+
+	var lines []string
+	for !p.eof() {
+		end := strings.IndexByte(p.src[p.pos:], '\n')
+		if end < 0 {
+			end = len(p.src) - p.pos
+		}
+		line := strings.TrimRight(p.src[p.pos:p.pos+end], "\r")
+		p.pos += end
+		if strings.TrimSpace(line) == marker {
+			if indented {
+				lines = trimCommonIndent(lines)
+			}
+			if len(lines) == 0 {
+				return "", nil
+			}
+			return strings.Join(lines, "\n") + "\n", nil
+		}
+		lines = append(lines, line)
+		if !p.eof() {
+			p.pos++
+		}
+	}
+	return "", fmt.Errorf("unterminated heredoc %s", marker)
+}
+
+// trimCommonIndent убирает наименьший общий отступ непустых строк
+func trimCommonIndent(lines []string) []string {
+	indent := -1
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		width := len(line) - len(strings.TrimLeft(line, " \t"))
+		if indent < 0 || width < indent {
+			indent = width
+		}
+	}
+
+	trimmed := make([]string, len(lines))
+	for i, line := range lines {
+		if len(line) >= indent && indent > 0 {
+			line = line[indent:]
+		}
+		trimmed[i] = line
+	}
+	return trimmed
+}
+
+// parseList разбирает список [a, b, c]; элементы разделяются запятыми или переводами строк
+func (p *hclParser) parseList() ([]interface{}, error) {
+	p.pos++
+	list := make([]interface{}, 0)
+	for {
+		p.skipSpace(true)
+		if p.eof() {
+			return nil, errors.New("unterminated list")
+		}
+		if p.peek() == ']' {
+			p.pos++
+			return list, nil
+		}
+
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, value)
+
+		p.skipSpace(true)
+		if p.peek() == ',' {
+			p.pos++
+		} else if p.peek() != ']' {
+			return nil, errors.New("expected ',' or ']' in list")
+		}
+	}
+}
+
+// parseObject разбирает объект { key = value, other: value }
+func (p *hclParser) parseObject() (map[string]interface{}, error) {
+	p.pos++
+	object := make(map[string]interface{})
+	for {
+		p.skipSpace(true)
+		if p.eof() {
+			return nil, errors.New("unterminated object")
+		}
+		if p.peek() == '}' {
+			p.pos++
+			return object, nil
+		}
+
+		var key string
+		if p.peek() == '"' {
+			var err error
+			if key, err = p.parseString(); err != nil {
+				return nil, err
+			}
+		} else if key = p.readIdentifier(); key == "" {
+			return nil, fmt.Errorf("unexpected character %q in object", p.peek())
+		}
+		p.skipSpace(false)
+		if p.peek() != '=' && p.peek() != ':' {
+			return nil, fmt.Errorf("expected '=' after object key %q", key)
+		}
+		p.pos++
+
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		object[key] = value
+
+		p.skipSpace(false)
+		if p.peek() == ',' {
+			p.pos++
+		}
+	}
+}
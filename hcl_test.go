@@ -0,0 +1,138 @@
+package code
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenDiff_TFVars(t *testing.T) {
+	dir := t.TempDir()
+	file1 := writeTestFile(t, dir, "prod1.tfvars", `# Production
+region        = "eu-west-1"
+instance_type = "t3.micro"
+
+variable "replicas" {
+  type    = number
+  default = 2
+}
+`)
+	file2 := writeTestFile(t, dir, "prod2.tfvars", `# Production
+region        = "eu-west-1"
+instance_type = "t3.micro"
+
+variable "replicas" {
+  type    = number
+  default = 3
+}
+
+variable "tags" {
+  default = {
+    team = "billing"
+    name = "${var.prefix}-app"
+  }
+}
+`)
+
+	result, err := GenDiff(file1, file2, "plain")
+	require.NoError(t, err)
+	assert.Equal(t, `Property 'variable.replicas.default' was updated. From 2 to 3
+Property 'variable.tags' was added with value: [complex value]`, result)
+
+	result, err = GenDiff(file1, file2, "stylish")
+	require.NoError(t, err)
+	assert.Contains(t, result, `      + tags: {
+            default: {
+                name: ${var.prefix}-app
+                team: billing
+            }
+        }`)
+}
+
+func TestParseHCL(t *testing.T) {
+	data, err := parseHCL([]byte(`
+/* Block comment */
+name    = "web \"frontend\""
+count   = 3
+ratio   = 0.5
+enabled = true
+nothing = null
+zones   = ["a", "b", // trailing comment
+  "c"]
+ami     = var.ami_id
+size    = var.big ? "large" : "small"
+upper   = [for s in var.list : upper(s)]
+script  = <<-EOT
+    echo hello
+      echo indented
+    EOT
+
+resource "aws_instance" "web" {
+  ami = "ami-123"
+  tags = { Name = "web", "env": "prod" }
+}
+
+resource "aws_instance" "db" {
+  ami = lookup(var.amis, "db")
+}
+
+ingress {
+  port = 80
+}
+
+ingress {
+  port = 443
+}
+`))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"name":    `web "frontend"`,
+		"count":   3.0,
+		"ratio":   0.5,
+		"enabled": true,
+		"nothing": nil,
+		"zones":   []interface{}{"a", "b", "c"},
+		"ami":     "var.ami_id",
+		"size":    `var.big ? "large" : "small"`,
+		"upper":   "[for s in var.list : upper(s)]",
+		"script":  "echo hello\n  echo indented\n",
+		"resource": map[string]interface{}{
+			"aws_instance": map[string]interface{}{
+				"web": map[string]interface{}{
+					"ami":  "ami-123",
+					"tags": map[string]interface{}{"Name": "web", "env": "prod"},
+				},
+				"db": map[string]interface{}{
+					"ami": `lookup(var.amis, "db")`,
+				},
+			},
+		},
+		"ingress": []interface{}{
+			map[string]interface{}{"port": 80.0},
+			map[string]interface{}{"port": 443.0},
+		},
+	}, data)
+}
+
+func TestParseHCL_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		errMsg  string
+	}{
+		{"unclosed block", "locals {\n  a = 1\n", "unexpected end of input, expected '}'"},
+		{"unterminated string", "a = \"open\n", "line 1: unterminated string"},
+		{"duplicate attribute", "a = 1\na = 2\n", `line 2: duplicate attribute "a"`},
+		{"unbalanced expression", "a = max(1, 2\n", "unbalanced brackets in expression"},
+		{"missing value", "a =\n", "expected a value"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseHCL([]byte(tt.content))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.errMsg)
+		})
+	}
+}
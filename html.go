@@ -0,0 +1,46 @@
+package code
+
+import (
+	"fmt"
+	"html"
+	"strings"
+)
+
+// writeHTML выводит дерево различий как вложенные списки <ul>/<li> с CSS-классами
+// diff-added, diff-removed, diff-unchanged и diff-nested. Изменённый ключ даёт два
+// элемента — со старым и с новым значением, оба дополнительно помечены diff-updated.
+// Все ключи и значения экранируются.
+func writeHTML(result textWriter, node *Node) {
+	writeHTMLList(result, node, "diff", 0)
+}
+
+// writeHTMLList выводит дочерние узлы списком <ul> с отступом depth
+func writeHTMLList(result textWriter, node *Node, class string, depth int) {
+	indent := strings.Repeat("  ", depth)
+	fmt.Fprintf(result, "%s<ul class=\"%s\">\n", indent, class)
+	for _, child := range node.Children {
+		key := html.EscapeString(child.name())
+		switch child.Type {
+		case NodeTypeNested:
+			fmt.Fprintf(result, "%s  <li class=\"diff-nested\"><span class=\"diff-key\">%s</span>\n", indent, key)
+			writeHTMLList(result, child, "diff-children", depth+2)
+			fmt.Fprintf(result, "%s  </li>\n", indent)
+		case NodeTypeAdded:
+			writeHTMLItem(result, indent, "diff-added", "+", key, child.NewValue)
+		case NodeTypeRemoved:
+			writeHTMLItem(result, indent, "diff-removed", "-", key, child.OldValue)
+		case NodeTypeUpdated:
+			writeHTMLItem(result, indent, "diff-updated diff-removed", "-", key, child.OldValue)
+			writeHTMLItem(result, indent, "diff-updated diff-added", "+", key, child.NewValue)
+		case NodeTypeUnchanged:
+			writeHTMLItem(result, indent, "diff-unchanged", " ", key, child.Value)
+		}
+	}
+	fmt.Fprintf(result, "%s</ul>\n", indent)
+}
+
+// writeHTMLItem выводит элемент списка для листа
+func writeHTMLItem(result textWriter, indent, class, marker, key string, v interface{}) {
+	fmt.Fprintf(result, "%s  <li class=\"%s\"><span class=\"diff-marker\">%s</span> <span class=\"diff-key\">%s</span>: <span class=\"diff-value\">%s</span></li>\n",
+		indent, class, marker, key, htmlTreeValue(v))
+}
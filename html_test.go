@@ -0,0 +1,37 @@
+package code
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenDiff_HTML(t *testing.T) {
+	content1 := `{"host": "hexlet.io", "timeout": 50, "common": {"banner": "hello"}}`
+	content2 := `{"host": "hexlet.io", "timeout": 20, "common": {"banner": "<script>alert('x')</script>"}}`
+
+	result, err := GenDiffString(content1, content2, "json", "html", Options{})
+	require.NoError(t, err)
+
+	assert.Equal(t, `<ul class="diff">
+  <li class="diff-nested"><span class="diff-key">common</span>
+    <ul class="diff-children">
+      <li class="diff-updated diff-removed"><span class="diff-marker">-</span> <span class="diff-key">banner</span>: <span class="diff-value">&#39;hello&#39;</span></li>
+      <li class="diff-updated diff-added"><span class="diff-marker">+</span> <span class="diff-key">banner</span>: <span class="diff-value">&#39;&lt;script&gt;alert(&#39;x&#39;)&lt;/script&gt;&#39;</span></li>
+    </ul>
+  </li>
+  <li class="diff-unchanged"><span class="diff-marker"> </span> <span class="diff-key">host</span>: <span class="diff-value">&#39;hexlet.io&#39;</span></li>
+  <li class="diff-updated diff-removed"><span class="diff-marker">-</span> <span class="diff-key">timeout</span>: <span class="diff-value">50</span></li>
+  <li class="diff-updated diff-added"><span class="diff-marker">+</span> <span class="diff-key">timeout</span>: <span class="diff-value">20</span></li>
+</ul>`, result)
+	assert.NotContains(t, result, "<script>")
+}
+
+func TestGenDiff_HTMLAddedRemoved(t *testing.T) {
+	result, err := GenDiffString(`{"old": {"a": 1}, "<key>": 1}`, `{"new": [1, 2], "<key>": 1}`, "json", "html", Options{})
+	require.NoError(t, err)
+	assert.Contains(t, result, `<li class="diff-removed"><span class="diff-marker">-</span> <span class="diff-key">old</span>: <span class="diff-value">{&#34;a&#34;:1}</span></li>`)
+	assert.Contains(t, result, `<li class="diff-added"><span class="diff-marker">+</span> <span class="diff-key">new</span>: <span class="diff-value">[1,2]</span></li>`)
+	assert.Contains(t, result, `<span class="diff-key">&lt;key&gt;</span>`)
+}
@@ -0,0 +1,73 @@
+package code
+
+import (
+	"fmt"
+	"html"
+	"strings"
+)
+
+// writeHTMLTree выводит дерево различий как вложенные элементы <details>/<summary>,
+// которые можно сворачивать в браузере. Вложенные объекты с изменениями раскрыты
+// (атрибут open), а неизменённые, добавленные и удалённые объекты свёрнуты. Все ключи
+// и значения экранируются.
+func writeHTMLTree(result textWriter, node *Node) {
+	result.WriteString("<div class=\"gendiff-tree\">\n")
+	writeHTMLTreeChildren(result, node, 1)
+	result.WriteString("</div>")
+}
+
+// writeHTMLTreeChildren выводит дочерние узлы с отступом depth
+func writeHTMLTreeChildren(result textWriter, node *Node, depth int) {
+	indent := strings.Repeat("  ", depth)
+	for _, child := range node.Children {
+		key := html.EscapeString(child.name())
+		switch child.Type {
+		case NodeTypeNested:
+			open := ""
+			if child.HasChanges() {
+				open = " open"
+			}
+			fmt.Fprintf(result, "%s<details class=\"nested\"%s>\n", indent, open)
+			fmt.Fprintf(result, "%s  <summary>%s</summary>\n", indent, key)
+			writeHTMLTreeChildren(result, child, depth+1)
+			fmt.Fprintf(result, "%s</details>\n", indent)
+		case NodeTypeAdded:
+			writeHTMLTreeValue(result, "added", "+ ", key, child.NewValue, depth)
+		case NodeTypeRemoved:
+			writeHTMLTreeValue(result, "removed", "- ", key, child.OldValue, depth)
+		case NodeTypeUpdated:
+			if isMap(child.OldValue) || isMap(child.NewValue) {
+				writeHTMLTreeValue(result, "removed", "- ", key, child.OldValue, depth)
+				writeHTMLTreeValue(result, "added", "+ ", key, child.NewValue, depth)
+				continue
+			}
+			fmt.Fprintf(result, "%s<div class=\"updated\">~ %s: %s &rarr; %s</div>\n",
+				indent, key, htmlTreeValue(child.OldValue), htmlTreeValue(child.NewValue))
+		case NodeTypeUnchanged:
+			writeHTMLTreeValue(result, "unchanged", "", key, child.Value, depth)
+		}
+	}
+}
+
+// writeHTMLTreeValue выводит значение листа; объекты выводятся свёрнутыми
+// элементами <details> с вложенными ключами
+func writeHTMLTreeValue(result textWriter, class, marker, key string, v interface{}, depth int) {
+	indent := strings.Repeat("  ", depth)
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		fmt.Fprintf(result, "%s<div class=\"%s\">%s%s: %s</div>\n", indent, class, marker, key, htmlTreeValue(v))
+		return
+	}
+
+	fmt.Fprintf(result, "%s<details class=\"%s\">\n", indent, class)
+	fmt.Fprintf(result, "%s  <summary>%s%s</summary>\n", indent, marker, key)
+	for _, nestedKey := range getSortedKeys(m) {
+		writeHTMLTreeValue(result, class, "", html.EscapeString(nestedKey), m[nestedKey], depth+1)
+	}
+	fmt.Fprintf(result, "%s</details>\n", indent)
+}
+
+// htmlTreeValue выводит значение как в drifted формате и экранирует его
+func htmlTreeValue(v interface{}) string {
+	return html.EscapeString(driftValue(v))
+}
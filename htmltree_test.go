@@ -0,0 +1,50 @@
+package code
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenDiff_HTMLTree(t *testing.T) {
+	content1 := `{
+		"common": {"setting": {"deep": {"value": 1}}, "follow": false},
+		"static": {"inner": {"a": 1}},
+		"name": "<b>old</b>"
+	}`
+	content2 := `{
+		"common": {"setting": {"deep": {"value": 2}}, "follow": false},
+		"static": {"inner": {"a": 1}},
+		"name": "\"new\" & more"
+	}`
+
+	result, err := GenDiffString(content1, content2, "json", "html-tree", Options{})
+	require.NoError(t, err)
+
+	// Nesting depth of <details> follows the tree: common > setting > deep
+	maxDepth, depth := 0, 0
+	for _, line := range strings.Split(result, "\n") {
+		switch {
+		case strings.Contains(line, "<details"):
+			depth++
+			maxDepth = max(maxDepth, depth)
+		case strings.Contains(line, "</details>"):
+			depth--
+		}
+	}
+	assert.Equal(t, 0, depth)
+	assert.Equal(t, 3, maxDepth)
+	assert.Equal(t, 5, strings.Count(result, "<details"))
+	assert.Equal(t, 3, strings.Count(result, "<details class=\"nested\" open>"))
+
+	// Changed subtrees start expanded, unchanged ones collapsed
+	assert.Contains(t, result, "  <details class=\"nested\" open>\n    <summary>common</summary>\n")
+	assert.Contains(t, result, "  <details class=\"unchanged\">\n    <summary>static</summary>\n")
+	assert.Contains(t, result, "<div class=\"updated\">~ value: 1 &rarr; 2</div>")
+
+	// Values are escaped
+	assert.Contains(t, result, "~ name: &#39;&lt;b&gt;old&lt;/b&gt;&#39; &rarr; &#39;&#34;new&#34; &amp; more&#39;")
+	assert.NotContains(t, result, "<b>")
+}
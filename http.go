@@ -0,0 +1,102 @@
+package code
+
+import (
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+)
+
+// httpClient загружает конфигурации по HTTP(S); таймаут не даёт зависнуть
+// на недоступном сервере
+var httpClient = &http.Client{Timeout: 30 * time.Second}
+
+// contentTypeFormats сопоставляет MIME-типы ответа форматам конфигурации
+var contentTypeFormats = map[string]string{
+	"application/json":   ".json",
+	"application/yaml":   ".yaml",
+	"application/x-yaml": ".yaml",
+	"text/yaml":          ".yaml",
+	"text/x-yaml":        ".yaml",
+	"application/toml":   ".toml",
+	"application/xml":    ".xml",
+	"text/xml":           ".xml",
+}
+
+// isHTTPURL проверяет, что аргумент — адрес http:// или https://
+func isHTTPURL(s string) bool {
+	lower := strings.ToLower(s)
+	return strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "https://")
+}
+
+// fetchURL выполняет GET-запрос и возвращает тело ответа и формат, если его удалось
+// определить по URL или заголовкам
+func fetchURL(rawURL string) ([]byte, string, error) {
+	location, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid URL %s: %w", rawURL, err)
+	}
+
+	resp, err := httpClient.Get(location.String())
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to fetch %s: %w", rawURL, err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("failed to fetch %s: unexpected status %s", rawURL, resp.Status)
+	}
+
+	content, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read %s: %w", rawURL, err)
+	}
+
+	// Расширение пути учитывается, только если это известный формат: у адресов вроде
+	// /config.php или /v1.2/settings оно ничего не говорит о содержимом
+	format := strings.ToLower(path.Ext(location.Path))
+	if !isKnownFormat(format) {
+		format = contentTypeFormat(resp.Header.Get("Content-Type"))
+	}
+	return content, format, nil
+}
+
+// isKnownFormat проверяет, что для расширения есть встроенный формат, зарегистрированный
+// парсер или вычислитель
+func isKnownFormat(ext string) bool {
+	if ext == "" {
+		return false
+	}
+	if configExtensions[ext] {
+		return true
+	}
+	if _, ok := lookupParser(ext); ok {
+		return true
+	}
+	_, ok := lookupEvaluator(ext)
+	return ok
+}
+
+// contentTypeFormat возвращает формат для заголовка Content-Type; типы с суффиксом
+// +json, +yaml и +xml считаются соответствующими форматами
+func contentTypeFormat(contentType string) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return ""
+	}
+	if format, ok := contentTypeFormats[mediaType]; ok {
+		return format
+	}
+	for _, suffix := range []string{"json", "yaml", "xml"} {
+		if strings.HasSuffix(mediaType, "+"+suffix) {
+			return "." + suffix
+		}
+	}
+	return ""
+}
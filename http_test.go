@@ -0,0 +1,73 @@
+package code
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenDiff_HTTPURLs(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/a.json", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"host": "hexlet.io", "timeout": 50}`))
+	})
+	mux.HandleFunc("/config/b", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/yaml; charset=utf-8")
+		_, _ = w.Write([]byte("host: hexlet.io\ntimeout: 20\n"))
+	})
+	mux.HandleFunc("/detected", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		_, _ = w.Write([]byte("host: hexlet.io\ntimeout: 50\n"))
+	})
+	mux.HandleFunc("/config.php", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"host": "hexlet.io", "timeout": 20}`))
+	})
+	mux.HandleFunc("/missing.json", func(w http.ResponseWriter, r *http.Request) {
+		http.NotFound(w, r)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	t.Run("extension and content type", func(t *testing.T) {
+		result, err := GenDiff(server.URL+"/a.json", server.URL+"/config/b", "plain")
+		require.NoError(t, err)
+		assert.Equal(t, "Property 'timeout' was updated. From 50 to 20", result)
+	})
+
+	t.Run("format detected from content", func(t *testing.T) {
+		result, err := GenDiff(server.URL+"/a.json", server.URL+"/detected", "plain")
+		require.NoError(t, err)
+		assert.Empty(t, result)
+	})
+
+	t.Run("unknown extension falls back to content type", func(t *testing.T) {
+		result, err := GenDiff(server.URL+"/a.json", server.URL+"/config.php", "plain")
+		require.NoError(t, err)
+		assert.Equal(t, "Property 'timeout' was updated. From 50 to 20", result)
+	})
+
+	t.Run("url against local file", func(t *testing.T) {
+		local := writeTestFile(t, t.TempDir(), "b.json", `{"host": "hexlet.io", "timeout": 20}`)
+		result, err := GenDiff(server.URL+"/a.json", local, "plain")
+		require.NoError(t, err)
+		assert.Equal(t, "Property 'timeout' was updated. From 50 to 20", result)
+	})
+
+	t.Run("non-200 response", func(t *testing.T) {
+		_, err := GenDiff(server.URL+"/a.json", server.URL+"/missing.json", "plain")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "unexpected status 404 Not Found")
+	})
+}
+
+func TestContentTypeFormat(t *testing.T) {
+	assert.Equal(t, ".json", contentTypeFormat("application/json"))
+	assert.Equal(t, ".json", contentTypeFormat("application/vnd.api+json"))
+	assert.Equal(t, ".yaml", contentTypeFormat("text/yaml; charset=utf-8"))
+	assert.Equal(t, "", contentTypeFormat("text/plain"))
+	assert.Equal(t, "", contentTypeFormat(""))
+}
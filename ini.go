@@ -0,0 +1,72 @@
+package code
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// parseINI парсит INI содержимое. Секции становятся вложенными картами с именем секции
+// в качестве ключа, ключи до первой секции попадают в корень. Значения остаются
+// строками; повтор ключа внутри одной секции считается ошибкой.
+func parseINI(content []byte) (map[string]interface{}, error) {
+	root := make(map[string]interface{})
+	current := root
+	section := ""
+
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, ";") || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") {
+			if !strings.HasSuffix(line, "]") {
+				return nil, fmt.Errorf("failed to parse INI: line %d: unterminated section header", lineNum)
+			}
+			section = strings.TrimSpace(line[1 : len(line)-1])
+			if section == "" {
+				return nil, fmt.Errorf("failed to parse INI: line %d: empty section name", lineNum)
+			}
+			// Повторный заголовок секции продолжает уже объявленную секцию
+			existing, ok := root[section]
+			if !ok {
+				existing = make(map[string]interface{})
+				root[section] = existing
+			}
+			if current, ok = existing.(map[string]interface{}); !ok {
+				return nil, fmt.Errorf("failed to parse INI: line %d: section %q conflicts with a top-level key", lineNum, section)
+			}
+			continue
+		}
+
+		sep := strings.IndexAny(line, "=:")
+		if sep <= 0 {
+			return nil, fmt.Errorf("failed to parse INI: line %d: expected key = value", lineNum)
+		}
+		key := strings.TrimSpace(line[:sep])
+		if _, exists := current[key]; exists {
+			if section == "" {
+				return nil, fmt.Errorf("failed to parse INI: line %d: duplicate key %q", lineNum, key)
+			}
+			return nil, fmt.Errorf("failed to parse INI: line %d: duplicate key %q in section [%s]", lineNum, key, section)
+		}
+		current[key] = iniValue(strings.TrimSpace(line[sep+1:]))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to parse INI: %w", err)
+	}
+	return root, nil
+}
+
+// iniValue снимает со значения парные кавычки
+func iniValue(value string) string {
+	if len(value) >= 2 {
+		if first, last := value[0], value[len(value)-1]; first == last && (first == '"' || first == '\'') {
+			return value[1 : len(value)-1]
+		}
+	}
+	return value
+}
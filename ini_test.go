@@ -0,0 +1,99 @@
+package code
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenDiff_INI(t *testing.T) {
+	dir := t.TempDir()
+	file1 := writeTestFile(t, dir, "file1.ini", `; legacy service
+name = billing
+
+[database]
+host = db.local
+port = 5432
+
+[cache]
+ttl = 60
+`)
+	file2 := writeTestFile(t, dir, "file2.ini", `name = billing
+
+[database]
+host = db.local
+port = 6432
+
+[redis]
+ttl = 60
+
+[logging]
+level = "debug"
+`)
+
+	result, err := GenDiff(file1, file2, "plain")
+	require.NoError(t, err)
+	// A renamed section shows up as a removal plus an addition
+	assert.Equal(t, `Property 'cache' was removed
+Property 'database.port' was updated. From '5432' to '6432'
+Property 'logging' was added with value: [complex value]
+Property 'redis' was added with value: [complex value]`, result)
+
+	result, err = GenDiff(file1, file2, "stylish")
+	require.NoError(t, err)
+	assert.Contains(t, result, `    database: {
+        host: db.local
+      - port: 5432
+      + port: 6432
+    }`)
+	assert.Contains(t, result, `  + logging: {
+        level: debug
+    }`)
+}
+
+func TestParseINI(t *testing.T) {
+	data, err := parseINI([]byte(`# comment
+top = 1
+quoted = 'single'
+
+[section]
+key: value
+empty =
+
+[section]
+other = 2
+`))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"top":    "1",
+		"quoted": "single",
+		"section": map[string]interface{}{
+			"key":   "value",
+			"empty": "",
+			"other": "2",
+		},
+	}, data)
+}
+
+func TestParseINI_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		errMsg  string
+	}{
+		{"duplicate key in section", "[db]\nhost = a\nhost = b\n", `line 3: duplicate key "host" in section [db]`},
+		{"duplicate top-level key", "a = 1\na = 2\n", `line 2: duplicate key "a"`},
+		{"unterminated header", "[db\n", "line 1: unterminated section header"},
+		{"missing separator", "[db]\nhost\n", "line 2: expected key = value"},
+		{"section clashes with key", "db = 1\n[db]\n", `section "db" conflicts with a top-level key`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseINI([]byte(tt.content))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.errMsg)
+		})
+	}
+}
@@ -0,0 +1,168 @@
+package code
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// configInput — содержимое одной конфигурации, уже прочитанное в память. format —
+// расширение или имя формата (".json", "yaml"); пустой формат определяется по комментарию
+// в первой строке или по содержимому. name — путь файла или адрес: относительно него
+// разрешаются !include и импорты вычислителей, им помечаются ошибки и предупреждения.
+// local отмечает содержимое локального файла: только в нём разрешён !include.
+type configInput struct {
+	content []byte
+	format  string
+	name    string
+	local   bool
+}
+
+// readInput читает файл или загружает адрес http(s):// в configInput. Сжатые файлы
+// (config.json.gz) распаковываются, а формат берётся из имени без .gz.
+func readInput(filePath string) (configInput, error) {
+	if isHTTPURL(filePath) {
+		content, format, err := fetchURL(filePath)
+		if err != nil {
+			return configInput{}, err
+		}
+		return configInput{content: content, format: format, name: filePath}, nil
+	}
+
+	if _, err := os.Stat(filePath); os.IsNotExist(err) {
+		return configInput{}, fmt.Errorf("file not found: %s", filePath)
+	}
+
+	// nolint:gosec // Мы читаем только конфигурационные файлы, а не пользовательский ввод
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return configInput{}, fmt.Errorf("failed to read file %s: %w", filePath, err)
+	}
+
+	name := filePath
+	if strings.EqualFold(filepath.Ext(filePath), ".gz") {
+		if content, err = gunzip(content); err != nil {
+			return configInput{}, fmt.Errorf("failed to decompress file %s: %w", filePath, err)
+		}
+		name = filePath[:len(filePath)-len(".gz")]
+	}
+	return configInput{content: content, format: filepath.Ext(name), name: name, local: true}, nil
+}
+
+// parseInput разбирает одну конфигурацию: перекодирует UTF-16, определяет формат,
+// отдаёт содержимое зарегистрированному парсеру, вычислителю или встроенному формату
+// и при необходимости раскрывает ключи .properties
+func parseInput(in configInput, opts Options) (map[string]interface{}, []string, error) {
+	// Файлы в UTF-16 (например, от инструментов Windows) перекодируются в UTF-8
+	content, err := decodeText(in.content)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to decode: %w", err)
+	}
+
+	format := strings.ToLower(in.format)
+	if format != "" && !strings.HasPrefix(format, ".") {
+		format = "." + format
+	}
+	if format == "" {
+		detected, stripped, ok := detectFormat(content)
+		if !ok {
+			if in.name == "" {
+				return nil, nil, fmt.Errorf("cannot determine input format")
+			}
+			return nil, nil, fmt.Errorf("cannot determine file format for %s", in.name)
+		}
+		format, content = detected, stripped
+	}
+
+	// Зарегистрированные парсеры важнее встроенных форматов
+	if parser, ok := lookupParser(format); ok {
+		data, err := parser(content)
+		return data, nil, err
+	}
+
+	// Шаблонные форматы сначала вычисляются в JSON
+	if evaluator, ok := lookupEvaluator(format); ok {
+		evaluated, err := evaluator(content, in.name)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to evaluate %s: %w", in.name, err)
+		}
+		return parseContent(evaluated, ".json", opts)
+	}
+
+	var data map[string]interface{}
+	var warnings []string
+	if format == ".yml" || format == ".yaml" {
+		// YAML разбирается с учётом пути файла, чтобы разрешать !include;
+		// для содержимого не из локального файла подключения запрещены
+		yamlFile := ""
+		if in.local {
+			yamlFile = in.name
+		}
+		data, warnings, err = parseYAMLFile(content, yamlFile, opts)
+	} else {
+		data, warnings, err = parseContent(content, format, opts)
+	}
+	if err == nil {
+		data, err = nestProperties(format, data, opts)
+	}
+	return data, warnings, err
+}
+
+// buildTreeFromInputs — общий конвейер сравнения двух конфигураций в памяти: его
+// используют сравнение файлов, байтов, строк, потоков и источников. Если timing
+// не nil, к нему добавляется длительность этапов.
+func buildTreeFromInputs(in1, in2 configInput, opts Options, timing *Timing) (*Node, []string, error) {
+	if timing == nil {
+		timing = &Timing{}
+	}
+
+	data := [2]map[string]interface{}{}
+	warnings := [2][]string{}
+	durations := [2]*time.Duration{&timing.Parse1, &timing.Parse2}
+	for i, in := range []configInput{in1, in2} {
+		if err := opts.ctxErr(); err != nil {
+			return nil, nil, err
+		}
+		start := time.Now()
+		var err error
+		data[i], warnings[i], err = parseInput(in, opts)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to parse %s: %w", inputLabel(in, i), err)
+		}
+		if in.name != "" {
+			warnings[i] = prefixWarnings(in.name, warnings[i])
+		}
+		*durations[i] += time.Since(start)
+	}
+
+	data1, data2, err := selectRoots(data[0], data[1], opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := checkStrictTypes(data1, data2, opts); err != nil {
+		return nil, nil, err
+	}
+
+	start := time.Now()
+	diffTree, treeWarnings := buildTree(data1, data2, opts)
+	timing.Diff = time.Since(start)
+	// Прерванное сравнение даёт неполное дерево, поэтому оно не возвращается
+	if err := opts.ctxErr(); err != nil {
+		return nil, nil, err
+	}
+	return diffTree, concatWarnings(warnings[0], warnings[1], treeWarnings), nil
+}
+
+// inputLabel возвращает имя конфигурации для сообщений об ошибках; безымянные
+// входы называются "first input" и "second input"
+func inputLabel(in configInput, index int) string {
+	if in.name != "" {
+		return in.name
+	}
+	if index == 0 {
+		return "first input"
+	}
+	return "second input"
+}
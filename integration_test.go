@@ -21,6 +21,8 @@ func TestGenDiff_Integration(t *testing.T) {
 	}
 
 	expectedStylish := readExpected("result_stylish.txt")
+	expectedGuides := readExpected("result_stylish_guides.txt")
+	expectedSymbols := readExpected("result_stylish_symbols.txt")
 
 	// Тестируем JSON и YAML входные форматы
 	inputFormats := []string{"json", "yml"}
@@ -34,5 +36,18 @@ func TestGenDiff_Integration(t *testing.T) {
 			assert.NoError(t, err)
 			assert.Equal(t, expectedStylish, result)
 		})
+
+		t.Run(inputFormat+"_stylish_guides", func(t *testing.T) {
+			result, err := GenDiffWithOptions(file1, file2, "stylish", Options{Guides: true})
+			assert.NoError(t, err)
+			assert.Equal(t, expectedGuides, result)
+		})
+
+		t.Run(inputFormat+"_stylish_symbols", func(t *testing.T) {
+			opts := Options{Symbols: StylishSymbols{Added: "ADD", Removed: "DEL"}}
+			result, err := GenDiffWithOptions(file1, file2, "stylish", opts)
+			assert.NoError(t, err)
+			assert.Equal(t, expectedSymbols, result)
+		})
 	}
 }
@@ -0,0 +1,173 @@
+package code
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Форматы экспорта патча
+const (
+	// PatchFormatJSONPatch — JSON Patch (RFC 6902): список операций add, remove и replace
+	PatchFormatJSONPatch = "json-patch"
+	// PatchFormatMergePatch — JSON Merge Patch (RFC 7396): документ с новыми значениями,
+	// в котором null означает удаление ключа. Поэтому добавление или замена значения
+	// на null в этом формате неотличимы от удаления.
+	PatchFormatMergePatch = "merge-patch"
+)
+
+// jsonPatchOp — одна операция JSON Patch
+type jsonPatchOp struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	Value interface{} `json:"value"`
+}
+
+// MarshalJSON всегда выводит value у операций add и replace: без него операция
+// недействительна по RFC 6902, даже если новое значение — null
+func (op jsonPatchOp) MarshalJSON() ([]byte, error) {
+	if op.Op == "remove" {
+		return json.Marshal(struct {
+			Op   string `json:"op"`
+			Path string `json:"path"`
+		}{op.Op, op.Path})
+	}
+	return json.Marshal(struct {
+		Op    string      `json:"op"`
+		Path  string      `json:"path"`
+		Value interface{} `json:"value"`
+	}{op.Op, op.Path, op.Value})
+}
+
+// ExportPatch возвращает патч, который превращает первый файл во второй.
+// Массивы, изменённые поэлементно или сопоставленные по ArrayKeyFields, заменяются целиком.
+func (n *Node) ExportPatch(format string) (string, error) {
+	var patch interface{}
+	switch format {
+	case PatchFormatJSONPatch:
+		ops := []jsonPatchOp{}
+		collectPatchOps(n, "", &ops)
+		patch = ops
+	case PatchFormatMergePatch:
+		patch = mergePatch(n)
+	default:
+		return "", fmt.Errorf("unsupported patch format: %s", format)
+	}
+
+	data, err := json.MarshalIndent(patch, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to serialize patch: %w", err)
+	}
+	return string(data), nil
+}
+
+// InversePatch возвращает патч, который, будучи применён ко второму файлу,
+// восстанавливает первый, — то есть патч для отката
+func (n *Node) InversePatch(format string) (string, error) {
+	return InvertDiff(n).ExportPatch(format)
+}
+
+// InvertDiff возвращает обратное дерево различий, превращающее второй документ
+// в первый: added и removed меняются местами, у updated меняются старое и новое
+// значения, вложенные узлы обрабатываются рекурсивно. Исходное дерево не изменяется.
+func InvertDiff(node *Node) *Node {
+	inverted := *node
+	switch node.Type {
+	case NodeTypeAdded:
+		inverted.Type = NodeTypeRemoved
+		inverted.OldValue, inverted.NewValue = node.NewValue, nil
+	case NodeTypeRemoved:
+		inverted.Type = NodeTypeAdded
+		inverted.OldValue, inverted.NewValue = nil, node.OldValue
+	case NodeTypeUpdated:
+		inverted.OldValue, inverted.NewValue = node.NewValue, node.OldValue
+	case NodeTypeUnchanged:
+		if node.rightValue != nil {
+			inverted.Value, inverted.rightValue = node.rightValue, node.Value
+		}
+	}
+
+	if node.Children != nil {
+		inverted.Children = make([]*Node, 0, len(node.Children))
+		for _, child := range node.Children {
+			inverted.Children = append(inverted.Children, InvertDiff(child))
+		}
+	}
+	return &inverted
+}
+
+// collectPatchOps собирает операции JSON Patch для дочерних узлов
+func collectPatchOps(node *Node, pointer string, ops *[]jsonPatchOp) {
+	for _, child := range node.Children {
+		childPointer := pointer + "/" + escapePointer(child.name())
+		switch child.Type {
+		case NodeTypeAdded:
+			*ops = append(*ops, jsonPatchOp{Op: "add", Path: childPointer, Value: child.NewValue})
+		case NodeTypeRemoved:
+			*ops = append(*ops, jsonPatchOp{Op: "remove", Path: childPointer})
+		case NodeTypeUpdated:
+			*ops = append(*ops, jsonPatchOp{Op: "replace", Path: childPointer, Value: child.NewValue})
+		case NodeTypeNested:
+			if !child.HasChanges() {
+				continue
+			}
+			if isArrayNode(child) || child.keyField != "" {
+				*ops = append(*ops, jsonPatchOp{Op: "replace", Path: childPointer, Value: patchValue(child, false)})
+				continue
+			}
+			collectPatchOps(child, childPointer, ops)
+		}
+	}
+}
+
+// mergePatch строит документ JSON Merge Patch для дочерних узлов
+func mergePatch(node *Node) map[string]interface{} {
+	patch := make(map[string]interface{})
+	for _, child := range node.Children {
+		switch child.Type {
+		case NodeTypeAdded, NodeTypeUpdated:
+			patch[child.name()] = child.NewValue
+		case NodeTypeRemoved:
+			patch[child.name()] = nil
+		case NodeTypeNested:
+			if !child.HasChanges() {
+				continue
+			}
+			if isArrayNode(child) || child.keyField != "" {
+				patch[child.name()] = patchValue(child, false)
+				continue
+			}
+			patch[child.name()] = mergePatch(child)
+		}
+	}
+	return patch
+}
+
+// applyMergePatch применяет JSON Merge Patch к документу по правилам RFC 7396
+func applyMergePatch(target, patch interface{}) interface{} {
+	patchMap, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	targetMap, ok := target.(map[string]interface{})
+	result := make(map[string]interface{}, len(targetMap))
+	if ok {
+		for key, value := range targetMap {
+			result[key] = value
+		}
+	}
+	for key, value := range patchMap {
+		if value == nil {
+			delete(result, key)
+			continue
+		}
+		result[key] = applyMergePatch(result[key], value)
+	}
+	return result
+}
+
+// escapePointer экранирует сегмент JSON Pointer (RFC 6901)
+func escapePointer(segment string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(segment, "~", "~0"), "/", "~1")
+}
@@ -0,0 +1,99 @@
+package code
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNode_InversePatch(t *testing.T) {
+	content1 := `{"host": "a", "port": 80, "db": {"user": "x", "pool": 5}, "tags": ["a", "b"], "legacy": true}`
+	content2 := `{"host": "a", "port": 8080, "db": {"user": "y", "ssl": {"mode": "on"}}, "tags": ["b", "c"]}`
+
+	data1, err := parseJSON([]byte(content1))
+	require.NoError(t, err)
+	data2, err := parseJSON([]byte(content2))
+	require.NoError(t, err)
+	tree, _ := buildTree(data1, data2, Options{LCSArrays: true})
+
+	forward, err := tree.ExportPatch(PatchFormatMergePatch)
+	require.NoError(t, err)
+	inverse, err := tree.InversePatch(PatchFormatMergePatch)
+	require.NoError(t, err)
+
+	var forwardPatch, inversePatch interface{}
+	require.NoError(t, json.Unmarshal([]byte(forward), &forwardPatch))
+	require.NoError(t, json.Unmarshal([]byte(inverse), &inversePatch))
+
+	patched := applyMergePatch(data1, forwardPatch)
+	assert.Equal(t, map[string]interface{}(data2), patched)
+
+	restored := applyMergePatch(patched, inversePatch)
+	assert.Equal(t, map[string]interface{}(data1), restored)
+}
+
+func TestNode_InversePatchJSONPatch(t *testing.T) {
+	data1, err := parseJSON([]byte(`{"a": 1, "b": {"c/d": "x"}, "e": "gone"}`))
+	require.NoError(t, err)
+	data2, err := parseJSON([]byte(`{"a": 2, "b": {"c/d": "y"}, "f": true}`))
+	require.NoError(t, err)
+	tree, _ := buildTree(data1, data2, Options{})
+
+	inverse, err := tree.InversePatch(PatchFormatJSONPatch)
+	require.NoError(t, err)
+
+	expected := `[
+  {"op": "replace", "path": "/a", "value": 1},
+  {"op": "replace", "path": "/b/c~1d", "value": "x"},
+  {"op": "add", "path": "/e", "value": "gone"},
+  {"op": "remove", "path": "/f"}
+]`
+	assert.JSONEq(t, expected, inverse)
+
+	_, err = tree.InversePatch("unknown")
+	assert.EqualError(t, err, "unsupported patch format: unknown")
+}
+
+func TestNode_ExportPatchNullValues(t *testing.T) {
+	data1, err := parseJSON([]byte(`{"a": 1, "b": true}`))
+	require.NoError(t, err)
+	data2, err := parseJSON([]byte(`{"a": null, "b": true, "c": null}`))
+	require.NoError(t, err)
+	tree, _ := buildTree(data1, data2, Options{})
+
+	patch, err := tree.ExportPatch(PatchFormatJSONPatch)
+	require.NoError(t, err)
+	expected := `[
+  {"op": "replace", "path": "/a", "value": null},
+  {"op": "add", "path": "/c", "value": null}
+]`
+	assert.JSONEq(t, expected, patch)
+}
+
+func TestNode_ExportPatchKeyedArrays(t *testing.T) {
+	data1, err := parseJSON([]byte(`{"c": [{"name": "web", "image": "v1"}, {"name": "db", "image": "pg"}]}`))
+	require.NoError(t, err)
+	data2, err := parseJSON([]byte(`{"c": [{"name": "db", "image": "pg"}, {"name": "web", "image": "v2"}]}`))
+	require.NoError(t, err)
+	tree, _ := buildTree(data1, data2, Options{ArrayKeyFields: []string{"name"}})
+
+	// The keyed array is replaced whole instead of being addressed by key values
+	patch, err := tree.ExportPatch(PatchFormatJSONPatch)
+	require.NoError(t, err)
+	expected := `[{"op": "replace", "path": "/c", "value": [
+  {"name": "db", "image": "pg"},
+  {"name": "web", "image": "v2"}
+]}]`
+	assert.JSONEq(t, expected, patch)
+
+	merge, err := tree.ExportPatch(PatchFormatMergePatch)
+	require.NoError(t, err)
+	var mergePatchDoc interface{}
+	require.NoError(t, json.Unmarshal([]byte(merge), &mergePatchDoc))
+	patched := applyMergePatch(data1, mergePatchDoc).(map[string]interface{})
+	items, ok := patched["c"].([]interface{})
+	require.True(t, ok, "merge patch turned the array into %T", patched["c"])
+	assert.ElementsMatch(t, data2["c"], items)
+}
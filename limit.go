@@ -0,0 +1,51 @@
+package code
+
+import (
+	"fmt"
+	"strings"
+)
+
+// formatLimited форматирует не более opts.MaxChanges изменений. В текстовых форматах
+// остаток отмечается строкой "... and M more"; json и patch не дополняются, чтобы
+// вывод оставался машиночитаемым.
+func formatLimited(diffTree *Node, format string, opts Options) (string, error) {
+	remaining := opts.MaxChanges
+	limited := limitChanges(diffTree, &remaining)
+	omitted := diffTree.Stats().Changes() - limited.Stats().Changes()
+
+	opts.MaxChanges = 0
+	output, err := formatDiff(limited, format, opts)
+	if err != nil || omitted == 0 {
+		return output, err
+	}
+
+	switch strings.ToLower(format) {
+	case "stylish", "plain":
+		output += fmt.Sprintf("\n... and %d more", omitted)
+	}
+	return output, nil
+}
+
+// limitChanges возвращает копию дерева, в которой оставлены только первые remaining
+// изменений в порядке обхода. Неизменённые узлы сохраняются.
+func limitChanges(node *Node, remaining *int) *Node {
+	limited := *node
+	limited.Children = make([]*Node, 0, len(node.Children))
+	for _, child := range node.Children {
+		switch child.Type {
+		case NodeTypeNested:
+			nested := limitChanges(child, remaining)
+			if len(nested.Children) > 0 {
+				limited.Children = append(limited.Children, nested)
+			}
+		case NodeTypeUnchanged:
+			limited.Children = append(limited.Children, child)
+		default:
+			if *remaining > 0 {
+				*remaining--
+				limited.Children = append(limited.Children, child)
+			}
+		}
+	}
+	return &limited
+}
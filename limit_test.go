@@ -0,0 +1,41 @@
+package code
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenDiff_MaxChanges(t *testing.T) {
+	before := make(map[string]interface{})
+	after := make(map[string]interface{})
+	for i := 0; i < 100; i++ {
+		key := fmt.Sprintf("key%03d", i)
+		before[key] = i
+		after[key] = i + 1
+	}
+	content1, err := json.Marshal(before)
+	require.NoError(t, err)
+	content2, err := json.Marshal(after)
+	require.NoError(t, err)
+
+	result, err := GenDiffString(string(content1), string(content2), "json", "plain", Options{MaxChanges: 10})
+	require.NoError(t, err)
+
+	lines := strings.Split(result, "\n")
+	require.Len(t, lines, 11)
+	assert.Equal(t, "Property 'key000' was updated. From 0 to 1", lines[0])
+	assert.Equal(t, "Property 'key009' was updated. From 9 to 10", lines[9])
+	assert.Equal(t, "... and 90 more", lines[10])
+
+	// Machine-readable formats are truncated without the note
+	result, err = GenDiffString(string(content1), string(content2), "json", "json", Options{MaxChanges: 10})
+	require.NoError(t, err)
+	var tree Node
+	require.NoError(t, json.Unmarshal([]byte(result), &tree))
+	assert.Len(t, tree.Children, 10)
+}
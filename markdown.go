@@ -0,0 +1,32 @@
+package code
+
+import (
+	"fmt"
+	"strings"
+)
+
+// writeMarkdown выводит изменения таблицей Markdown с колонками Path, Change, Old и New.
+// Пути записываются через точку, значения — как в plain формате; у добавленных ключей
+// пуста колонка Old, у удалённых — New.
+func writeMarkdown(w textWriter, node *Node) {
+	w.WriteString("| Path | Change | Old | New |\n| --- | --- | --- | --- |")
+	walkChanges(node, nil, func(nodePath []string, child *Node) {
+		var oldValue, newValue string
+		if child.Type != NodeTypeAdded {
+			oldValue = formatPlainValue(child.OldValue)
+		}
+		if child.Type != NodeTypeRemoved {
+			newValue = formatPlainValue(child.NewValue)
+		}
+		fmt.Fprintf(w, "\n| %s | %s | %s | %s |",
+			markdownCell(strings.Join(nodePath, ".")), child.Type, markdownCell(oldValue), markdownCell(newValue))
+	})
+}
+
+// markdownCell экранирует вертикальную черту и переводы строк, чтобы значение
+// не разрывало строку таблицы
+func markdownCell(s string) string {
+	s = strings.ReplaceAll(s, "|", `\|`)
+	s = strings.ReplaceAll(s, "\r\n", "<br>")
+	return strings.ReplaceAll(s, "\n", "<br>")
+}
@@ -0,0 +1,44 @@
+package code
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenDiff_Markdown(t *testing.T) {
+	content1 := `{
+		"common": {"setting1": "Value 1", "setting2": 200, "nest": {"key": "value"}},
+		"follow": false,
+		"pipe": "a|b"
+	}`
+	content2 := `{
+		"common": {"setting1": "Value 1", "setting3": null, "nest": "str"},
+		"follow": false,
+		"pipe": "a|c",
+		"group": {"abc": 12345}
+	}`
+
+	result, err := GenDiffString(content1, content2, "json", "markdown", Options{})
+	require.NoError(t, err)
+
+	lines := strings.Split(result, "\n")
+	require.Len(t, lines, 7)
+	assert.Equal(t, "| Path | Change | Old | New |", lines[0])
+	assert.Equal(t, "| --- | --- | --- | --- |", lines[1])
+	assert.Equal(t, []string{
+		"| common.nest | updated | [complex value] | 'str' |",
+		"| common.setting2 | removed | 200 |  |",
+		"| common.setting3 | added |  | null |",
+		"| group | added |  | [complex value] |",
+		`| pipe | updated | 'a\|b' | 'a\|c' |`,
+	}, lines[2:])
+}
+
+func TestGenDiff_MarkdownNoChanges(t *testing.T) {
+	result, err := GenDiffString(`{"a": 1}`, `{"a": 1}`, "json", "markdown", Options{})
+	require.NoError(t, err)
+	assert.Equal(t, "| Path | Change | Old | New |\n| --- | --- | --- | --- |", result)
+}
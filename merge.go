@@ -0,0 +1,114 @@
+package code
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// MergeDiffs объединяет диффы A→B и B→C в дифф A→C без повторного чтения файлов.
+// Правила композиции для каждого ключа:
+//   - added, затем updated — added с итоговым значением;
+//   - added, затем removed — ключ исчезает из результата;
+//   - updated, затем removed — removed с исходным значением из A;
+//   - removed, затем added — updated (или unchanged, если значение вернулось прежним);
+//   - ключ, не упомянутый в одном из диффов, считается в нём неизменным.
+//
+// Если состояние B, описанное первым диффом, не совпадает с состоянием B во втором,
+// возвращается ошибка.
+func MergeDiffs(ab, bc *Node) (*Node, error) {
+	return mergeChildren(ab, bc, nil)
+}
+
+// mergeChildren объединяет дочерние узлы двух вложенных узлов с одинаковым путём
+func mergeChildren(ab, bc *Node, path []string) (*Node, error) {
+	root := &Node{Type: NodeTypeRoot, Children: []*Node{}}
+	d := newDiffer(Options{})
+
+	first := childrenByName(ab)
+	second := childrenByName(bc)
+
+	names := make([]string, 0, len(first)+len(second))
+	for name := range first {
+		names = append(names, name)
+	}
+	for name := range second {
+		if _, ok := first[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		childPath := appendPath(path, name)
+		n1, n2 := first[name], second[name]
+
+		// Обе стороны — вложенные карты, объединяем рекурсивно
+		if n1 != nil && n2 != nil && n1.Type == NodeTypeNested && n2.Type == NodeTypeNested &&
+			!isArrayNode(n1) && !isArrayNode(n2) {
+			merged, err := mergeChildren(n1, n2, childPath)
+			if err != nil {
+				return nil, err
+			}
+			merged.Type = NodeTypeNested
+			merged.Key = name
+			if merged.HasChanges() {
+				root.Children = append(root.Children, merged)
+			} else {
+				root.Children = append(root.Children, &Node{Type: NodeTypeUnchanged, Key: name, Value: newDocument(merged)})
+			}
+			continue
+		}
+
+		// Состояния ключа в A, B (по каждому из диффов) и C; ключ, не упомянутый
+		// в одном из диффов, считается в нём неизменным
+		var valueA, valueB1, valueB2, valueC interface{}
+		var existsA, existsB1, existsB2, existsC bool
+		if n1 != nil {
+			valueA, existsA = sideChildValue(n1, true)
+			valueB1, existsB1 = sideChildValue(n1, false)
+		}
+		if n2 != nil {
+			valueB2, existsB2 = sideChildValue(n2, true)
+			valueC, existsC = sideChildValue(n2, false)
+		}
+		if n1 == nil {
+			valueA, existsA = valueB2, existsB2
+			valueB1, existsB1 = valueB2, existsB2
+		}
+		if n2 == nil {
+			valueB2, existsB2 = valueB1, existsB1
+			valueC, existsC = valueB1, existsB1
+		}
+
+		if existsB1 != existsB2 || (existsB1 && !d.isEqual(valueB1, valueB2)) {
+			return nil, fmt.Errorf("diffs do not chain at '%s'", strings.Join(childPath, "."))
+		}
+
+		var merged *Node
+		switch {
+		case !existsA && !existsC:
+			// Ключ добавлен и затем удалён — изменения взаимно уничтожаются
+		case !existsA:
+			merged = &Node{Type: NodeTypeAdded, Key: name, NewValue: valueC}
+		case !existsC:
+			merged = &Node{Type: NodeTypeRemoved, Key: name, OldValue: valueA}
+		default:
+			merged = d.processExistingKey(name, valueA, valueC, childPath)
+		}
+		if merged != nil {
+			root.Children = append(root.Children, merged)
+		}
+	}
+
+	return root, nil
+}
+
+// childrenByName индексирует дочерние узлы по имени
+func childrenByName(node *Node) map[string]*Node {
+	result := make(map[string]*Node, len(node.Children))
+	for _, child := range node.Children {
+		result[child.name()] = child
+	}
+	return result
+}
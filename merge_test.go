@@ -0,0 +1,90 @@
+package code
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergeDiffs(t *testing.T) {
+	a := map[string]interface{}{
+		"kept":      1,
+		"updated":   "a",
+		"doomed":    true,
+		"reverted":  10,
+		"resurrect": "old",
+		"nested":    map[string]interface{}{"x": 1, "y": 2},
+	}
+	b := map[string]interface{}{
+		"kept":      1,
+		"updated":   "b",
+		"doomed":    false,
+		"reverted":  20,
+		"temporary": "tmp",
+		"fresh":     1,
+		"nested":    map[string]interface{}{"x": 1, "y": 3},
+	}
+	c := map[string]interface{}{
+		"kept":      1,
+		"updated":   "c",
+		"reverted":  10,
+		"resurrect": "new",
+		"fresh":     2,
+		"nested":    map[string]interface{}{"x": 1, "y": 3, "z": 4},
+	}
+
+	ab := newDiffer(Options{}).buildDiffTree(a, b, nil)
+	bc := newDiffer(Options{}).buildDiffTree(b, c, nil)
+
+	merged, err := MergeDiffs(ab, bc)
+	require.NoError(t, err)
+
+	// The merged tree matches a direct A→C diff
+	assert.Equal(t, newDiffer(Options{}).buildDiffTree(a, c, nil), merged)
+
+	result, err := formatDiff(merged, "plain", Options{})
+	require.NoError(t, err)
+	assert.Equal(t, `Property 'doomed' was removed
+Property 'fresh' was added with value: 2
+Property 'nested.y' was updated. From 2 to 3
+Property 'nested.z' was added with value: 4
+Property 'resurrect' was updated. From 'old' to 'new'
+Property 'updated' was updated. From 'a' to 'c'`, result)
+}
+
+func TestMergeDiffs_Rules(t *testing.T) {
+	merge := func(a, b, c map[string]interface{}) *Node {
+		merged, err := MergeDiffs(
+			newDiffer(Options{}).buildDiffTree(a, b, nil),
+			newDiffer(Options{}).buildDiffTree(b, c, nil),
+		)
+		require.NoError(t, err)
+		return merged
+	}
+
+	// Added and then updated collapses into a single added with the final value
+	merged := merge(map[string]interface{}{}, map[string]interface{}{"k": 1}, map[string]interface{}{"k": 2})
+	assert.Equal(t, []*Node{{Type: NodeTypeAdded, Key: "k", NewValue: 2}}, merged.Children)
+
+	// Updated and then removed becomes removed with the original value
+	merged = merge(map[string]interface{}{"k": 1}, map[string]interface{}{"k": 2}, map[string]interface{}{})
+	assert.Equal(t, []*Node{{Type: NodeTypeRemoved, Key: "k", OldValue: 1}}, merged.Children)
+
+	// Added and then removed cancels out
+	merged = merge(map[string]interface{}{}, map[string]interface{}{"k": 1}, map[string]interface{}{})
+	assert.Empty(t, merged.Children)
+
+	// Updated and then reverted is unchanged
+	merged = merge(map[string]interface{}{"k": 1}, map[string]interface{}{"k": 2}, map[string]interface{}{"k": 1})
+	assert.Equal(t, []*Node{{Type: NodeTypeUnchanged, Key: "k", Value: 1}}, merged.Children)
+}
+
+func TestMergeDiffs_NotChained(t *testing.T) {
+	ab := newDiffer(Options{}).buildDiffTree(map[string]interface{}{"k": 1}, map[string]interface{}{"k": 2}, nil)
+	bc := newDiffer(Options{}).buildDiffTree(map[string]interface{}{"k": 3}, map[string]interface{}{"k": 4}, nil)
+
+	_, err := MergeDiffs(ab, bc)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "diffs do not chain at 'k'")
+}
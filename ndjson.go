@@ -0,0 +1,56 @@
+package code
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+)
+
+// changeRecord — одно изменение в машиночитаемых форматах (ndjson, envelope).
+// Отсутствующая сторона изменения (old у добавленного ключа, new у удалённого)
+// не выводится, а null сохраняется.
+type changeRecord struct {
+	Path string          `json:"path"`
+	Type string          `json:"type"`
+	Old  json.RawMessage `json:"old,omitempty"`
+	New  json.RawMessage `json:"new,omitempty"`
+}
+
+// changeRecords возвращает записи для всех изменений в порядке обхода дерева
+func changeRecords(node *Node) ([]changeRecord, error) {
+	records := []changeRecord{}
+	var err error
+	walkChanges(node, nil, func(nodePath []string, child *Node) {
+		if err != nil {
+			return
+		}
+		record := changeRecord{Path: strings.Join(nodePath, "."), Type: child.Type}
+		if child.Type != NodeTypeAdded {
+			if record.Old, err = json.Marshal(child.OldValue); err != nil {
+				return
+			}
+		}
+		if child.Type != NodeTypeRemoved {
+			if record.New, err = json.Marshal(child.NewValue); err != nil {
+				return
+			}
+		}
+		records = append(records, record)
+	})
+	return records, err
+}
+
+// writeNDJSON пишет в w по одному JSON-объекту на строку для каждого изменения
+func writeNDJSON(w io.Writer, node *Node) error {
+	records, err := changeRecords(node)
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(w)
+	for _, record := range records {
+		if err := encoder.Encode(record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
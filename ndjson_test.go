@@ -0,0 +1,30 @@
+package code
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenDiff_NDJSONFormat(t *testing.T) {
+	content1 := `{"a": {"b": 1, "c": "x"}, "d": true, "e": null}`
+	content2 := `{"a": {"b": 2, "c": "x"}, "f": [1, 2], "e": 0}`
+
+	result, err := GenDiffString(content1, content2, "json", "ndjson", Options{})
+	require.NoError(t, err)
+
+	lines := strings.Split(result, "\n")
+	require.Len(t, lines, 4)
+	for _, line := range lines {
+		var change map[string]interface{}
+		assert.NoError(t, json.Unmarshal([]byte(line), &change), line)
+	}
+
+	assert.Equal(t, `{"path":"a.b","type":"updated","old":1,"new":2}`, lines[0])
+	assert.Equal(t, `{"path":"d","type":"removed","old":true}`, lines[1])
+	assert.Equal(t, `{"path":"e","type":"updated","old":null,"new":0}`, lines[2])
+	assert.Equal(t, `{"path":"f","type":"added","new":[1,2]}`, lines[3])
+}
@@ -0,0 +1,343 @@
+package code
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// toFloat приводит числовое значение любого поддерживаемого типа к float64
+func toFloat(v interface{}) (float64, bool) {
+	switch val := v.(type) {
+	case float64:
+		return val, true
+	case float32:
+		return float64(val), true
+	case int:
+		return float64(val), true
+	case int64:
+		return float64(val), true
+	case int32:
+		return float64(val), true
+	case uint64:
+		return float64(val), true
+	case uint:
+		return float64(val), true
+	case json.Number:
+		f, err := val.Float64()
+		return f, err == nil
+	default:
+		return 0, false
+	}
+}
+
+// floatPrecision возвращает число знаков для округления; false, если точность полная.
+// Отрицательное значение, как и nil, сохраняет полную точность.
+func (o Options) floatPrecision() (int, bool) {
+	if o.FloatPrecision == nil || *o.FloatPrecision < 0 {
+		return 0, false
+	}
+	return *o.FloatPrecision, true
+}
+
+// roundFloat округляет число до указанного количества знаков после запятой
+func roundFloat(v float64, precision int) float64 {
+	scale := math.Pow(10, float64(precision))
+	return math.Round(v*scale) / scale
+}
+
+// numericDelta возвращает аннотацию " (-30, -60%)" с абсолютным и относительным
+// изменением числа. Для нулевого старого значения процент не выводится, для
+// нечисловых значений возвращается пустая строка.
+func numericDelta(oldValue, newValue interface{}) string {
+	oldNum, okOld := toFloat(oldValue)
+	newNum, okNew := toFloat(newValue)
+	if !okOld || !okNew {
+		return ""
+	}
+
+	delta := signedNumber(newNum - oldNum)
+	if oldNum == 0 {
+		return fmt.Sprintf(" (%s)", delta)
+	}
+	percent := roundFloat((newNum-oldNum)/math.Abs(oldNum)*100, 2)
+	return fmt.Sprintf(" (%s, %s%%)", delta, signedNumber(percent))
+}
+
+// signedNumber форматирует число с явным знаком
+func signedNumber(v float64) string {
+	formatted := strconv.FormatFloat(v, 'f', -1, 64)
+	if v > 0 {
+		return "+" + formatted
+	}
+	return formatted
+}
+
+// roundSignificant округляет число до указанного количества значащих цифр
+func roundSignificant(v float64, figures int) float64 {
+	if v == 0 || math.IsInf(v, 0) || math.IsNaN(v) {
+		return v
+	}
+	magnitude := int(math.Floor(math.Log10(math.Abs(v))))
+	precision := figures - 1 - magnitude
+	if precision >= 0 {
+		return roundFloat(v, precision)
+	}
+	// Округление до десятков, сотен и т.д.
+	scale := math.Pow(10, float64(-precision))
+	return math.Round(v/scale) * scale
+}
+
+// roundNodeFloats округляет дробные числа во всех значениях дерева различий
+func roundNodeFloats(node *Node, precision int) {
+	node.Value = roundValueFloats(node.Value, precision)
+	node.OldValue = roundValueFloats(node.OldValue, precision)
+	node.NewValue = roundValueFloats(node.NewValue, precision)
+	for _, child := range node.Children {
+		roundNodeFloats(child, precision)
+	}
+}
+
+// roundValueFloats возвращает копию значения с округлёнными дробными числами;
+// исходные карты и массивы не изменяются
+func roundValueFloats(v interface{}, precision int) interface{} {
+	switch val := v.(type) {
+	case float64:
+		return roundFloat(val, precision)
+	case json.Number:
+		if f, err := val.Float64(); err == nil {
+			return roundFloat(f, precision)
+		}
+		return val
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(val))
+		for key, item := range val {
+			result[key] = roundValueFloats(item, precision)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(val))
+		for i, item := range val {
+			result[i] = roundValueFloats(item, precision)
+		}
+		return result
+	default:
+		return v
+	}
+}
+
+// numberSeparators описывает разделители разрядов и дробной части в локали
+type numberSeparators struct {
+	group   string
+	decimal string
+}
+
+// numberLocales содержит поддерживаемые локали для NumberLocale
+var numberLocales = map[string]numberSeparators{
+	"en": {group: ",", decimal: "."},
+	"de": {group: ".", decimal: ","},
+	"fr": {group: " ", decimal: ","},
+}
+
+// localeNumber приводит к float64 число или строку с числом в формате локали
+func localeNumber(v interface{}, locale string) (float64, bool) {
+	if num, ok := toFloat(v); ok {
+		return num, true
+	}
+	str, ok := v.(string)
+	if !ok {
+		return 0, false
+	}
+	return parseLocaleNumber(str, locale)
+}
+
+// parseLocaleNumber разбирает строку вида "1.234,56" по правилам локали. Группы разрядов
+// должны быть по три цифры, иначе строка не считается числом.
+func parseLocaleNumber(s, locale string) (float64, bool) {
+	seps, ok := numberLocales[locale]
+	if !ok {
+		return 0, false
+	}
+
+	s = strings.TrimSpace(s)
+	sign := ""
+	if strings.HasPrefix(s, "-") || strings.HasPrefix(s, "+") {
+		sign, s = s[:1], s[1:]
+	}
+
+	intPart, fracPart, hasFrac := strings.Cut(s, seps.decimal)
+	if hasFrac && !isDigits(fracPart) {
+		return 0, false
+	}
+
+	groups := strings.Split(intPart, seps.group)
+	if !isDigits(groups[0]) || (len(groups) > 1 && len(groups[0]) > 3) {
+		return 0, false
+	}
+	for _, group := range groups[1:] {
+		if len(group) != 3 || !isDigits(group) {
+			return 0, false
+		}
+	}
+
+	normalized := sign + strings.Join(groups, "")
+	if hasFrac {
+		normalized += "." + fracPart
+	}
+	num, err := strconv.ParseFloat(normalized, 64)
+	return num, err == nil
+}
+
+// isDigits проверяет, что строка непустая и состоит только из цифр
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// boolIntEqual сравнивает булево значение с числом 0 или 1; второй результат false,
+// если пара значений не является парой булева значения и числа
+func boolIntEqual(a, b interface{}) (bool, bool) {
+	flag, okFlag := a.(bool)
+	num, okNum := toFloat(b)
+	if !okFlag || !okNum {
+		flag, okFlag = b.(bool)
+		num, okNum = toFloat(a)
+	}
+	if !okFlag || !okNum {
+		return false, false
+	}
+	return (flag && num == 1) || (!flag && num == 0), true
+}
+
+// unmarshalJSON декодирует JSON, сохраняя числа как json.Number
+func unmarshalJSON(content []byte, v interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.UseNumber()
+	if err := decoder.Decode(v); err != nil {
+		return err
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return fmt.Errorf("invalid character after top-level value")
+	}
+	return nil
+}
+
+// normalizeJSONNumbers заменяет json.Number на float64 везде, где это не меняет
+// запись числа при выводе, и оставляет исходную запись для остальных чисел
+func normalizeJSONNumbers(v interface{}) interface{} {
+	switch val := v.(type) {
+	case json.Number:
+		f, err := val.Float64()
+		if err == nil && keepsNumberText(val, f) {
+			return f
+		}
+		return val
+	case map[string]interface{}:
+		for key, item := range val {
+			val[key] = normalizeJSONNumbers(item)
+		}
+		return val
+	case []interface{}:
+		for i, item := range val {
+			val[i] = normalizeJSONNumbers(item)
+		}
+		return val
+	default:
+		return v
+	}
+}
+
+// keepsNumberText проверяет, что f выводится ровно так же, как записано n; иначе
+// (1.50, 10000000000, большие целые) число сохраняется в исходной записи
+func keepsNumberText(n json.Number, f float64) bool {
+	return fmt.Sprintf("%v", f) == n.String()
+}
+
+// exactNumbersEqual точно сравнивает числа, если хотя бы одно из них — json.Number;
+// второй результат false, если такого сравнения не требуется
+func exactNumbersEqual(a, b interface{}) (bool, bool) {
+	if equal, ok := nanEqual(a, b); ok {
+		return equal, true
+	}
+	_, okA := a.(json.Number)
+	_, okB := b.(json.Number)
+	if !okA && !okB {
+		return false, false
+	}
+	ratA, okA := numberRat(a)
+	ratB, okB := numberRat(b)
+	if !okA || !okB {
+		return false, false
+	}
+	return ratA.Cmp(ratB) == 0, true
+}
+
+// numbersWithin проверяет, что два числа отличаются не больше чем на epsilon.
+// Разность считается точно по десятичной записи чисел, поэтому граница включается
+// (1.0 и 1.1 равны при epsilon 0.1), а большие целые из json.Number не теряют точность.
+// Второе значение равно false, если хотя бы одно из значений не является числом.
+func numbersWithin(a, b interface{}, epsilon float64) (bool, bool) {
+	if equal, ok := nanEqual(a, b); ok {
+		return equal, true
+	}
+	ratA, okA := numberRat(a)
+	ratB, okB := numberRat(b)
+	if !okA || !okB {
+		return false, false
+	}
+	limit, ok := numberRat(epsilon)
+	if !ok {
+		return false, false
+	}
+	diff := new(big.Rat).Sub(ratA, ratB)
+	return diff.Abs(diff).Cmp(limit) <= 0, true
+}
+
+// floatsEqual сравнивает числа, считая два NaN равными: одинаковые файлы со значением
+// NaN не должны давать изменений
+func floatsEqual(a, b float64) bool {
+	return a == b || (math.IsNaN(a) && math.IsNaN(b))
+}
+
+// nanEqual сравнивает числа, если хотя бы одно из них NaN: два NaN равны, NaN и другое
+// число — нет. Второй результат false, если NaN среди чисел нет.
+func nanEqual(a, b interface{}) (bool, bool) {
+	numA, okA := toFloat(a)
+	numB, okB := toFloat(b)
+	if !okA || !okB || (!math.IsNaN(numA) && !math.IsNaN(numB)) {
+		return false, false
+	}
+	return math.IsNaN(numA) && math.IsNaN(numB), true
+}
+
+// numberRat возвращает точное рациональное значение числа
+func numberRat(v interface{}) (*big.Rat, bool) {
+	switch val := v.(type) {
+	case json.Number:
+		return new(big.Rat).SetString(val.String())
+	case int:
+		return new(big.Rat).SetInt64(int64(val)), true
+	case int64:
+		return new(big.Rat).SetInt64(val), true
+	case uint64:
+		return new(big.Rat).SetUint64(val), true
+	default:
+		f, ok := toFloat(v)
+		if !ok || math.IsInf(f, 0) || math.IsNaN(f) {
+			return nil, false
+		}
+		return new(big.Rat).SetString(strconv.FormatFloat(f, 'g', -1, 64))
+	}
+}
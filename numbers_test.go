@@ -0,0 +1,281 @@
+package code
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenDiff_FloatPrecision(t *testing.T) {
+	file1 := createTempFile(t, `{"ratio":1.234,"scale":1.231,"count":3}`)
+	file2 := createTempFile(t, `{"ratio":1.2339,"scale":1.234,"count":3}`)
+	defer func() {
+		if err := os.Remove(file1); err != nil {
+			t.Logf("failed to remove temp file %s: %v", file1, err)
+		}
+		if err := os.Remove(file2); err != nil {
+			t.Logf("failed to remove temp file %s: %v", file2, err)
+		}
+	}()
+
+	// At precision 3 only scale differs, and values are shown rounded
+	result, err := GenDiffWithOptions(file1, file2, "stylish", Options{FloatPrecision: intPtr(3)})
+	require.NoError(t, err)
+	assert.Equal(t, "{\n    count: 3\n    ratio: 1.234\n  - scale: 1.231\n  + scale: 1.234\n}", result)
+
+	// At precision 2 everything is equal
+	result, err = GenDiffWithOptions(file1, file2, "stylish", Options{FloatPrecision: intPtr(2)})
+	require.NoError(t, err)
+	assert.Equal(t, "{\n    count: 3\n    ratio: 1.23\n    scale: 1.23\n}", result)
+
+	// At precision 0 numbers are rounded to whole numbers
+	result, err = GenDiffString(`{"a": 1.4, "b": 2.6}`, `{"a": 0.6, "b": 3.4}`, "json", "plain", Options{FloatPrecision: intPtr(0)})
+	require.NoError(t, err)
+	assert.Empty(t, result)
+	result, err = GenDiffString(`{"a": 1.4}`, `{"a": 1.6}`, "json", "stylish", Options{FloatPrecision: intPtr(0)})
+	require.NoError(t, err)
+	assert.Equal(t, "{\n  - a: 1\n  + a: 2\n}", result)
+
+	// A negative precision keeps full precision, like the default
+	result, err = GenDiffWithOptions(file1, file2, "plain", Options{FloatPrecision: intPtr(-1)})
+	require.NoError(t, err)
+	assert.Contains(t, result, "From 1.234 to 1.2339")
+
+	// Full precision is kept by default
+	result, err = GenDiff(file1, file2, "plain")
+	require.NoError(t, err)
+	assert.Equal(t, "Property 'ratio' was updated. From 1.234 to 1.2339\n"+
+		"Property 'scale' was updated. From 1.231 to 1.234", result)
+}
+
+func TestGenDiff_NumberLocale(t *testing.T) {
+	content1 := `{"price": "1.234,56", "code": "1.2.3"}`
+	content2 := `{"price": 1234.56, "code": 123}`
+
+	t.Run("disabled by default", func(t *testing.T) {
+		result, err := GenDiffString(content1, content2, "json", "plain", Options{})
+		require.NoError(t, err)
+		assert.Contains(t, result, "Property 'price' was updated. From '1.234,56' to 1234.56")
+	})
+
+	t.Run("german locale", func(t *testing.T) {
+		result, err := GenDiffString(content1, content2, "json", "plain", Options{NumberLocale: "de"})
+		require.NoError(t, err)
+		assert.NotContains(t, result, "price")
+		// Malformed grouping is not treated as a number
+		assert.Contains(t, result, "Property 'code' was updated")
+	})
+}
+
+func TestParseLocaleNumber(t *testing.T) {
+	tests := []struct {
+		input  string
+		locale string
+		want   float64
+		ok     bool
+	}{
+		{"1.234,56", "de", 1234.56, true},
+		{"-1.234.567", "de", -1234567, true},
+		{"1,5", "de", 1.5, true},
+		{"1,234.56", "en", 1234.56, true},
+		{"1 234,5", "fr", 1234.5, true},
+		{"12.34,5", "de", 0, false},
+		{"1.234,56", "en", 0, false},
+		{"abc", "de", 0, false},
+		{"1,5", "xx", 0, false},
+	}
+
+	for _, tt := range tests {
+		got, ok := parseLocaleNumber(tt.input, tt.locale)
+		assert.Equal(t, tt.ok, ok, tt.input)
+		assert.InDelta(t, tt.want, got, 1e-9, tt.input)
+	}
+}
+
+func TestGenDiff_BoolAsInt(t *testing.T) {
+	content1 := `{"enabled": 1, "debug": 0, "verbose": 2}`
+	content2 := `{"enabled": true, "debug": false, "verbose": true}`
+
+	t.Run("disabled", func(t *testing.T) {
+		result, err := GenDiffString(content1, content2, "json", "plain", Options{})
+		require.NoError(t, err)
+		assert.Contains(t, result, "Property 'enabled' was updated. From 1 to true")
+		assert.Contains(t, result, "Property 'debug' was updated. From 0 to false")
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		result, err := GenDiffString(content1, content2, "json", "plain", Options{BoolAsInt: true})
+		require.NoError(t, err)
+		// Only 0 and 1 map to booleans
+		assert.Equal(t, "Property 'verbose' was updated. From 2 to true", result)
+	})
+}
+
+func TestGenDiff_SignificantFigures(t *testing.T) {
+	content1 := `{"count": 12345, "ratio": 0.0012345, "mass": 6.0221e23}`
+	content2 := `{"count": 12300, "ratio": 0.0012349, "mass": 6.0231e23}`
+
+	t.Run("disabled", func(t *testing.T) {
+		result, err := GenDiffString(content1, content2, "json", "plain", Options{})
+		require.NoError(t, err)
+		assert.Contains(t, result, "Property 'count' was updated")
+	})
+
+	t.Run("3 figures", func(t *testing.T) {
+		result, err := GenDiffString(content1, content2, "json", "plain", Options{SignificantFigures: 3})
+		require.NoError(t, err)
+		assert.Equal(t, "", result)
+	})
+
+	t.Run("4 figures", func(t *testing.T) {
+		result, err := GenDiffString(content1, content2, "json", "plain", Options{SignificantFigures: 4})
+		require.NoError(t, err)
+		assert.Contains(t, result, "Property 'count' was updated")
+		assert.Contains(t, result, "Property 'mass' was updated")
+		// 0.0012345 and 0.0012349 both round to 0.001235
+		assert.NotContains(t, result, "ratio")
+	})
+}
+
+func TestRoundSignificant(t *testing.T) {
+	assert.InDelta(t, 12300, roundSignificant(12345, 3), 1e-9)
+	assert.InDelta(t, 12350, roundSignificant(12345, 4), 1e-9)
+	assert.InDelta(t, -0.0457, roundSignificant(-0.045678, 3), 1e-12)
+	assert.Equal(t, 0.0, roundSignificant(0, 3))
+}
+
+func TestGenDiff_ShowNumericDelta(t *testing.T) {
+	content1 := `{"timeout": 50, "retries": 0, "host": "a.io", "ratio": 1.5}`
+	content2 := `{"timeout": 20, "retries": 3, "host": "b.io", "ratio": 2}`
+	opts := Options{ShowNumericDelta: true}
+
+	t.Run("plain", func(t *testing.T) {
+		result, err := GenDiffString(content1, content2, "json", "plain", opts)
+		require.NoError(t, err)
+		assert.Contains(t, result, "Property 'timeout' was updated. From 50 to 20 (-30, -60%)")
+		assert.Contains(t, result, "Property 'ratio' was updated. From 1.5 to 2 (+0.5, +33.33%)")
+		// A zero old value has no percent change
+		assert.Contains(t, result, "Property 'retries' was updated. From 0 to 3 (+3)\n")
+		// Non-numeric updates are not annotated
+		assert.Contains(t, result, "Property 'host' was updated. From 'a.io' to 'b.io'\n")
+	})
+
+	t.Run("stylish", func(t *testing.T) {
+		result, err := GenDiffString(content1, content2, "json", "stylish", opts)
+		require.NoError(t, err)
+		assert.Contains(t, result, "  - timeout: 50\n  + timeout: 20 (-30, -60%)")
+		assert.Contains(t, result, "  + host: b.io\n")
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		result, err := GenDiffString(content1, content2, "json", "plain", Options{})
+		require.NoError(t, err)
+		assert.NotContains(t, result, "%)")
+	})
+}
+
+func TestGenDiff_FloatEpsilon(t *testing.T) {
+	tests := []struct {
+		name     string
+		content1 string
+		content2 string
+		epsilon  float64
+		equal    bool
+	}{
+		{"serializer noise", `{"v": 3.14}`, `{"v": 3.1400000001}`, 1e-9, true},
+		{"inside boundary", `{"v": 1.0}`, `{"v": 1.09}`, 0.1, true},
+		{"on boundary", `{"v": 1.0}`, `{"v": 1.1}`, 0.1, true},
+		{"outside boundary", `{"v": 1.0}`, `{"v": 1.11}`, 0.1, false},
+		{"negative numbers", `{"v": -5}`, `{"v": -5.05}`, 0.1, true},
+		{"int and float", `{"v": 2}`, `{"v": 2.0000001}`, 1e-6, true},
+		{"large numbers within", `{"v": 1e20}`, `{"v": 100000000000000000100}`, 1000, true},
+		{"large numbers outside", `{"v": 1e20}`, `{"v": 1.0000001e20}`, 1000, false},
+		{"big integers within", `{"v": 9007199254740993}`, `{"v": 9007199254740995}`, 2, true},
+		{"big integers outside", `{"v": 9007199254740993}`, `{"v": 9007199254740996}`, 2, false},
+		{"disabled", `{"v": 3.14}`, `{"v": 3.1400000001}`, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := GenDiffString(tt.content1, tt.content2, "json", "plain", Options{FloatEpsilon: tt.epsilon})
+			require.NoError(t, err)
+			if tt.equal {
+				assert.Empty(t, result)
+			} else {
+				assert.Contains(t, result, "Property 'v' was updated")
+			}
+		})
+	}
+
+	// YAML integers compare against JSON floats under the tolerance as well
+	data1 := map[string]interface{}{"v": 3}
+	data2 := map[string]interface{}{"v": 3.0000000001}
+	result, err := GenDiffMaps(data1, data2, "plain", Options{FloatEpsilon: 1e-9})
+	require.NoError(t, err)
+	assert.Empty(t, result)
+
+	// Strings are not affected
+	result, err = GenDiffString(`{"v": "1.0"}`, `{"v": "1.05"}`, "json", "plain", Options{FloatEpsilon: 0.1})
+	require.NoError(t, err)
+	assert.Contains(t, result, "Property 'v' was updated")
+}
+
+func TestGenDiff_JSONNumberText(t *testing.T) {
+	content1 := `{"big": 10000000000, "price": 1.50, "port": 8080, "ratio": 1.50}`
+	content2 := `{"big": 20000000000, "price": 1.75, "port": 8080, "ratio": 1.5}`
+
+	result, err := GenDiffString(content1, content2, "json", "stylish", Options{})
+	require.NoError(t, err)
+	// The original textual form is printed; 1.50 and 1.5 are the same number
+	assert.Equal(t, "{\n  - big: 10000000000\n  + big: 20000000000\n    port: 8080\n  - price: 1.50\n  + price: 1.75\n    ratio: 1.50\n}", result)
+
+	result, err = GenDiffString(content1, content2, "json", "plain", Options{})
+	require.NoError(t, err)
+	assert.Equal(t, "Property 'big' was updated. From 10000000000 to 20000000000\nProperty 'price' was updated. From 1.50 to 1.75", result)
+
+	data, err := parseJSON([]byte(content1))
+	require.NoError(t, err)
+	assert.Equal(t, json.Number("10000000000"), data["big"])
+	assert.Equal(t, json.Number("1.50"), data["price"])
+	assert.Equal(t, 8080.0, data["port"])
+
+	// Rounding still applies to numbers kept in their textual form
+	rounded, err := GenDiffString(`{"v": 1.50}`, `{"v": 1.5001}`, "json", "plain", Options{FloatPrecision: intPtr(2)})
+	require.NoError(t, err)
+	assert.Empty(t, rounded)
+}
+
+func TestGenDiff_NaNEqual(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string][2]string{
+		"yaml": {
+			writeTestFile(t, dir, "a.yml", "x: .nan\ny: 1\n"),
+			writeTestFile(t, dir, "b.yml", "x: .nan\ny: 1\n"),
+		},
+		"toml": {
+			writeTestFile(t, dir, "a.toml", "x = nan\ny = 1\n"),
+			writeTestFile(t, dir, "b.toml", "x = nan\ny = 1\n"),
+		},
+	}
+
+	for name, pair := range files {
+		t.Run(name, func(t *testing.T) {
+			for _, opts := range []Options{{}, {FloatEpsilon: 0.1}, {FloatPrecision: intPtr(2)}, {SignificantFigures: 3}} {
+				result, err := GenDiffWithOptions(pair[0], pair[1], "plain", opts)
+				require.NoError(t, err)
+				assert.Empty(t, result, "options %+v", opts)
+			}
+		})
+	}
+
+	t.Run("NaN and number differ", func(t *testing.T) {
+		nan := writeTestFile(t, dir, "nan.yml", "x: .nan\n")
+		number := writeTestFile(t, dir, "number.yml", "x: 1.5\n")
+		result, err := GenDiffWithOptions(nan, number, "plain", Options{FloatEpsilon: 0.1})
+		require.NoError(t, err)
+		assert.Equal(t, "Property 'x' was updated. From NaN to 1.5", result)
+	})
+}
@@ -0,0 +1,58 @@
+package code
+
+import (
+	"sort"
+	"strconv"
+)
+
+// numericKey возвращает числовое значение целочисленного ключа
+func numericKey(key string) (int64, bool) {
+	n, err := strconv.ParseInt(key, 10, 64)
+	return n, err == nil
+}
+
+// alignNumericKeys переименовывает ключи второй карты, численно равные ключам первой,
+// в написание из первой карты, чтобы они сравнивались как один ключ
+func alignNumericKeys(data1, data2 map[string]interface{}) map[string]interface{} {
+	spelling := make(map[int64]string)
+	for key := range data1 {
+		if n, ok := numericKey(key); ok {
+			if existing, seen := spelling[n]; !seen || key < existing {
+				spelling[n] = key
+			}
+		}
+	}
+	if len(spelling) == 0 {
+		return data2
+	}
+
+	aligned := make(map[string]interface{}, len(data2))
+	for key, value := range data2 {
+		if n, ok := numericKey(key); ok {
+			if original, found := spelling[n]; found {
+				if _, exact := data2[original]; !exact || original == key {
+					key = original
+				}
+			}
+		}
+		aligned[key] = value
+	}
+	return aligned
+}
+
+// sortNumericKeys сортирует ключи так, что целочисленные идут первыми по возрастанию
+// значения, а остальные — по алфавиту
+func sortNumericKeys(keys []string) {
+	sort.SliceStable(keys, func(i, j int) bool {
+		a, okA := numericKey(keys[i])
+		b, okB := numericKey(keys[j])
+		switch {
+		case okA && okB && a != b:
+			return a < b
+		case okA != okB:
+			return okA
+		default:
+			return keys[i] < keys[j]
+		}
+	})
+}
@@ -0,0 +1,48 @@
+package code
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenDiff_TreatNumericKeysAsNumbers(t *testing.T) {
+	content1 := `{"01": "a", "2": "b", "10": "c", "name": "x"}`
+	content2 := `{"1": "a", "2": "b", "10": "c", "name": "x"}`
+
+	t.Run("as strings", func(t *testing.T) {
+		result, err := GenDiffString(content1, content2, "json", "plain", Options{})
+		require.NoError(t, err)
+		assert.Equal(t, "Property '01' was removed\nProperty '1' was added with value: 'a'", result)
+	})
+
+	t.Run("as numbers", func(t *testing.T) {
+		result, err := GenDiffString(content1, content2, "json", "stylish", Options{TreatNumericKeysAsNumbers: true})
+		require.NoError(t, err)
+		expected := `{
+    01: a
+    2: b
+    10: c
+    name: x
+}`
+		assert.Equal(t, expected, result)
+	})
+}
+
+func TestGenDiff_TreatNumericKeysAsNumbersNested(t *testing.T) {
+	content1 := `{"ports": {"01": "http", "2": "https"}, "name": "x"}`
+	content2 := `{"ports": {"1": "http", "2": "https"}, "name": "x"}`
+
+	data1, err := parseJSON([]byte(content1))
+	require.NoError(t, err)
+	data2, err := parseJSON([]byte(content2))
+	require.NoError(t, err)
+	tree, _ := buildTree(data1, data2, Options{TreatNumericKeysAsNumbers: true})
+
+	// Equal nested maps collapse into one unchanged node instead of a nested one
+	require.Len(t, tree.Children, 2)
+	assert.Equal(t, NodeTypeUnchanged, tree.Children[1].Type)
+	assert.Equal(t, "ports", tree.Children[1].Key)
+	assert.False(t, tree.HasChanges())
+}
@@ -0,0 +1,44 @@
+package code
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Parser разбирает содержимое файла пользовательского формата в карту
+type Parser func(content []byte) (map[string]interface{}, error)
+
+var (
+	parsersMu sync.RWMutex
+	parsers   = make(map[string]Parser)
+)
+
+// RegisterParser регистрирует парсер для расширения файла (например, ".foo"). Зарегистрированный
+// парсер проверяется раньше встроенных форматов. Расширение встроенного формата (".json",
+// ".yaml" и т.д.) можно занять только с override, иначе возвращается ошибка; повторная
+// регистрация пользовательского расширения заменяет прежний парсер.
+func RegisterParser(ext string, fn Parser, override bool) error {
+	ext = parserExt(ext)
+	if configExtensions[ext] && !override {
+		return fmt.Errorf("extension %s is handled by a built-in parser", ext)
+	}
+
+	parsersMu.Lock()
+	defer parsersMu.Unlock()
+	parsers[ext] = fn
+	return nil
+}
+
+// lookupParser возвращает парсер, зарегистрированный для расширения или формата
+func lookupParser(format string) (Parser, bool) {
+	parsersMu.RLock()
+	defer parsersMu.RUnlock()
+	parser, ok := parsers[parserExt(format)]
+	return parser, ok
+}
+
+// parserExt приводит расширение к виду ".ext" в нижнем регистре
+func parserExt(ext string) string {
+	return "." + strings.TrimPrefix(strings.ToLower(ext), ".")
+}
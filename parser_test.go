@@ -0,0 +1,67 @@
+package code
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// parseFoo is a fake parser: "key: value" lines, "!" makes the file invalid
+func parseFoo(content []byte) (map[string]interface{}, error) {
+	result := make(map[string]interface{})
+	for _, line := range strings.Split(strings.TrimSpace(string(content)), "\n") {
+		key, value, ok := strings.Cut(line, ":")
+		if !ok || strings.Contains(line, "!") {
+			return nil, errors.New("bad foo line")
+		}
+		result[strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+	return result, nil
+}
+
+func unregisterParser(t *testing.T, ext string) {
+	t.Cleanup(func() {
+		parsersMu.Lock()
+		defer parsersMu.Unlock()
+		delete(parsers, ext)
+	})
+}
+
+func TestRegisterParser(t *testing.T) {
+	require.NoError(t, RegisterParser("foo", parseFoo, false))
+	unregisterParser(t, ".foo")
+
+	dir := t.TempDir()
+	file1 := writeTestFile(t, dir, "a.foo", "host: hexlet.io\ntimeout: 50")
+	file2 := writeTestFile(t, dir, "b.FOO", "host: hexlet.io\ntimeout: 20\nproxy: on")
+	broken := writeTestFile(t, dir, "broken.foo", "!")
+
+	result, err := GenDiff(file1, file2, "plain")
+	require.NoError(t, err)
+	assert.Equal(t, "Property 'proxy' was added with value: 'on'\nProperty 'timeout' was updated. From '50' to '20'", result)
+
+	_, err = GenDiff(file1, broken, "plain")
+	assert.ErrorContains(t, err, "bad foo line")
+
+	result, err = GenDiffString("a: 1", "a: 2", "foo", "plain", Options{})
+	require.NoError(t, err)
+	assert.Equal(t, "Property 'a' was updated. From '1' to '2'", result)
+}
+
+func TestRegisterParser_BuiltinExtension(t *testing.T) {
+	err := RegisterParser(".JSON", parseFoo, false)
+	assert.ErrorContains(t, err, "extension .json is handled by a built-in parser")
+
+	require.NoError(t, RegisterParser(".json", parseFoo, true))
+	unregisterParser(t, ".json")
+
+	dir := t.TempDir()
+	file1 := writeTestFile(t, dir, "a.json", "host: a")
+	file2 := writeTestFile(t, dir, "b.json", "host: b")
+	result, err := GenDiff(file1, file2, "plain")
+	require.NoError(t, err)
+	assert.Equal(t, "Property 'host' was updated. From 'a' to 'b'", result)
+}
@@ -0,0 +1,246 @@
+package code
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// patchContext — количество строк контекста вокруг изменений в каждом фрагменте
+const patchContext = 3
+
+// noNewlineMarker следует за последней строкой текста без завершающего перевода строки
+const noNewlineMarker = `\ No newline at end of file`
+
+// patchOp описывает одну строку сценария правки: ' ' — общая, '-' — удалённая, '+' — добавленная
+type patchOp struct {
+	kind byte
+	text string
+}
+
+// formatPatch форматирует различия как unified diff между каноническими представлениями
+// обоих документов (JSON с отступом в два пробела и отсортированными ключами; массивы,
+// сопоставленные по ArrayKeyFields, упорядочены по значению ключевого поля).
+// Результат применим утилитой patch к канонической форме первого файла.
+func formatPatch(node *Node) (string, error) {
+	oldText, err := canonicalText(patchValue(node, true).(map[string]interface{}))
+	if err != nil {
+		return "", err
+	}
+	newText, err := canonicalText(patchValue(node, false).(map[string]interface{}))
+	if err != nil {
+		return "", err
+	}
+
+	ops := diffLines(strings.Split(oldText, "\n"), strings.Split(newText, "\n"))
+
+	hunks := buildHunks(ops)
+	if len(hunks) == 0 {
+		return "", nil
+	}
+
+	lines := []string{"--- a", "+++ b"}
+	lines = append(lines, hunks...)
+	return strings.Join(lines, "\n"), nil
+}
+
+// oldDocument восстанавливает по дереву различий содержимое первого файла
+func oldDocument(node *Node) map[string]interface{} {
+	return sideValue(node, true).(map[string]interface{})
+}
+
+// newDocument восстанавливает по дереву различий содержимое второго файла
+func newDocument(node *Node) map[string]interface{} {
+	return sideValue(node, false).(map[string]interface{})
+}
+
+// sideValue восстанавливает значение вложенного узла для одной из сторон сравнения:
+// массив, если узел описывает элементы массива, и карту в остальных случаях
+func sideValue(node *Node, old bool) interface{} {
+	if isArrayNode(node) {
+		items := []interface{}{}
+		for _, child := range node.Children {
+			if value, ok := sideChildValue(child, old); ok {
+				items = append(items, value)
+			}
+		}
+		return items
+	}
+
+	result := make(map[string]interface{})
+	for _, child := range node.Children {
+		if value, ok := sideChildValue(child, old); ok {
+			result[child.Key] = value
+		}
+	}
+	return result
+}
+
+// sideChildValue возвращает значение дочернего узла для одной из сторон сравнения
+// и признак того, что узел на этой стороне существует
+func sideChildValue(child *Node, old bool) (interface{}, bool) {
+	switch child.Type {
+	case NodeTypeUnchanged:
+		return child.Value, true
+	case NodeTypeNested:
+		return sideValue(child, old), true
+	case NodeTypeUpdated:
+		if old {
+			return child.OldValue, true
+		}
+		return child.NewValue, true
+	case NodeTypeRemoved:
+		return child.OldValue, old
+	case NodeTypeAdded:
+		return child.NewValue, !old
+	}
+	return nil, false
+}
+
+// patchValue восстанавливает значение вложенного узла для канонического текста. В отличие
+// от sideValue массивы, сопоставленные по ArrayKeyFields, остаются массивами, а не картами
+// по значению ключевого поля.
+func patchValue(node *Node, old bool) interface{} {
+	if node.keyField == "" && !isArrayNode(node) {
+		result := make(map[string]interface{})
+		for _, child := range node.Children {
+			if value, ok := patchChildValue(child, old); ok {
+				result[child.Key] = value
+			}
+		}
+		return result
+	}
+
+	items := []interface{}{}
+	for _, child := range node.Children {
+		if value, ok := patchChildValue(child, old); ok {
+			items = append(items, value)
+		}
+	}
+	return items
+}
+
+// patchChildValue возвращает значение дочернего узла для канонического текста
+func patchChildValue(child *Node, old bool) (interface{}, bool) {
+	if child.Type == NodeTypeNested {
+		return patchValue(child, old), true
+	}
+	return sideChildValue(child, old)
+}
+
+// canonicalText сериализует документ в каноническую текстовую форму
+func canonicalText(data map[string]interface{}) (string, error) {
+	content, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to serialize document: %w", err)
+	}
+	return string(content), nil
+}
+
+// diffLines строит сценарий правки двух последовательностей строк по наибольшей общей подпоследовательности
+func diffLines(before, after []string) []patchOp {
+	lengths := make([][]int, len(before)+1)
+	for i := range lengths {
+		lengths[i] = make([]int, len(after)+1)
+	}
+	for i := len(before) - 1; i >= 0; i-- {
+		for j := len(after) - 1; j >= 0; j-- {
+			if before[i] == after[j] {
+				lengths[i][j] = lengths[i+1][j+1] + 1
+			} else {
+				lengths[i][j] = max(lengths[i+1][j], lengths[i][j+1])
+			}
+		}
+	}
+
+	ops := make([]patchOp, 0, len(before)+len(after))
+	i, j := 0, 0
+	for i < len(before) || j < len(after) {
+		switch {
+		case i < len(before) && j < len(after) && before[i] == after[j]:
+			ops = append(ops, patchOp{kind: ' ', text: before[i]})
+			i++
+			j++
+		case i < len(before) && (j == len(after) || lengths[i+1][j] >= lengths[i][j+1]):
+			ops = append(ops, patchOp{kind: '-', text: before[i]})
+			i++
+		default:
+			ops = append(ops, patchOp{kind: '+', text: after[j]})
+			j++
+		}
+	}
+	return ops
+}
+
+// buildHunks группирует изменения во фрагменты с заголовками @@ и строками контекста.
+// Канонический текст не заканчивается переводом строки, поэтому за последней строкой
+// каждого текста, попавшей во фрагмент, следует noNewlineMarker.
+func buildHunks(ops []patchOp) []string {
+	var lines []string
+
+	// Номера строк в исходном и новом тексте перед каждой операцией
+	oldLine := make([]int, len(ops)+1)
+	newLine := make([]int, len(ops)+1)
+	for i, op := range ops {
+		oldLine[i+1] = oldLine[i]
+		newLine[i+1] = newLine[i]
+		if op.kind != '+' {
+			oldLine[i+1]++
+		}
+		if op.kind != '-' {
+			newLine[i+1]++
+		}
+	}
+
+	for i := 0; i < len(ops); {
+		if ops[i].kind == ' ' {
+			i++
+			continue
+		}
+
+		// Расширяем фрагмент, пока между изменениями не больше 2*patchContext общих строк
+		start := max(0, i-patchContext)
+		end := i
+		for end < len(ops) {
+			if ops[end].kind != ' ' {
+				end++
+				continue
+			}
+			next := end
+			for next < len(ops) && ops[next].kind == ' ' {
+				next++
+			}
+			if next == len(ops) || next-end > 2*patchContext {
+				break
+			}
+			end = next
+		}
+		end = min(len(ops), end+patchContext)
+
+		oldCount := oldLine[end] - oldLine[start]
+		newCount := newLine[end] - newLine[start]
+		lines = append(lines, fmt.Sprintf("@@ -%s +%s @@",
+			hunkRange(oldLine[start], oldCount), hunkRange(newLine[start], newCount)))
+		for k, op := range ops[start:end] {
+			lines = append(lines, string(op.kind)+op.text)
+			lastOld := op.kind != '+' && oldLine[start+k+1] == oldLine[len(ops)]
+			lastNew := op.kind != '-' && newLine[start+k+1] == newLine[len(ops)]
+			if lastOld || lastNew {
+				lines = append(lines, noNewlineMarker)
+			}
+		}
+
+		i = end
+	}
+
+	return lines
+}
+
+// hunkRange форматирует диапазон строк фрагмента; для пустого диапазона
+// указывается строка, после которой он расположен
+func hunkRange(before, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", before)
+	}
+	return fmt.Sprintf("%d,%d", before+1, count)
+}
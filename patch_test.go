@@ -0,0 +1,174 @@
+package code
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenDiff_Patch(t *testing.T) {
+	file1 := createTempFile(t, `{"host":"hexlet.io","timeout":50,"proxy":"123.234.53.22","follow":false,
+"a":1,"b1":1,"b2":2,"b3":3,"b4":4,"b5":5,"b6":6,"b7":7,"b8":8}`)
+	file2 := createTempFile(t, `{"timeout":20,"verbose":true,"host":"hexlet.io",
+"a":2,"b1":1,"b2":2,"b3":3,"b4":4,"b5":5,"b6":6,"b7":7,"b8":8}`)
+	defer func() {
+		if err := os.Remove(file1); err != nil {
+			t.Logf("failed to remove temp file %s: %v", file1, err)
+		}
+		if err := os.Remove(file2); err != nil {
+			t.Logf("failed to remove temp file %s: %v", file2, err)
+		}
+	}()
+
+	result, err := GenDiff(file1, file2, "patch")
+	require.NoError(t, err)
+
+	lines := strings.Split(result, "\n")
+	require.GreaterOrEqual(t, len(lines), 3)
+	assert.Equal(t, "--- a", lines[0])
+	assert.Equal(t, "+++ b", lines[1])
+
+	// Two separate hunks: one for the "a" key and one for the rest
+	hunks := validateHunks(t, lines[2:])
+	assert.Equal(t, 2, hunks)
+
+	// Applying the patch to the canonical first file yields the canonical second file
+	oldText, err := canonicalText(map[string]interface{}{
+		"host": "hexlet.io", "timeout": 50, "proxy": "123.234.53.22", "follow": false,
+		"a": 1, "b1": 1, "b2": 2, "b3": 3, "b4": 4, "b5": 5, "b6": 6, "b7": 7, "b8": 8,
+	})
+	require.NoError(t, err)
+	newText, err := canonicalText(map[string]interface{}{
+		"timeout": 20, "verbose": true, "host": "hexlet.io",
+		"a": 2, "b1": 1, "b2": 2, "b3": 3, "b4": 4, "b5": 5, "b6": 6, "b7": 7, "b8": 8,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, newText, applyHunks(t, oldText, lines[2:]))
+
+	// The closing brace has no trailing newline in either text
+	assert.Equal(t, []string{" }", noNewlineMarker}, lines[len(lines)-2:])
+}
+
+func TestGenDiff_PatchNoNewlineMarker(t *testing.T) {
+	// Both last lines differ, so each gets its own marker
+	lines := buildHunks(diffLines([]string{"a", "b"}, []string{"a", "c"}))
+	assert.Equal(t, []string{"@@ -1,2 +1,2 @@", " a", "-b", noNewlineMarker, "+c", noNewlineMarker}, lines)
+
+	// Changes far from the end leave the marker out
+	lines = buildHunks(diffLines(
+		[]string{"x", "1", "2", "3", "4", "5"},
+		[]string{"y", "1", "2", "3", "4", "5"},
+	))
+	assert.NotContains(t, lines, noNewlineMarker)
+}
+
+func TestGenDiff_PatchKeyedArrays(t *testing.T) {
+	dir := t.TempDir()
+	file1 := writeTestFile(t, dir, "a.json", `{"items": [{"id": 1, "v": "x"}, {"id": 2, "v": "y"}]}`)
+	file2 := writeTestFile(t, dir, "b.json", `{"items": [{"id": 2, "v": "y"}, {"id": 1, "v": "q"}]}`)
+
+	result, err := GenDiffWithOptions(file1, file2, "patch", Options{ArrayKeyFields: []string{"id"}})
+	require.NoError(t, err)
+
+	lines := strings.Split(result, "\n")
+	validateHunks(t, lines[2:])
+
+	// Keyed arrays stay arrays in the canonical text, ordered by the key field
+	oldText, err := canonicalText(map[string]interface{}{"items": []interface{}{
+		map[string]interface{}{"id": 1, "v": "x"},
+		map[string]interface{}{"id": 2, "v": "y"},
+	}})
+	require.NoError(t, err)
+	newText, err := canonicalText(map[string]interface{}{"items": []interface{}{
+		map[string]interface{}{"id": 1, "v": "q"},
+		map[string]interface{}{"id": 2, "v": "y"},
+	}})
+	require.NoError(t, err)
+	assert.Equal(t, newText, applyHunks(t, oldText, lines[2:]))
+	assert.Contains(t, result, ` "items": [`)
+}
+
+func TestGenDiff_PatchIdentical(t *testing.T) {
+	file1 := createTempFile(t, `{"host":"hexlet.io"}`)
+	defer func() {
+		if err := os.Remove(file1); err != nil {
+			t.Logf("failed to remove temp file %s: %v", file1, err)
+		}
+	}()
+
+	result, err := GenDiff(file1, file1, "patch")
+	require.NoError(t, err)
+	assert.Empty(t, result)
+}
+
+// validateHunks checks that every hunk header matches the number of lines in its body
+func validateHunks(t *testing.T, lines []string) int {
+	hunks := 0
+	for i := 0; i < len(lines); {
+		var oldStart, oldCount, newStart, newCount int
+		_, err := fmt.Sscanf(lines[i], "@@ -%d,%d +%d,%d @@", &oldStart, &oldCount, &newStart, &newCount)
+		require.NoError(t, err, "invalid hunk header %q", lines[i])
+		hunks++
+		i++
+
+		oldSeen, newSeen := 0, 0
+		for i < len(lines) && !strings.HasPrefix(lines[i], "@@") {
+			switch lines[i][0] {
+			case ' ':
+				oldSeen++
+				newSeen++
+			case '-':
+				oldSeen++
+			case '+':
+				newSeen++
+			case '\\':
+				assert.Equal(t, noNewlineMarker, lines[i])
+			default:
+				t.Fatalf("unexpected hunk line %q", lines[i])
+			}
+			i++
+		}
+		assert.Equal(t, oldCount, oldSeen)
+		assert.Equal(t, newCount, newSeen)
+	}
+	return hunks
+}
+
+// applyHunks applies unified diff hunks to text the way patch(1) would
+func applyHunks(t *testing.T, text string, lines []string) string {
+	source := strings.Split(text, "\n")
+	var result []string
+	pos := 0
+	for _, line := range lines {
+		if strings.HasPrefix(line, "@@") {
+			var oldStart, oldCount int
+			_, err := fmt.Sscanf(line, "@@ -%d,%d", &oldStart, &oldCount)
+			require.NoError(t, err)
+			if oldCount == 0 {
+				oldStart++
+			}
+			result = append(result, source[pos:oldStart-1]...)
+			pos = oldStart - 1
+			continue
+		}
+		if line == noNewlineMarker {
+			continue
+		}
+		switch line[0] {
+		case ' ', '-':
+			require.Equal(t, source[pos], line[1:])
+			if line[0] == ' ' {
+				result = append(result, line[1:])
+			}
+			pos++
+		case '+':
+			result = append(result, line[1:])
+		}
+	}
+	result = append(result, source[pos:]...)
+	return strings.Join(result, "\n")
+}
@@ -0,0 +1,72 @@
+package code
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Имена встроенных профилей
+const (
+	// ProfileK8s подходит для манифестов Kubernetes: контейнеры и другие списки
+	// сопоставляются по name, служебные поля metadata и status игнорируются
+	ProfileK8s = "k8s"
+	// ProfileStrict сравнивает значения как есть, без нормализации, и показывает каждое
+	// расхождение: массивы сравниваются поэлементно, а значения неподдерживаемых типов
+	// дают ошибку вместо вывода через %v
+	ProfileStrict = "strict"
+	// ProfileLenient прощает шум в пробелах, пустых строках, кодировании булевых значений
+	// и типах (число 8080 и строка "8080" равны)
+	ProfileLenient = "lenient"
+)
+
+// profiles содержит параметры встроенных профилей
+var profiles = map[string]func() Options{
+	ProfileK8s: func() Options {
+		return Options{
+			ArrayKeyFields: []string{"name"},
+			IgnorePaths: []string{
+				"metadata.creationTimestamp",
+				"metadata.generation",
+				"metadata.managedFields",
+				"metadata.resourceVersion",
+				"metadata.uid",
+				"status",
+			},
+		}
+	},
+	ProfileStrict: func() Options {
+		return Options{
+			IndexArrays: true,
+			StrictTypes: true,
+		}
+	},
+	ProfileLenient: func() Options {
+		return Options{
+			TrimStrings:               true,
+			NormalizeMultilineStrings: true,
+			BoolAsInt:                 true,
+			TreatNumericKeysAsNumbers: true,
+			LooseTypes:                true,
+		}
+	},
+}
+
+// Profile возвращает параметры сравнения для профиля с указанным именем.
+// Отдельные поля результата можно переопределить перед вызовом GenDiffWithOptions.
+func Profile(name string) (Options, error) {
+	profile, ok := profiles[name]
+	if !ok {
+		return Options{}, fmt.Errorf("unknown profile: %s (available: %v)", name, ProfileNames())
+	}
+	return profile(), nil
+}
+
+// ProfileNames возвращает отсортированные имена встроенных профилей
+func ProfileNames() []string {
+	names := make([]string, 0, len(profiles))
+	for name := range profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
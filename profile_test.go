@@ -0,0 +1,83 @@
+package code
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProfile_K8s(t *testing.T) {
+	opts, err := Profile(ProfileK8s)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"name"}, opts.ArrayKeyFields)
+	assert.Contains(t, opts.IgnorePaths, "metadata.creationTimestamp")
+
+	manifest1 := `
+metadata:
+  name: web
+  creationTimestamp: "2024-01-01T00:00:00Z"
+spec:
+  containers:
+    - name: app
+      image: app:1
+    - name: sidecar
+      image: proxy:1
+`
+	manifest2 := `
+metadata:
+  name: web
+  creationTimestamp: "2024-02-01T00:00:00Z"
+spec:
+  containers:
+    - name: sidecar
+      image: proxy:1
+    - name: app
+      image: app:2
+`
+	result, err := GenDiffString(manifest1, manifest2, "yaml", "plain", opts)
+	require.NoError(t, err)
+	// Reordered containers are matched by name and the timestamp is ignored
+	assert.Equal(t, "Property 'spec.containers.app.image' was updated. From 'app:1' to 'app:2'", result)
+
+	// Without the profile the same manifests differ in several places
+	result, err = GenDiffString(manifest1, manifest2, "yaml", "plain", Options{})
+	require.NoError(t, err)
+	assert.Contains(t, result, "Property 'metadata.creationTimestamp' was updated")
+	assert.Contains(t, result, "Property 'spec.containers' was updated")
+}
+
+func TestProfile_Unknown(t *testing.T) {
+	_, err := Profile("missing")
+	assert.EqualError(t, err, "unknown profile: missing (available: [k8s lenient strict])")
+}
+
+func TestProfile_Lenient(t *testing.T) {
+	opts, err := Profile(ProfileLenient)
+	require.NoError(t, err)
+
+	result, err := GenDiffString(`{"enabled": 1, "name": " app ", "port": 8080}`, `{"enabled": true, "name": "app", "port": "8080"}`, "json", "plain", opts)
+	require.NoError(t, err)
+	assert.Equal(t, "", result)
+}
+
+func TestProfile_Strict(t *testing.T) {
+	opts, err := Profile(ProfileStrict)
+	require.NoError(t, err)
+	assert.NotEqual(t, Options{}, opts)
+
+	// Arrays are compared element by element and values keep their types
+	result, err := GenDiffString(`{"ports": [80, 443], "replicas": 1, "name": "app"}`,
+		`{"ports": [80, 8443], "replicas": "1", "name": "app "}`, "json", "plain", opts)
+	require.NoError(t, err)
+	assert.Equal(t, "Property 'name' was updated. From 'app' to 'app '\n"+
+		"Property 'ports.1' was updated. From 443 to 8443\n"+
+		"Property 'replicas' was updated. From 1 to '1'", result)
+
+	// Values of unsupported types are an error instead of being printed with %v
+	_, err = GenDiffMaps(map[string]interface{}{"at": time.Unix(0, 0)}, map[string]interface{}{}, "plain", opts)
+	require.Error(t, err)
+	_, err = GenDiffMaps(map[string]interface{}{"at": time.Unix(0, 0)}, map[string]interface{}{}, "plain", Options{})
+	require.NoError(t, err)
+}
@@ -0,0 +1,159 @@
+package code
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// parseProperties парсит содержимое Java .properties файла в плоскую карту строк.
+// Поддерживаются разделители "=", ":" и пробел, комментарии "#" и "!", продолжение
+// строки обратной косой чертой в конце и escape-последовательности вида \\u00e9.
+func parseProperties(content []byte) (map[string]interface{}, []string, error) {
+	result := make(map[string]interface{})
+	var warnings []string
+
+	lines := strings.Split(strings.ReplaceAll(string(content), "\r\n", "\n"), "\n")
+	for i := 0; i < len(lines); i++ {
+		lineNum := i + 1
+		line := strings.TrimLeft(lines[i], " \t\f")
+		if line == "" || line[0] == '#' || line[0] == '!' {
+			continue
+		}
+
+		// Склеиваем логическую строку из строк с продолжением
+		for continuesLine(line) && i+1 < len(lines) {
+			i++
+			line = line[:len(line)-1] + strings.TrimLeft(lines[i], " \t\f")
+		}
+		if continuesLine(line) {
+			line = line[:len(line)-1]
+		}
+
+		rawKey, rawValue := splitProperty(line)
+		key, err := unescapeProperty(rawKey)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to parse properties: line %d: %w", lineNum, err)
+		}
+		value, err := unescapeProperty(rawValue)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to parse properties: line %d: %w", lineNum, err)
+		}
+
+		if _, exists := result[key]; exists {
+			warnings = append(warnings, fmt.Sprintf("duplicate key '%s' at line %d", key, lineNum))
+		}
+		result[key] = value
+	}
+	return result, warnings, nil
+}
+
+// continuesLine проверяет, заканчивается ли строка неэкранированной обратной косой чертой
+func continuesLine(line string) bool {
+	slashes := len(line) - len(strings.TrimRight(line, `\`))
+	return slashes%2 == 1
+}
+
+// splitProperty делит логическую строку на ключ и значение по первому
+// неэкранированному разделителю
+func splitProperty(line string) (string, string) {
+	end := len(line)
+	for i := 0; i < len(line); i++ {
+		if line[i] == '\\' {
+			i++
+			continue
+		}
+		if strings.IndexByte("=: \t\f", line[i]) >= 0 {
+			end = i
+			break
+		}
+	}
+
+	key, rest := line[:end], strings.TrimLeft(line[end:], " \t\f")
+	if rest != "" && (rest[0] == '=' || rest[0] == ':') {
+		rest = strings.TrimLeft(rest[1:], " \t\f")
+	}
+	return key, rest
+}
+
+// unescapeProperty раскрывает escape-последовательности .properties
+func unescapeProperty(s string) (string, error) {
+	if !strings.Contains(s, `\`) {
+		return s, nil
+	}
+
+	var result strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 == len(s) {
+			result.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch s[i] {
+		case 't':
+			result.WriteByte('\t')
+		case 'n':
+			result.WriteByte('\n')
+		case 'r':
+			result.WriteByte('\r')
+		case 'f':
+			result.WriteByte('\f')
+		case 'u':
+			if i+5 > len(s) {
+				return "", fmt.Errorf("malformed \\u escape in %q", s)
+			}
+			code, err := strconv.ParseUint(s[i+1:i+5], 16, 16)
+			if err != nil {
+				return "", fmt.Errorf("malformed \\u escape in %q", s)
+			}
+			result.WriteRune(rune(code))
+			i += 4
+		default:
+			// Прочие символы после обратной косой черты означают сами себя
+			r, size := utf8.DecodeRuneInString(s[i:])
+			result.WriteRune(r)
+			i += size - 1
+		}
+	}
+	return result.String(), nil
+}
+
+// nestProperties при включённом opts.NestPropertiesKeys раскрывает ключи через точку
+// в данных .properties файла; данные других форматов возвращаются без изменений
+func nestProperties(format string, data map[string]interface{}, opts Options) (map[string]interface{}, error) {
+	if !opts.NestPropertiesKeys || strings.TrimPrefix(strings.ToLower(format), ".") != "properties" {
+		return data, nil
+	}
+	return expandDottedKeys(data)
+}
+
+// expandDottedKeys превращает плоские ключи через точку (server.port) во вложенные
+// карты. Ключ, который одновременно является значением и префиксом другого ключа,
+// считается ошибкой.
+func expandDottedKeys(data map[string]interface{}) (map[string]interface{}, error) {
+	result := make(map[string]interface{})
+	for _, key := range getSortedKeys(data) {
+		segments := strings.Split(key, ".")
+		current := result
+		for i, segment := range segments[:len(segments)-1] {
+			next, exists := current[segment]
+			if !exists {
+				next = make(map[string]interface{})
+				current[segment] = next
+			}
+			nested, ok := next.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("key '%s' is both a value and a prefix of '%s'", strings.Join(segments[:i+1], "."), key)
+			}
+			current = nested
+		}
+
+		last := segments[len(segments)-1]
+		if _, exists := current[last]; exists {
+			return nil, fmt.Errorf("key '%s' is both a value and a prefix of another key", key)
+		}
+		current[last] = data[key]
+	}
+	return result, nil
+}
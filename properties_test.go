@@ -0,0 +1,91 @@
+package code
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenDiff_Properties(t *testing.T) {
+	dir := t.TempDir()
+	file1 := writeTestFile(t, dir, "release1.properties", `# Release 1
+server.port=8080
+server.address: 127.0.0.1
+app.description=Billing \
+    service
+spring.datasource.url=jdbc:postgresql://db/app
+`)
+	file2 := writeTestFile(t, dir, "release2.properties", `# Release 2
+server.port = 9090
+server.address: 127.0.0.1
+app.description=Billing \
+    service \
+    for caf\u00e9s
+`)
+
+	t.Run("flat keys", func(t *testing.T) {
+		result, err := GenDiff(file1, file2, "plain")
+		require.NoError(t, err)
+		// Flat keys contain the path separator, so plain shows them in brackets
+		assert.Equal(t, `Property '[app.description]' was updated. From 'Billing service' to 'Billing service for cafés'
+Property '[server.port]' was updated. From '8080' to '9090'
+Property '[spring.datasource.url]' was removed`, result)
+	})
+
+	t.Run("nested keys", func(t *testing.T) {
+		result, err := GenDiffWithOptions(file1, file2, "stylish", Options{NestPropertiesKeys: true})
+		require.NoError(t, err)
+		assert.Contains(t, result, `    server: {
+        address: 127.0.0.1
+      - port: 8080
+      + port: 9090
+    }`)
+		assert.Contains(t, result, `  - spring: {
+        datasource: {
+            url: jdbc:postgresql://db/app
+        }
+    }`)
+	})
+}
+
+func TestParseProperties(t *testing.T) {
+	data, warnings, err := parseProperties([]byte(`! comment
+key1=value1
+key2 : value2
+key3 value3
+escaped\=key = a\tb
+path=C:\\dir
+empty=
+continued = one, \
+            two, \
+            three
+key1=again
+`))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"key1":        "again",
+		"key2":        "value2",
+		"key3":        "value3",
+		"escaped=key": "a\tb",
+		"path":        `C:\dir`,
+		"empty":       "",
+		"continued":   "one, two, three",
+	}, data)
+	assert.Equal(t, []string{"duplicate key 'key1' at line 11"}, warnings)
+
+	_, _, err = parseProperties([]byte(`bad=\u12`))
+	assert.EqualError(t, err, `failed to parse properties: line 1: malformed \u escape in "\\u12"`)
+}
+
+func TestExpandDottedKeys(t *testing.T) {
+	data, err := expandDottedKeys(map[string]interface{}{"a.b": "1", "a.c": "2", "d": "3"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"a": map[string]interface{}{"b": "1", "c": "2"},
+		"d": "3",
+	}, data)
+
+	_, err = expandDottedKeys(map[string]interface{}{"logging": "on", "logging.level": "debug"})
+	assert.EqualError(t, err, "key 'logging' is both a value and a prefix of 'logging.level'")
+}
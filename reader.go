@@ -0,0 +1,58 @@
+package code
+
+import (
+	"fmt"
+	"io"
+)
+
+// GenDiffBytes сравнивает две конфигурации, уже прочитанные в память, без обращения
+// к файловой системе. Формат входных данных (json, yaml, ...) общий для обоих входов.
+func GenDiffBytes(content1, content2 []byte, inFormat, outFormat string) (string, error) {
+	return GenDiffBytesWithOptions(content1, content2, inFormat, outFormat, Options{})
+}
+
+// GenDiffBytesWithOptions работает как GenDiffBytes с учётом переданных параметров.
+// Предупреждения пишутся в opts.Logger, а если он не задан — в stderr.
+func GenDiffBytesWithOptions(content1, content2 []byte, inFormat, outFormat string, opts Options) (string, error) {
+	if inFormat == "" {
+		return "", fmt.Errorf("input format is required")
+	}
+	return genDiffInputs(
+		configInput{content: content1, format: inFormat},
+		configInput{content: content2, format: inFormat},
+		outFormat, opts)
+}
+
+// GenDiffReader сравнивает две конфигурации, читаемые из r1 и r2 (например, из stdin).
+// Формат каждого входа (json, yaml, ...) задаётся явно, поскольку расширения нет;
+// пустой формат определяется по комментарию в первой строке или по содержимому,
+// как для файлов без расширения.
+func GenDiffReader(r1, r2 io.Reader, format1, format2, outFormat string, opts Options) (string, error) {
+	content1, err := io.ReadAll(r1)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse first input: failed to read input: %w", err)
+	}
+	content2, err := io.ReadAll(r2)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse second input: failed to read input: %w", err)
+	}
+	return genDiffInputs(
+		configInput{content: content1, format: format1},
+		configInput{content: content2, format: format2},
+		outFormat, opts)
+}
+
+// genDiffInputs сравнивает две конфигурации в памяти и форматирует результат
+func genDiffInputs(in1, in2 configInput, format string, opts Options) (string, error) {
+	diffTree, warnings, err := buildTreeFromInputs(in1, in2, opts, nil)
+	if err != nil {
+		return "", err
+	}
+	result, err := formatDiff(diffTree, format, opts)
+	if err != nil {
+		return "", fmt.Errorf("failed to format diff: %w", err)
+	}
+
+	logWarnings(opts, warnings)
+	return result, nil
+}
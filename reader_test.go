@@ -0,0 +1,91 @@
+package code
+
+import (
+	"encoding/binary"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenDiffReader(t *testing.T) {
+	json1 := strings.NewReader(`{"host": "hexlet.io", "timeout": 50, "proxy": "123.234.53.22"}`)
+	yaml2 := strings.NewReader("host: hexlet.io\ntimeout: 20\n")
+
+	result, err := GenDiffReader(json1, yaml2, "json", "yaml", "plain", Options{})
+	require.NoError(t, err)
+	assert.Equal(t, "Property 'proxy' was removed\nProperty 'timeout' was updated. From 50 to 20", result)
+}
+
+func TestGenDiffReader_DetectsFormat(t *testing.T) {
+	r1 := strings.NewReader("# format: toml\nname = \"a\"\n")
+	r2 := strings.NewReader(`{"name": "b"}`)
+
+	result, err := GenDiffReader(r1, r2, "", "", "plain", Options{})
+	require.NoError(t, err)
+	assert.Equal(t, "Property 'name' was updated. From 'a' to 'b'", result)
+}
+
+// failingReader always fails to read
+type failingReader struct{}
+
+func (failingReader) Read([]byte) (int, error) {
+	return 0, errors.New("broken pipe")
+}
+
+func TestGenDiffReader_Errors(t *testing.T) {
+	_, err := GenDiffReader(strings.NewReader("{}"), strings.NewReader("just text"), "json", "", "plain", Options{})
+	assert.EqualError(t, err, "failed to parse second input: cannot determine input format")
+
+	_, err = GenDiffReader(failingReader{}, strings.NewReader("{}"), "json", "json", "plain", Options{})
+	assert.EqualError(t, err, "failed to parse first input: failed to read input: broken pipe")
+
+	_, err = GenDiffReader(strings.NewReader("{"), strings.NewReader("{}"), "json", "json", "plain", Options{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to parse first input: failed to parse JSON")
+}
+
+func TestGenDiffBytes(t *testing.T) {
+	tests := []struct {
+		name     string
+		content1 string
+		content2 string
+		format   string
+	}{
+		{"json", `{"host": "hexlet.io", "timeout": 50, "proxy": "123.234.53.22"}`, `{"host": "hexlet.io", "timeout": 20}`, "json"},
+		{"yaml", "host: hexlet.io\ntimeout: 50\nproxy: 123.234.53.22\n", "host: hexlet.io\ntimeout: 20\n", "yaml"},
+		{"yml with dot", "host: hexlet.io\ntimeout: 50\nproxy: 123.234.53.22\n", "host: hexlet.io\ntimeout: 20\n", ".yml"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := GenDiffBytes([]byte(tt.content1), []byte(tt.content2), tt.format, "plain")
+			require.NoError(t, err)
+			assert.Equal(t, "Property 'proxy' was removed\nProperty 'timeout' was updated. From 50 to 20", result)
+		})
+	}
+
+	_, err := GenDiffBytes([]byte(`{}`), []byte(`{}`), "", "plain")
+	assert.EqualError(t, err, "input format is required")
+
+	_, err = GenDiffBytes([]byte(`{}`), []byte(`{}`), "json", "unknown")
+	assert.EqualError(t, err, "failed to format diff: unsupported format: unknown")
+}
+
+func TestGenDiffBytesWithOptions(t *testing.T) {
+	result, err := GenDiffBytesWithOptions([]byte(`{"a": 1.4, "b": "x"}`), []byte(`{"a": 1.6, "b": "y"}`),
+		"json", "plain", Options{IgnoreKeys: []string{"b"}})
+	require.NoError(t, err)
+	assert.Equal(t, "Property 'a' was updated. From 1.4 to 1.6", result)
+}
+
+func TestGenDiffString_DecodesUTF16(t *testing.T) {
+	// Strings share the file pipeline, so UTF-16 content is decoded as for files
+	utf16 := string(encodeUTF16(`{"host": "a"}`, binary.LittleEndian))
+
+	result, err := GenDiffString(utf16, `{"host": "b"}`, "json", "plain", Options{})
+	require.NoError(t, err)
+	assert.Equal(t, "Property 'host' was updated. From 'a' to 'b'", result)
+}
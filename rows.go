@@ -0,0 +1,63 @@
+package code
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strings"
+)
+
+// ChangeRow — плоское представление одного изменения для загрузки в базу данных.
+// Отсутствующее значение (например, OldValue у добавленного ключа) — пустая строка.
+type ChangeRow struct {
+	Path     string
+	Type     string
+	OldValue string
+	NewValue string
+}
+
+// ToRows возвращает по строке на каждое изменение в порядке обхода дерева
+func (n *Node) ToRows() []ChangeRow {
+	var rows []ChangeRow
+	walkChanges(n, nil, func(nodePath []string, child *Node) {
+		row := ChangeRow{Path: strings.Join(nodePath, "."), Type: child.Type}
+		if child.Type != NodeTypeAdded {
+			row.OldValue = rowValue(child.OldValue)
+		}
+		if child.Type != NodeTypeRemoved {
+			row.NewValue = rowValue(child.NewValue)
+		}
+		rows = append(rows, row)
+	})
+	return rows
+}
+
+// rowValue превращает значение в строку: скаляры как в stylish формате,
+// карты и массивы — компактным JSON
+func rowValue(v interface{}) string {
+	switch v.(type) {
+	case map[string]interface{}, []interface{}:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return renderScalar(v, scalarStyleStylish)
+		}
+		return string(data)
+	default:
+		return renderScalar(v, scalarStyleStylish)
+	}
+}
+
+// writeCSV пишет изменения в CSV с заголовком path,type,old_value,new_value
+func writeCSV(w io.Writer, node *Node) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write([]string{"path", "type", "old_value", "new_value"}); err != nil {
+		return err
+	}
+	for _, row := range node.ToRows() {
+		if err := writer.Write([]string{row.Path, row.Type, row.OldValue, row.NewValue}); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,48 @@
+package code
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNode_ToRows(t *testing.T) {
+	content1 := `{
+  "name": "app",
+  "db": {"host": "localhost", "port": 5432, "legacy": true},
+  "features": {"beta": false}
+}`
+	content2 := `{
+  "name": "app",
+  "db": {"host": "db.internal", "port": 5432, "pool": {"size": 10}},
+  "features": "all",
+  "tags": null
+}`
+
+	data1, err := parseJSON([]byte(content1))
+	require.NoError(t, err)
+	data2, err := parseJSON([]byte(content2))
+	require.NoError(t, err)
+	tree, _ := buildTree(data1, data2, Options{})
+
+	expected := []ChangeRow{
+		{Path: "db.host", Type: NodeTypeUpdated, OldValue: "localhost", NewValue: "db.internal"},
+		{Path: "db.legacy", Type: NodeTypeRemoved, OldValue: "true"},
+		{Path: "db.pool", Type: NodeTypeAdded, NewValue: `{"size":10}`},
+		{Path: "features", Type: NodeTypeUpdated, OldValue: `{"beta":false}`, NewValue: "all"},
+		{Path: "tags", Type: NodeTypeAdded, NewValue: "null"},
+	}
+	assert.Equal(t, expected, tree.ToRows())
+}
+
+func TestGenDiff_CSVFormat(t *testing.T) {
+	result, err := GenDiffString(`{"a": "x, y", "b": 1}`, `{"a": "z", "c": [1, 2]}`, "json", "csv", Options{})
+	require.NoError(t, err)
+
+	expected := `path,type,old_value,new_value
+a,updated,"x, y",z
+b,removed,1,
+c,added,,"[1,2]"`
+	assert.Equal(t, expected, result)
+}
@@ -0,0 +1,26 @@
+package code
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// formatSectionStats выводит JSON-объект, сопоставляющий каждому ключу верхнего уровня
+// число изменений в его поддереве по типам: {"database": {"updated": 2}}. Нулевые
+// счётчики и разделы без изменений не выводятся.
+func formatSectionStats(node *Node) (string, error) {
+	sections := make(map[string]map[string]int)
+	walkChanges(node, nil, func(nodePath []string, child *Node) {
+		section := nodePath[0]
+		if sections[section] == nil {
+			sections[section] = make(map[string]int)
+		}
+		sections[section][child.Type]++
+	})
+
+	data, err := json.MarshalIndent(sections, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal section stats: %w", err)
+	}
+	return string(data), nil
+}
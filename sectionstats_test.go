@@ -0,0 +1,33 @@
+package code
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenDiff_SectionStats(t *testing.T) {
+	content1 := `{
+		"database": {"host": "db1", "pool": {"min": 1, "max": 10}, "user": "app"},
+		"server": {"port": 80},
+		"cache": {"ttl": 60},
+		"legacy": true
+	}`
+	content2 := `{
+		"database": {"host": "db2", "pool": {"min": 1, "max": 20}, "user": "app"},
+		"server": {"port": 80, "tls": true},
+		"cache": {"ttl": 60},
+		"debug": false
+	}`
+
+	result, err := GenDiffString(content1, content2, "json", "section-stats", Options{})
+	require.NoError(t, err)
+	// Unchanged sections and zero counters are omitted; top-level leaves count as their own section
+	assert.JSONEq(t, `{
+		"database": {"updated": 2},
+		"debug": {"added": 1},
+		"legacy": {"removed": 1},
+		"server": {"added": 1}
+	}`, result)
+}
@@ -0,0 +1,35 @@
+package code
+
+// stringSimilarity возвращает долю совпадения двух строк от 0 до 1 на основе
+// расстояния Левенштейна по символам
+func stringSimilarity(a, b string) float64 {
+	runesA, runesB := []rune(a), []rune(b)
+	longest := max(len(runesA), len(runesB))
+	if longest == 0 {
+		return 1
+	}
+	return 1 - float64(levenshtein(runesA, runesB))/float64(longest)
+}
+
+// levenshtein вычисляет минимальное число вставок, удалений и замен символов,
+// превращающих a в b
+func levenshtein(a, b []rune) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
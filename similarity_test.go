@@ -0,0 +1,34 @@
+package code
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenDiff_StringSimilarityThreshold(t *testing.T) {
+	content1 := `{"description": "The quick brown fox jumps over the lazy dog", "name": "api"}`
+	content2 := `{"description": "The quick brown fox jumps over the lazy cat", "name": "web"}`
+
+	t.Run("threshold 0.9", func(t *testing.T) {
+		result, err := GenDiffString(content1, content2, "json", "plain", Options{StringSimilarityThreshold: 0.9})
+		require.NoError(t, err)
+		// Short strings still differ a lot relative to their length
+		assert.Equal(t, "Property 'name' was updated. From 'api' to 'web'", result)
+	})
+
+	t.Run("threshold 1.0", func(t *testing.T) {
+		result, err := GenDiffString(content1, content2, "json", "plain", Options{StringSimilarityThreshold: 1.0})
+		require.NoError(t, err)
+		assert.Contains(t, result, "Property 'description' was updated")
+	})
+}
+
+func TestStringSimilarity(t *testing.T) {
+	assert.Equal(t, 1.0, stringSimilarity("", ""))
+	assert.Equal(t, 1.0, stringSimilarity("same", "same"))
+	assert.Equal(t, 0.0, stringSimilarity("abc", "xyz"))
+	assert.InDelta(t, 0.75, stringSimilarity("café", "cafe"), 1e-9)
+	assert.Equal(t, 3, levenshtein([]rune("kitten"), []rune("sitting")))
+}
@@ -0,0 +1,59 @@
+package code
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// SnapshotDateLayout — формат имён каталогов со снимками конфигурации (YYYY-MM-DD)
+const SnapshotDateLayout = "2006-01-02"
+
+// FindSnapshot находит в каталоге snapshotsDir снимок за дату since и возвращает путь
+// к копии файла filePath в нём. Если снимка ровно за эту дату нет, выбирается самый
+// поздний снимок до неё. Копия ищется по относительному пути файла, а затем по его имени.
+func FindSnapshot(snapshotsDir, since, filePath string) (string, error) {
+	sinceDate, err := time.Parse(SnapshotDateLayout, since)
+	if err != nil {
+		return "", fmt.Errorf("invalid snapshot date %q, expected YYYY-MM-DD: %w", since, err)
+	}
+
+	entries, err := os.ReadDir(snapshotsDir)
+	if err != nil {
+		return "", fmt.Errorf("failed to read snapshots directory %s: %w", snapshotsDir, err)
+	}
+
+	// Ищем самый поздний снимок, не превышающий указанную дату
+	var chosen string
+	var chosenDate time.Time
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		date, err := time.Parse(SnapshotDateLayout, entry.Name())
+		if err != nil || date.After(sinceDate) {
+			continue
+		}
+		if chosen == "" || date.After(chosenDate) {
+			chosen, chosenDate = entry.Name(), date
+		}
+	}
+	if chosen == "" {
+		return "", fmt.Errorf("no snapshot found on or before %s in %s", since, snapshotsDir)
+	}
+
+	snapshotDir := filepath.Join(snapshotsDir, chosen)
+	candidates := []string{filepath.Base(filePath)}
+	if !filepath.IsAbs(filePath) {
+		candidates = append([]string{filePath}, candidates...)
+	}
+	for _, candidate := range candidates {
+		snapshotPath := filepath.Join(snapshotDir, candidate)
+		if _, err := os.Stat(snapshotPath); err == nil {
+			return snapshotPath, nil
+		}
+	}
+
+	return "", fmt.Errorf("file %s not found in snapshot %s", filePath, snapshotDir)
+}
@@ -0,0 +1,46 @@
+package code
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindSnapshot(t *testing.T) {
+	root := t.TempDir()
+	for _, date := range []string{"2026-01-01", "2026-02-01"} {
+		require.NoError(t, os.MkdirAll(filepath.Join(root, date), 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(root, date, "app.json"), []byte(`{"date":"`+date+`"}`), 0o600))
+	}
+	// A directory that is not a snapshot is ignored
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "latest"), 0o755))
+
+	path, err := FindSnapshot(root, "2026-02-01", "app.json")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(root, "2026-02-01", "app.json"), path)
+
+	// Without an exact match the latest earlier snapshot is chosen
+	path, err = FindSnapshot(root, "2026-01-20", "config/app.json")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(root, "2026-01-01", "app.json"), path)
+
+	_, err = FindSnapshot(root, "2025-12-31", "app.json")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "no snapshot found")
+
+	_, err = FindSnapshot(root, "01.02.2026", "app.json")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid snapshot date")
+
+	current := filepath.Join(t.TempDir(), "app.json")
+	require.NoError(t, os.WriteFile(current, []byte(`{"date":"2026-03-01"}`), 0o600))
+	path, err = FindSnapshot(root, "2026-01-20", current)
+	require.NoError(t, err)
+
+	result, err := GenDiff(path, current, "plain")
+	require.NoError(t, err)
+	assert.Equal(t, "Property 'date' was updated. From '2026-01-01' to '2026-03-01'", result)
+}
@@ -0,0 +1,72 @@
+package code
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sync"
+)
+
+// ConfigSource загружает конфигурацию из внешнего хранилища (например, Consul KV
+// или Spring Cloud Config). Конкретные реализации регистрируются через RegisterSource,
+// поэтому ядро библиотеки не зависит от их клиентов.
+type ConfigSource interface {
+	// Fetch возвращает содержимое конфигурации и её формат ("json", "yaml")
+	Fetch(ctx context.Context, location *url.URL) (content []byte, format string, err error)
+}
+
+var (
+	sourcesMu sync.RWMutex
+	sources   = make(map[string]ConfigSource)
+)
+
+// RegisterSource регистрирует источник конфигурации для схемы URL; повторная
+// регистрация схемы заменяет прежний источник
+func RegisterSource(scheme string, source ConfigSource) {
+	sourcesMu.Lock()
+	defer sourcesMu.Unlock()
+	sources[scheme] = source
+}
+
+// lookupSource возвращает зарегистрированный источник для схемы URL
+func lookupSource(scheme string) (ConfigSource, bool) {
+	sourcesMu.RLock()
+	defer sourcesMu.RUnlock()
+	source, ok := sources[scheme]
+	return source, ok
+}
+
+// GenDiffSource загружает текущую конфигурацию из источника по URL и сравнивает её
+// с локальным файлом: источник выступает первым файлом, локальный файл — вторым
+func GenDiffSource(ctx context.Context, sourceURL, filePath, format string, opts Options) (string, error) {
+	opts.files = [2]string{sourceURL, filePath}
+	source, err := fetchSource(ctx, sourceURL)
+	if err != nil {
+		return "", err
+	}
+
+	local, err := readInput(filePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse %s: %w", filePath, err)
+	}
+	return genDiffInputs(source, local, format, opts)
+}
+
+// fetchSource находит источник по схеме URL и загружает конфигурацию
+func fetchSource(ctx context.Context, sourceURL string) (configInput, error) {
+	location, err := url.Parse(sourceURL)
+	if err != nil {
+		return configInput{}, fmt.Errorf("invalid source URL %s: %w", sourceURL, err)
+	}
+
+	source, ok := lookupSource(location.Scheme)
+	if !ok {
+		return configInput{}, fmt.Errorf("no config source registered for scheme %q", location.Scheme)
+	}
+
+	content, format, err := source.Fetch(ctx, location)
+	if err != nil {
+		return configInput{}, fmt.Errorf("failed to fetch %s: %w", sourceURL, err)
+	}
+	return configInput{content: content, format: format, name: sourceURL}, nil
+}
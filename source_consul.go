@@ -0,0 +1,60 @@
+package code
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// ConsulSource загружает значение ключа из Consul KV через HTTP API.
+// URL имеет вид consul://host:port/path/to/key; формат определяется по расширению
+// ключа, а при его отсутствии считается JSON. Источник не регистрируется
+// автоматически: вызовите RegisterSource("consul", ConsulSource{}).
+type ConsulSource struct {
+	// Client выполняет запросы; по умолчанию используется общий клиент с таймаутом,
+	// как и для загрузки по HTTP
+	Client *http.Client
+}
+
+// Fetch запрашивает сырое значение ключа из Consul KV
+func (s ConsulSource) Fetch(ctx context.Context, location *url.URL) ([]byte, string, error) {
+	client := s.Client
+	if client == nil {
+		client = httpClient
+	}
+
+	key := strings.TrimPrefix(location.Path, "/")
+	endpoint := fmt.Sprintf("http://%s/v1/kv/%s?raw", location.Host, key)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, "", err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	content, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", err
+	}
+
+	format := strings.TrimPrefix(path.Ext(key), ".")
+	if format == "" {
+		format = "json"
+	}
+	return content, format, nil
+}
@@ -0,0 +1,69 @@
+package code
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockSource serves configs from memory keyed by URL path
+type mockSource map[string]string
+
+func (m mockSource) Fetch(_ context.Context, location *url.URL) ([]byte, string, error) {
+	content, ok := m[location.Path]
+	if !ok {
+		return nil, "", fmt.Errorf("key %s not found", location.Path)
+	}
+	return []byte(content), "json", nil
+}
+
+func TestGenDiffSource(t *testing.T) {
+	RegisterSource("mock", mockSource{"/app/config": `{"host":"hexlet.io","timeout":50}`})
+
+	file := createTempFile(t, `{"host":"hexlet.io","timeout":20}`)
+	defer func() {
+		if err := os.Remove(file); err != nil {
+			t.Logf("failed to remove temp file %s: %v", file, err)
+		}
+	}()
+
+	result, err := GenDiffSource(context.Background(), "mock://server/app/config", file, "plain", Options{})
+	require.NoError(t, err)
+	assert.Equal(t, "Property 'timeout' was updated. From 50 to 20", result)
+
+	_, err = GenDiffSource(context.Background(), "mock://server/missing", file, "plain", Options{})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "key /missing not found")
+
+	_, err = GenDiffSource(context.Background(), "unknown://server/app", file, "plain", Options{})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), `no config source registered for scheme "unknown"`)
+}
+
+func TestConsulSource_DefaultClientHasTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+		_, _ = w.Write([]byte(`{"a": 1}`))
+	}))
+	defer server.Close()
+
+	original := httpClient
+	httpClient = &http.Client{Timeout: 20 * time.Millisecond}
+	defer func() { httpClient = original }()
+
+	location, err := url.Parse("consul://" + strings.TrimPrefix(server.URL, "http://") + "/app/config")
+	require.NoError(t, err)
+
+	_, _, err = ConsulSource{}.Fetch(context.Background(), location)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Timeout")
+}
@@ -0,0 +1,123 @@
+package code
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Stats содержит количество узлов каждого типа в дереве различий
+type Stats struct {
+	Added     int `json:"added"`
+	Removed   int `json:"removed"`
+	Updated   int `json:"updated"`
+	Unchanged int `json:"unchanged"`
+	Nested    int `json:"nested"`
+}
+
+// Changes возвращает общее количество изменений (добавленных, удалённых и обновлённых узлов)
+func (s Stats) Changes() int {
+	return s.Added + s.Removed + s.Updated
+}
+
+// Stats рекурсивно подсчитывает узлы дерева различий по типам
+func (n *Node) Stats() Stats {
+	var stats Stats
+	countNodes(n, &stats)
+	return stats
+}
+
+// countNodes обходит дочерние узлы и увеличивает соответствующие счётчики
+func countNodes(node *Node, stats *Stats) {
+	for _, child := range node.Children {
+		switch child.Type {
+		case NodeTypeAdded:
+			stats.Added++
+		case NodeTypeRemoved:
+			stats.Removed++
+		case NodeTypeUpdated:
+			stats.Updated++
+		case NodeTypeUnchanged:
+			stats.Unchanged++
+		case NodeTypeNested:
+			stats.Nested++
+			countNodes(child, stats)
+		}
+	}
+}
+
+// formatSummary выводит только счётчики узлов каждого типа в одну строку
+func formatSummary(node *Node) string {
+	stats := node.Stats()
+	return fmt.Sprintf("added: %d, removed: %d, updated: %d, nested: %d, unchanged: %d",
+		stats.Added, stats.Removed, stats.Updated, stats.Nested, stats.Unchanged)
+}
+
+// HasChanges проверяет, содержит ли дерево хотя бы одно изменение;
+// обход прекращается на первом найденном изменении
+func (n *Node) HasChanges() bool {
+	for _, child := range n.Children {
+		switch child.Type {
+		case NodeTypeAdded, NodeTypeRemoved, NodeTypeUpdated:
+			return true
+		case NodeTypeNested:
+			if child.HasChanges() {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// FilesDiffer быстро проверяет, различаются ли два файла: дерево строится
+// только до первого изменения
+func FilesDiffer(filepath1, filepath2 string, opts Options) (bool, error) {
+	opts.StopAtFirstChange = true
+	diffTree, warnings, err := buildTreeFromFiles(filepath1, filepath2, opts, nil)
+	if err != nil {
+		return false, err
+	}
+	logWarnings(opts, warnings)
+	return diffTree.HasChanges(), nil
+}
+
+// DriftScore суммирует веса изменённых узлов (added, removed, updated) для оценки
+// дрейфа конфигурации. Ключи weights — пути через точку, сегменты которых могут быть
+// glob-шаблонами; шаблон применяется ко всем узлам под совпавшим путём. При нескольких
+// совпадениях используется самый длинный шаблон, узлы без совпадений весят 1.
+func (n *Node) DriftScore(weights map[string]float64) float64 {
+	patterns := make([]string, 0, len(weights))
+	for pattern := range weights {
+		patterns = append(patterns, pattern)
+	}
+	// Сортируем, чтобы выбор среди равных по длине шаблонов был детерминированным
+	sort.Strings(patterns)
+
+	var score float64
+	walkChanges(n, nil, func(nodePath []string, _ *Node) {
+		weight := 1.0
+		matchedLen := 0
+		for _, pattern := range patterns {
+			segments := strings.Split(pattern, ".")
+			if len(segments) > matchedLen && matchPathPrefix(segments, nodePath) {
+				weight = weights[pattern]
+				matchedLen = len(segments)
+			}
+		}
+		score += weight
+	})
+	return score
+}
+
+// walkChanges вызывает fn с путём и самим узлом для каждого изменённого узла
+func walkChanges(node *Node, nodePath []string, fn func(nodePath []string, child *Node)) {
+	for _, child := range node.Children {
+		childPath := appendPath(nodePath, child.name())
+		switch child.Type {
+		case NodeTypeAdded, NodeTypeRemoved, NodeTypeUpdated:
+			fn(childPath, child)
+		case NodeTypeNested:
+			walkChanges(child, childPath, fn)
+		}
+	}
+}
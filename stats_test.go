@@ -0,0 +1,180 @@
+package code
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNodeStats(t *testing.T) {
+	tree := newDiffer(Options{}).buildDiffTree(
+		map[string]interface{}{"a": 1, "b": 2, "nested": map[string]interface{}{"c": 3, "d": 4}},
+		map[string]interface{}{"a": 1, "b": 5, "nested": map[string]interface{}{"c": 3, "e": 6}},
+		nil,
+	)
+
+	assert.Equal(t, Stats{Added: 1, Removed: 1, Updated: 1, Unchanged: 2, Nested: 1}, tree.Stats())
+	assert.Equal(t, 3, tree.Stats().Changes())
+}
+
+func TestStopAtFirstChange(t *testing.T) {
+	data1 := map[string]interface{}{"a": 1, "b": 2, "c": map[string]interface{}{"d": 3}, "e": 4}
+	data2 := map[string]interface{}{"a": 1, "b": 5, "c": map[string]interface{}{"d": 6}, "e": 7}
+
+	full := newDiffer(Options{}).buildDiffTree(data1, data2, nil)
+	partial := newDiffer(Options{StopAtFirstChange: true}).buildDiffTree(data1, data2, nil)
+
+	// The tree stops right after "b" was found to be updated
+	assert.Len(t, full.Children, 4)
+	assert.Len(t, partial.Children, 2)
+	assert.True(t, partial.HasChanges())
+	assert.True(t, full.HasChanges())
+}
+
+func TestStopAtFirstChange_LCSArrays(t *testing.T) {
+	data1 := map[string]interface{}{"a": []interface{}{1, 2, 3}, "b": 1}
+	data2 := map[string]interface{}{"a": []interface{}{1, 3}, "b": 2}
+
+	full := newDiffer(Options{LCSArrays: true}).buildDiffTree(data1, data2, nil)
+	partial := newDiffer(Options{LCSArrays: true, StopAtFirstChange: true}).buildDiffTree(data1, data2, nil)
+
+	// The tree stops right after element 2 was found to be removed, so "b" is not compared
+	assert.Len(t, full.Children, 2)
+	require.Len(t, partial.Children, 1)
+	assert.Len(t, partial.Children[0].Children, 2)
+	assert.True(t, partial.HasChanges())
+}
+
+func TestHasChanges(t *testing.T) {
+	same := newDiffer(Options{}).buildDiffTree(
+		map[string]interface{}{"a": map[string]interface{}{"b": 1}},
+		map[string]interface{}{"a": map[string]interface{}{"b": 1}},
+		nil,
+	)
+	assert.False(t, same.HasChanges())
+
+	nested := newDiffer(Options{}).buildDiffTree(
+		map[string]interface{}{"a": map[string]interface{}{"b": 1}},
+		map[string]interface{}{"a": map[string]interface{}{"b": 2}},
+		nil,
+	)
+	assert.True(t, nested.HasChanges())
+}
+
+func TestFilesDiffer(t *testing.T) {
+	file1 := createTempFile(t, `{"host":"hexlet.io","timeout":50}`)
+	file2 := createTempFile(t, `{"host":"hexlet.io","timeout":20}`)
+	defer func() {
+		if err := os.Remove(file1); err != nil {
+			t.Logf("failed to remove temp file %s: %v", file1, err)
+		}
+		if err := os.Remove(file2); err != nil {
+			t.Logf("failed to remove temp file %s: %v", file2, err)
+		}
+	}()
+
+	differ, err := FilesDiffer(file1, file2, Options{})
+	require.NoError(t, err)
+	assert.True(t, differ)
+
+	differ, err = FilesDiffer(file1, file1, Options{})
+	require.NoError(t, err)
+	assert.False(t, differ)
+}
+
+func TestFilesDiffer_IncludePaths(t *testing.T) {
+	dir := t.TempDir()
+	file1 := writeTestFile(t, dir, "a.json", `{"metadata": {"name": "a"}, "spec": {"replicas": 1}}`)
+	file2 := writeTestFile(t, dir, "b.json", `{"metadata": {"name": "b"}, "spec": {"replicas": 2}}`)
+
+	// The first change is in metadata, which the filter drops
+	differ, err := FilesDiffer(file1, file2, Options{IncludePaths: []string{"spec.replicas"}})
+	require.NoError(t, err)
+	assert.True(t, differ)
+
+	differ, err = FilesDiffer(file1, file2, Options{IgnorePaths: []string{"metadata"}})
+	require.NoError(t, err)
+	assert.True(t, differ)
+
+	differ, err = FilesDiffer(file1, file2, Options{IncludePaths: []string{"spec.missing"}})
+	require.NoError(t, err)
+	assert.False(t, differ)
+}
+
+func BenchmarkStopAtFirstChange(b *testing.B) {
+	// A large input whose first key already differs
+	data1 := make(map[string]interface{})
+	data2 := make(map[string]interface{})
+	for i := 0; i < 10000; i++ {
+		key := fmt.Sprintf("key%05d", i)
+		value := map[string]interface{}{"value": strings.Repeat("x", 32), "index": i}
+		data1[key] = value
+		data2[key] = value
+	}
+	data1["key00000"] = "old"
+	data2["key00000"] = "new"
+
+	b.Run("full", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			newDiffer(Options{}).buildDiffTree(data1, data2, nil)
+		}
+	})
+	b.Run("stop_at_first_change", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			newDiffer(Options{StopAtFirstChange: true}).buildDiffTree(data1, data2, nil)
+		}
+	})
+}
+
+func TestDriftScore(t *testing.T) {
+	tree := newDiffer(Options{}).buildDiffTree(
+		map[string]interface{}{
+			"security": map[string]interface{}{"tls": true, "ciphers": "modern", "auth": map[string]interface{}{"mode": "basic"}},
+			"server":   map[string]interface{}{"port": 80, "host": "a"},
+			"name":     "app",
+		},
+		map[string]interface{}{
+			"security": map[string]interface{}{"tls": false, "ciphers": "legacy", "auth": map[string]interface{}{"mode": "none"}},
+			"server":   map[string]interface{}{"port": 8080, "host": "a", "debug": true},
+			"name":     "app",
+		},
+		nil,
+	)
+
+	weights := map[string]float64{
+		"security":        5,
+		"security.auth.*": 10,
+		"server.port":     2,
+	}
+
+	// security.auth.mode (10) + security.ciphers (5) + security.tls (5) +
+	// server.debug (default 1) + server.port (2)
+	assert.InDelta(t, 23.0, tree.DriftScore(weights), 1e-9)
+	assert.InDelta(t, 5.0, tree.DriftScore(nil), 1e-9)
+}
+
+func TestGenDiff_SummaryFormat(t *testing.T) {
+	content1 := `{
+  "common": {"setting1": "Value 1", "setting2": 200, "setting6": {"key": "value", "doge": {"wow": ""}}},
+  "group1": {"baz": "bas", "foo": "bar"},
+  "group2": {"abc": 12345}
+}`
+	content2 := `{
+  "common": {"setting1": "Value 1", "setting3": null, "setting6": {"key": "value", "doge": {"wow": "so much"}, "ops": "vops"}},
+  "group1": {"baz": "bars", "foo": "bar"},
+  "group3": {"fee": 100500}
+}`
+
+	result, err := GenDiffString(content1, content2, "json", "summary", Options{})
+	require.NoError(t, err)
+	// Nested: common, common.setting6, common.setting6.doge, group1
+	assert.Equal(t, "added: 3, removed: 2, updated: 2, nested: 4, unchanged: 3", result)
+
+	empty, err := GenDiffString(`{}`, `{}`, "json", "summary", Options{})
+	require.NoError(t, err)
+	assert.Equal(t, "added: 0, removed: 0, updated: 0, nested: 0, unchanged: 0", empty)
+}
@@ -0,0 +1,68 @@
+package code
+
+import (
+	"fmt"
+	"io"
+)
+
+// GenDiffTo сравнивает два конфигурационных файла и пишет результат в w по мере
+// форматирования, не собирая весь вывод в одну строку. Так выводятся stylish, plain,
+// json, ndjson, csv, markdown, html и html-tree; остальные форматы собираются в памяти.
+func GenDiffTo(w io.Writer, filepath1, filepath2, format string) error {
+	return GenDiffToWithOptions(w, filepath1, filepath2, format, Options{})
+}
+
+// GenDiffToWithOptions работает как GenDiffTo с учётом переданных параметров.
+// Предупреждения пишутся в opts.Logger, а если он не задан — в stderr.
+func GenDiffToWithOptions(w io.Writer, filepath1, filepath2, format string, opts Options) error {
+	opts.files = [2]string{filepath1, filepath2}
+	diffTree, warnings, err := buildTreeFromFiles(filepath1, filepath2, opts, nil)
+	if err != nil {
+		return err
+	}
+	logWarnings(opts, warnings)
+
+	if err := writeDiff(w, diffTree, format, opts); err != nil {
+		return fmt.Errorf("failed to format diff: %w", err)
+	}
+	return nil
+}
+
+// textWriter — приёмник вывода форматтеров: strings.Builder, bytes.Buffer или bufio.Writer.
+// Ошибки записи не проверяются в каждом вызове: bufio.Writer запоминает первую из них
+// и возвращает её из Flush.
+type textWriter interface {
+	io.Writer
+	io.StringWriter
+}
+
+// newlineTrimmer придерживает завершающий перевод строки записи и передаёт его дальше,
+// только если за ним следует ещё запись, чтобы вывод не заканчивался "\n"
+type newlineTrimmer struct {
+	w       io.Writer
+	pending bool
+}
+
+func (t *newlineTrimmer) Write(p []byte) (int, error) {
+	n := len(p)
+	if n == 0 {
+		return 0, nil
+	}
+	if t.pending {
+		if _, err := t.w.Write([]byte{'\n'}); err != nil {
+			return 0, err
+		}
+		t.pending = false
+	}
+	if p[n-1] == '\n' {
+		p, t.pending = p[:n-1], true
+	}
+	if _, err := t.w.Write(p); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+func (t *newlineTrimmer) WriteString(s string) (int, error) {
+	return t.Write([]byte(s))
+}
@@ -0,0 +1,98 @@
+package code
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+func TestGenDiffTo(t *testing.T) {
+	file1 := filepath.Join("testdata", "fixture", "file1.json")
+	file2 := filepath.Join("testdata", "fixture", "file2.json")
+
+	formats := []string{
+		"stylish", "plain", "json", "patch", "csv", "ndjson", "envelope", "drifted",
+		"section-stats", "html", "markdown", "unified", "yaml", "html-tree", "summary",
+	}
+	for _, format := range formats {
+		t.Run(format, func(t *testing.T) {
+			buffered, err := GenDiff(file1, file2, format)
+			require.NoError(t, err)
+
+			var streamed bytes.Buffer
+			require.NoError(t, GenDiffTo(&streamed, file1, file2, format))
+			assert.Equal(t, buffered, streamed.String())
+		})
+	}
+
+	t.Run("stylish with options", func(t *testing.T) {
+		for _, opts := range []Options{{Guides: true}, {MaxChanges: 2}, {IndentWidth: 2}} {
+			buffered, err := GenDiffWithOptions(file1, file2, "stylish", opts)
+			require.NoError(t, err)
+
+			var streamed bytes.Buffer
+			require.NoError(t, GenDiffToWithOptions(&streamed, file1, file2, "stylish", opts))
+			assert.Equal(t, buffered, streamed.String())
+		}
+	})
+}
+
+func TestGenDiffTo_Errors(t *testing.T) {
+	file1 := filepath.Join("testdata", "fixture", "file1.json")
+	file2 := filepath.Join("testdata", "fixture", "file2.json")
+
+	err := GenDiffTo(failingWriter{}, file1, file2, "stylish")
+	assert.ErrorContains(t, err, "disk full")
+
+	var out bytes.Buffer
+	err = GenDiffTo(&out, file1, file2, "unknown")
+	assert.ErrorContains(t, err, "unsupported format: unknown")
+	assert.Empty(t, out.String())
+}
+
+func TestNewlineTrimmer(t *testing.T) {
+	var out bytes.Buffer
+	trimmer := &newlineTrimmer{w: &out}
+	for _, chunk := range []string{"a\n", "", "b\n\n", "c\n"} {
+		_, err := trimmer.WriteString(chunk)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, "a\nb\n\nc", out.String())
+}
+
+func TestWriteJSON_MatchesMarshalIndent(t *testing.T) {
+	file1 := filepath.Join("testdata", "fixture", "file1.json")
+	file2 := filepath.Join("testdata", "fixture", "file2.json")
+	tree, err := GenDiffTree(file1, file2)
+	require.NoError(t, err)
+
+	for _, nodePaths := range []bool{false, true} {
+		expectedTree := tree.Clone()
+		if !nodePaths {
+			require.NoError(t, Walk(expectedTree, func(n *Node, _ []string) error {
+				n.Path = nil
+				return nil
+			}))
+		}
+		expected, err := json.MarshalIndent(expectedTree, "", "  ")
+		require.NoError(t, err)
+
+		var streamed bytes.Buffer
+		require.NoError(t, writeJSON(&streamed, tree, Options{NodePaths: nodePaths}))
+		assert.Equal(t, string(expected), streamed.String())
+	}
+
+	// Writing the json output must not strip paths from the caller's tree
+	assert.NotEmpty(t, tree.Children[0].Path)
+}
@@ -0,0 +1,70 @@
+package code
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// GenDiffMaps сравнивает две уже разобранные конфигурации. Значения должны иметь
+// типы, которые возвращают парсеры: карты map[string]interface{}, массивы
+// []interface{}, строки, числа, bool и nil. Прочие типы выводятся через %v,
+// а с opts.StrictTypes приводят к ошибке.
+func GenDiffMaps(data1, data2 map[string]interface{}, format string, opts Options) (string, error) {
+	data1, data2, err := selectRoots(data1, data2, opts)
+	if err != nil {
+		return "", err
+	}
+	if err := checkStrictTypes(data1, data2, opts); err != nil {
+		return "", err
+	}
+
+	diffTree, warnings := buildTree(data1, data2, opts)
+	result, err := formatDiff(diffTree, format, opts)
+	if err != nil {
+		return "", fmt.Errorf("failed to format diff: %w", err)
+	}
+
+	logWarnings(opts, warnings)
+	return result, nil
+}
+
+// checkStrictTypes при включённом opts.StrictTypes проверяет, что обе конфигурации
+// содержат только поддерживаемые типы значений
+func checkStrictTypes(data1, data2 map[string]interface{}, opts Options) error {
+	if !opts.StrictTypes {
+		return nil
+	}
+	if err := checkValueTypes(data1, nil); err != nil {
+		return fmt.Errorf("first input: %w", err)
+	}
+	if err := checkValueTypes(data2, nil); err != nil {
+		return fmt.Errorf("second input: %w", err)
+	}
+	return nil
+}
+
+// checkValueTypes рекурсивно ищет значение неподдерживаемого типа и возвращает
+// ошибку с его путём
+func checkValueTypes(v interface{}, path []string) error {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for _, key := range getSortedKeys(val) {
+			if err := checkValueTypes(val[key], appendPath(path, key)); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for i, item := range val {
+			if err := checkValueTypes(item, appendPath(path, strconv.Itoa(i))); err != nil {
+				return err
+			}
+		}
+	case nil, bool, string, json.Number,
+		float64, float32, int, int64, int32, uint, uint64:
+	default:
+		return fmt.Errorf("unsupported value type %T at '%s'", v, strings.Join(path, "."))
+	}
+	return nil
+}
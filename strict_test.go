@@ -0,0 +1,43 @@
+package code
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenDiffMaps_StrictTypes(t *testing.T) {
+	type custom struct{ Name string }
+	data1 := map[string]interface{}{
+		"host":    "hexlet.io",
+		"created": time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+	data2 := map[string]interface{}{
+		"host":   "hexlet.io",
+		"owners": []interface{}{"a", custom{Name: "b"}},
+	}
+
+	t.Run("lenient", func(t *testing.T) {
+		result, err := GenDiffMaps(data1, data2, "plain", Options{})
+		require.NoError(t, err)
+		assert.Contains(t, result, "Property 'created' was removed")
+	})
+
+	t.Run("strict", func(t *testing.T) {
+		_, err := GenDiffMaps(data1, data2, "plain", Options{StrictTypes: true})
+		assert.EqualError(t, err, "first input: unsupported value type time.Time at 'created'")
+
+		delete(data1, "created")
+		_, err = GenDiffMaps(data1, data2, "plain", Options{StrictTypes: true})
+		assert.EqualError(t, err, "second input: unsupported value type code.custom at 'owners.1'")
+	})
+
+	t.Run("parsed values pass", func(t *testing.T) {
+		content := `{"a": 1, "b": [true, null, "x", {"c": 1.5}]}`
+		result, err := GenDiffString(content, `{"a": 2}`, "json", "plain", Options{StrictTypes: true})
+		require.NoError(t, err)
+		assert.Contains(t, result, "Property 'a' was updated. From 1 to 2")
+	})
+}
@@ -0,0 +1,27 @@
+package code
+
+// ChangedSubtree возвращает только добавленные и изменённые ключи с их новыми значениями,
+// сохраняя вложенность. Удалённые ключи не попадают в результат. Подходит для записи
+// в файл переопределений.
+func (n *Node) ChangedSubtree() map[string]interface{} {
+	subtree := make(map[string]interface{})
+	for _, child := range n.Children {
+		switch child.Type {
+		case NodeTypeAdded, NodeTypeUpdated:
+			subtree[child.name()] = child.NewValue
+		case NodeTypeNested:
+			if !child.HasChanges() {
+				continue
+			}
+			if isArrayNode(child) {
+				// Массив целиком заменяется новым значением
+				subtree[child.name()] = sideValue(child, false)
+				continue
+			}
+			if nested := child.ChangedSubtree(); len(nested) > 0 {
+				subtree[child.name()] = nested
+			}
+		}
+	}
+	return subtree
+}
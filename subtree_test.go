@@ -0,0 +1,41 @@
+package code
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChangedSubtree(t *testing.T) {
+	content1 := `{
+  "host": "localhost",
+  "port": 80,
+  "legacy": true,
+  "db": {"user": "admin", "pool": 5, "old": 1},
+  "cache": {"ttl": 60}
+}`
+	content2 := `{
+  "host": "localhost",
+  "port": 8080,
+  "db": {"user": "admin", "pool": 10, "ssl": {"mode": "require"}},
+  "cache": {"ttl": 60},
+  "tags": ["a", "b"]
+}`
+
+	data1, err := parseJSON([]byte(content1))
+	require.NoError(t, err)
+	data2, err := parseJSON([]byte(content2))
+	require.NoError(t, err)
+	tree, _ := buildTree(data1, data2, Options{})
+
+	expected := map[string]interface{}{
+		"port": 8080.0,
+		"db": map[string]interface{}{
+			"pool": 10.0,
+			"ssl":  map[string]interface{}{"mode": "require"},
+		},
+		"tags": []interface{}{"a", "b"},
+	}
+	assert.Equal(t, expected, tree.ChangedSubtree())
+}
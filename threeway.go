@@ -0,0 +1,199 @@
+package code
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Классы ключей трёхстороннего сравнения
+const (
+	// MergeUnchanged — ключ не изменён ни одной из веток
+	MergeUnchanged = "unchanged"
+	// MergeOurs — ключ изменён только нашей веткой
+	MergeOurs = "ours"
+	// MergeTheirs — ключ изменён только их веткой
+	MergeTheirs = "theirs"
+	// MergeBoth — обе ветки изменили ключ одинаково
+	MergeBoth = "both"
+	// MergeConflict — ветки изменили ключ по-разному
+	MergeConflict = "conflict"
+)
+
+// MergeEntry описывает состояние одного пути при трёхстороннем сравнении.
+// Отсутствие значения на стороне (ключ удалён или не добавлен) отмечается флагом *Exists.
+type MergeEntry struct {
+	Path         string      `json:"path"`
+	Status       string      `json:"status"`
+	Base         interface{} `json:"base,omitempty"`
+	BaseExists   bool        `json:"-"`
+	Ours         interface{} `json:"ours,omitempty"`
+	OursExists   bool        `json:"-"`
+	Theirs       interface{} `json:"theirs,omitempty"`
+	TheirsExists bool        `json:"-"`
+}
+
+// GenDiff3 сравнивает две ветки ours и theirs с общей базой base и относит каждый ключ
+// к одному из классов: unchanged, ours, theirs, both или conflict. Поддерживаются
+// форматы stylish (конфликты отмечены "!"), plain и json.
+func GenDiff3(base, ours, theirs string, format string) (string, error) {
+	return GenDiff3WithOptions(base, ours, theirs, format, Options{})
+}
+
+// GenDiff3WithOptions работает как GenDiff3, сравнивая файлы с параметрами opts
+func GenDiff3WithOptions(base, ours, theirs string, format string, opts Options) (string, error) {
+	oursTree, oursWarnings, err := buildTreeFromFiles(base, ours, opts, nil)
+	if err != nil {
+		return "", err
+	}
+	theirsTree, theirsWarnings, err := buildTreeFromFiles(base, theirs, opts, nil)
+	if err != nil {
+		return "", err
+	}
+	logWarnings(opts, concatWarnings(oursWarnings, theirsWarnings))
+
+	entries := MergeEntries(oursTree, theirsTree)
+	switch strings.ToLower(format) {
+	case "stylish":
+		return formatMergeStylish(entries), nil
+	case "plain":
+		return formatMergePlain(entries), nil
+	case "json":
+		data, err := json.MarshalIndent(entries, "", "  ")
+		if err != nil {
+			return "", fmt.Errorf("failed to format three-way diff: %w", err)
+		}
+		return string(data), nil
+	default:
+		return "", fmt.Errorf("unsupported format for three-way diff: %s", format)
+	}
+}
+
+// MergeEntries классифицирует пути двух деревьев различий от общей базы. Изменённые
+// пути берутся из FindConflicts, неизменённые — из узлов, не затронутых ни одной веткой.
+// Результат упорядочен по пути.
+func MergeEntries(ours, theirs *Node) []MergeEntry {
+	baseDoc := oldDocument(ours)
+	oursDoc := newDocument(ours)
+	theirsDoc := newDocument(theirs)
+
+	entry := func(name string, nodePath []string, status string) MergeEntry {
+		e := MergeEntry{Path: name, Status: status}
+		e.Base, e.BaseExists = valueAt(baseDoc, nodePath)
+		e.Ours, e.OursExists = valueAt(oursDoc, nodePath)
+		e.Theirs, e.TheirsExists = valueAt(theirsDoc, nodePath)
+		return e
+	}
+
+	conflicts, clean := FindConflicts(ours, theirs)
+	entries := make([]MergeEntry, 0, len(conflicts)+len(clean))
+	for _, conflict := range conflicts {
+		entries = append(entries, entry(conflict.Path, conflict.Segments, MergeConflict))
+	}
+	for _, change := range clean {
+		entries = append(entries, entry(change.Path, change.Segments, change.Side))
+	}
+
+	changed := append(changedPaths(ours), changedPaths(theirs)...)
+	for _, nodePath := range unchangedPaths(ours) {
+		touched := false
+		for _, changedPath := range changed {
+			if isPathPrefix(nodePath, changedPath) || isPathPrefix(changedPath, nodePath) {
+				touched = true
+				break
+			}
+		}
+		if !touched {
+			entries = append(entries, entry(strings.Join(nodePath, "."), nodePath, MergeUnchanged))
+		}
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Path < entries[j].Path })
+	return entries
+}
+
+// unchangedPaths возвращает пути неизменённых узлов дерева
+func unchangedPaths(node *Node) [][]string {
+	var paths [][]string
+	var walk func(node *Node, nodePath []string)
+	walk = func(node *Node, nodePath []string) {
+		for _, child := range node.Children {
+			childPath := appendPath(nodePath, child.name())
+			switch child.Type {
+			case NodeTypeUnchanged:
+				paths = append(paths, childPath)
+			case NodeTypeNested:
+				walk(child, childPath)
+			}
+		}
+	}
+	walk(node, nil)
+	return paths
+}
+
+// mergeMarkers — маркеры строк трёхстороннего stylish вывода
+var mergeMarkers = map[string]string{
+	MergeUnchanged: " ",
+	MergeOurs:      "<",
+	MergeTheirs:    ">",
+	MergeBoth:      "=",
+	MergeConflict:  "!",
+}
+
+// formatMergeStylish выводит по строке на путь: "<" — изменение нашей ветки,
+// ">" — их ветки, "=" — одинаковое изменение обеих, "!" — конфликт со значениями
+// обеих сторон
+func formatMergeStylish(entries []MergeEntry) string {
+	lines := make([]string, 0, len(entries)+2)
+	lines = append(lines, "{")
+	for _, e := range entries {
+		var value string
+		switch e.Status {
+		case MergeConflict:
+			value = fmt.Sprintf("ours %s | theirs %s", mergeValue(e.Ours, e.OursExists), mergeValue(e.Theirs, e.TheirsExists))
+		case MergeTheirs:
+			value = mergeValue(e.Theirs, e.TheirsExists)
+		default:
+			value = mergeValue(e.Ours, e.OursExists)
+		}
+		lines = append(lines, fmt.Sprintf("  %s %s: %s", mergeMarkers[e.Status], e.Path, value))
+	}
+	lines = append(lines, "}")
+	return strings.Join(lines, "\n")
+}
+
+// formatMergePlain описывает каждое изменение предложением; неизменённые пути не выводятся
+func formatMergePlain(entries []MergeEntry) string {
+	var lines []string
+	for _, e := range entries {
+		switch e.Status {
+		case MergeOurs:
+			lines = append(lines, fmt.Sprintf("Property '%s' was changed by ours to %s", e.Path, mergePlainValue(e.Ours, e.OursExists)))
+		case MergeTheirs:
+			lines = append(lines, fmt.Sprintf("Property '%s' was changed by theirs to %s", e.Path, mergePlainValue(e.Theirs, e.TheirsExists)))
+		case MergeBoth:
+			lines = append(lines, fmt.Sprintf("Property '%s' was changed by both to %s", e.Path, mergePlainValue(e.Ours, e.OursExists)))
+		case MergeConflict:
+			lines = append(lines, fmt.Sprintf("CONFLICT: property '%s' was changed to %s by ours and to %s by theirs",
+				e.Path, mergePlainValue(e.Ours, e.OursExists), mergePlainValue(e.Theirs, e.TheirsExists)))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// mergeValue форматирует значение стороны; отсутствующее значение выводится как (removed)
+func mergeValue(v interface{}, exists bool) string {
+	if !exists {
+		return "(removed)"
+	}
+	return rowValue(v)
+}
+
+// mergePlainValue форматирует значение стороны в стиле plain
+func mergePlainValue(v interface{}, exists bool) string {
+	if !exists {
+		return "(removed)"
+	}
+	return formatPlainValue(v)
+}
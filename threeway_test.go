@@ -0,0 +1,95 @@
+package code
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenDiff3_CleanMerge(t *testing.T) {
+	dir := t.TempDir()
+	base := writeTestFile(t, dir, "base.json", `{"host": "a", "port": 80, "debug": false}`)
+	ours := writeTestFile(t, dir, "ours.json", `{"host": "b", "port": 80, "debug": false}`)
+	theirs := writeTestFile(t, dir, "theirs.json", `{"host": "a", "port": 8080, "debug": false}`)
+
+	result, err := GenDiff3(base, ours, theirs, "stylish")
+	require.NoError(t, err)
+	expected := `{
+    debug: false
+  < host: b
+  > port: 8080
+}`
+	assert.Equal(t, expected, result)
+
+	result, err = GenDiff3(base, ours, theirs, "plain")
+	require.NoError(t, err)
+	assert.Equal(t, "Property 'host' was changed by ours to 'b'\nProperty 'port' was changed by theirs to 8080", result)
+	assert.NotContains(t, result, "CONFLICT")
+}
+
+func TestGenDiff3_Conflict(t *testing.T) {
+	dir := t.TempDir()
+	base := writeTestFile(t, dir, "base.json", `{"db": {"port": 5432, "host": "a"}, "legacy": 1, "debug": false}`)
+	ours := writeTestFile(t, dir, "ours.json", `{"db": {"port": 5433, "host": "a"}, "debug": true}`)
+	theirs := writeTestFile(t, dir, "theirs.json", `{"db": {"port": 5434, "host": "a"}, "legacy": 2, "debug": true}`)
+
+	result, err := GenDiff3(base, ours, theirs, "stylish")
+	require.NoError(t, err)
+	expected := `{
+    db.host: a
+  ! db.port: ours 5433 | theirs 5434
+  = debug: true
+  ! legacy: ours (removed) | theirs 2
+}`
+	assert.Equal(t, expected, result)
+
+	result, err = GenDiff3(base, ours, theirs, "plain")
+	require.NoError(t, err)
+	assert.Contains(t, result, "CONFLICT: property 'db.port' was changed to 5433 by ours and to 5434 by theirs")
+	assert.Contains(t, result, "CONFLICT: property 'legacy' was changed to (removed) by ours and to 2 by theirs")
+
+	var entries []MergeEntry
+	out, err := GenDiff3(base, ours, theirs, "json")
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal([]byte(out), &entries))
+	statuses := map[string]string{}
+	for _, e := range entries {
+		statuses[e.Path] = e.Status
+	}
+	assert.Equal(t, map[string]string{
+		"db.host": MergeUnchanged,
+		"db.port": MergeConflict,
+		"debug":   MergeBoth,
+		"legacy":  MergeConflict,
+	}, statuses)
+}
+
+func TestGenDiff3_DottedKeys(t *testing.T) {
+	dir := t.TempDir()
+	base := writeTestFile(t, dir, "base.json", `{"app.port": 80, "app.host": "a"}`)
+	ours := writeTestFile(t, dir, "ours.json", `{"app.port": 81, "app.host": "a"}`)
+	theirs := writeTestFile(t, dir, "theirs.json", `{"app.port": 82, "app.host": "a"}`)
+
+	var entries []MergeEntry
+	out, err := GenDiff3(base, ours, theirs, "json")
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal([]byte(out), &entries))
+	require.Len(t, entries, 2)
+
+	// The dotted key is looked up as one segment, so the values are found
+	assert.Equal(t, "app.port", entries[1].Path)
+	assert.Equal(t, MergeConflict, entries[1].Status)
+	assert.Equal(t, 80.0, entries[1].Base)
+	assert.Equal(t, 81.0, entries[1].Ours)
+	assert.Equal(t, 82.0, entries[1].Theirs)
+}
+
+func TestGenDiff3_UnsupportedFormat(t *testing.T) {
+	dir := t.TempDir()
+	file := writeTestFile(t, dir, "a.json", `{}`)
+
+	_, err := GenDiff3(file, file, file, "csv")
+	assert.ErrorContains(t, err, "unsupported format")
+}
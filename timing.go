@@ -0,0 +1,30 @@
+package code
+
+import (
+	"fmt"
+	"time"
+)
+
+// Timing содержит длительность этапов сравнения
+type Timing struct {
+	Parse1 time.Duration
+	Parse2 time.Duration
+	Diff   time.Duration
+	Format time.Duration
+}
+
+// Total возвращает суммарную длительность всех этапов
+func (t Timing) Total() time.Duration {
+	return t.Parse1 + t.Parse2 + t.Diff + t.Format
+}
+
+// Lines возвращает по строке на каждый этап для вывода в stderr
+func (t Timing) Lines(filepath1, filepath2 string) []string {
+	return []string{
+		fmt.Sprintf("parse %s: %s", filepath1, t.Parse1),
+		fmt.Sprintf("parse %s: %s", filepath2, t.Parse2),
+		fmt.Sprintf("diff: %s", t.Diff),
+		fmt.Sprintf("format: %s", t.Format),
+		fmt.Sprintf("total: %s", t.Total()),
+	}
+}
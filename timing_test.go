@@ -0,0 +1,45 @@
+package code
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenDiffResult_Timing(t *testing.T) {
+	file1 := createTempFile(t, `{"host":"a","port":80}`)
+	file2 := createTempYAMLFile(t, "host: a\nport: 8080\n")
+	defer func() {
+		if err := os.Remove(file1); err != nil {
+			t.Logf("failed to remove temp file %s: %v", file1, err)
+		}
+		if err := os.Remove(file2); err != nil {
+			t.Logf("failed to remove temp file %s: %v", file2, err)
+		}
+	}()
+
+	result, err := GenDiffResult(file1, file2, "stylish", Options{})
+	require.NoError(t, err)
+
+	timing := result.Timing
+	assert.GreaterOrEqual(t, timing.Parse1, time.Duration(0))
+	assert.GreaterOrEqual(t, timing.Parse2, time.Duration(0))
+	assert.GreaterOrEqual(t, timing.Diff, time.Duration(0))
+	assert.GreaterOrEqual(t, timing.Format, time.Duration(0))
+	assert.Equal(t, timing.Parse1+timing.Parse2+timing.Diff+timing.Format, timing.Total())
+
+	lines := timing.Lines(file1, file2)
+	require.Len(t, lines, 5)
+	assert.True(t, strings.HasPrefix(lines[0], "parse "+file1+": "))
+	assert.True(t, strings.HasPrefix(lines[1], "parse "+file2+": "))
+	assert.True(t, strings.HasPrefix(lines[2], "diff: "))
+	assert.True(t, strings.HasPrefix(lines[3], "format: "))
+	assert.True(t, strings.HasPrefix(lines[4], "total: "))
+	for _, line := range lines {
+		assert.NotContains(t, line, ": -")
+	}
+}
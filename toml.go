@@ -0,0 +1,671 @@
+package code
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Шаблоны для распознавания дат и времени TOML
+var (
+	tomlDatePattern = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}$`)
+	tomlTimePattern = regexp.MustCompile(`^\d{2}:\d{2}(:\d{2}(\.\d+)?)?$`)
+)
+
+// tomlParser разбирает TOML документ в карты того же вида, что и JSON: таблицы
+// становятся map[string]interface{}, массивы — []interface{}, числа — float64
+// (целые вне диапазона точного представления остаются int64), а даты и время —
+// строками в нормализованной записи RFC 3339
+type tomlParser struct {
+	src  string
+	pos  int
+	line int
+
+	root    map[string]interface{}
+	current map[string]interface{}
+	// defined отмечает таблицы, уже объявленные заголовком или ключом
+	defined map[string]bool
+	// arrayTables отмечает массивы, созданные заголовками [[...]]
+	arrayTables map[string]bool
+}
+
+// parseTOML парсит TOML содержимое
+func parseTOML(content []byte) (map[string]interface{}, error) {
+	root := make(map[string]interface{})
+	p := &tomlParser{
+		src:         string(content),
+		line:        1,
+		root:        root,
+		current:     root,
+		defined:     make(map[string]bool),
+		arrayTables: make(map[string]bool),
+	}
+	if err := p.parse(); err != nil {
+		return nil, fmt.Errorf("failed to parse TOML: line %d: %w", p.line, err)
+	}
+	return root, nil
+}
+
+// parse разбирает документ построчно: заголовки таблиц и пары ключ = значение
+func (p *tomlParser) parse() error {
+	for {
+		p.skipBlank()
+		if p.eof() {
+			return nil
+		}
+
+		var err error
+		switch {
+		case strings.HasPrefix(p.src[p.pos:], "[["):
+			err = p.parseArrayTableHeader()
+		case p.peek() == '[':
+			err = p.parseTableHeader()
+		default:
+			err = p.parseKeyValue(p.current)
+		}
+		if err != nil {
+			return err
+		}
+
+		// После выражения допустимы только пробелы, комментарий и конец строки
+		p.skipSpaces()
+		p.skipComment()
+		if !p.eof() && !p.consumeNewline() {
+			return fmt.Errorf("unexpected %q after value", p.peek())
+		}
+	}
+}
+
+// parseTableHeader разбирает заголовок [a.b] и делает таблицу текущей
+func (p *tomlParser) parseTableHeader() error {
+	p.pos++
+	keys, err := p.parseKey()
+	if err != nil {
+		return err
+	}
+	p.skipSpaces()
+	if !p.consume("]") {
+		return fmt.Errorf("expected ']' after table name")
+	}
+
+	name := tableName(keys)
+	if p.defined[name] {
+		return fmt.Errorf("table '%s' is defined more than once", name)
+	}
+	table, err := p.descend(p.root, keys, true)
+	if err != nil {
+		return err
+	}
+	p.defined[name] = true
+	p.current = table
+	return nil
+}
+
+// parseArrayTableHeader разбирает заголовок [[a.b]], добавляя новую таблицу в массив
+func (p *tomlParser) parseArrayTableHeader() error {
+	p.pos += 2
+	keys, err := p.parseKey()
+	if err != nil {
+		return err
+	}
+	p.skipSpaces()
+	if !p.consume("]]") {
+		return fmt.Errorf("expected ']]' after array of tables name")
+	}
+
+	parent, err := p.descend(p.root, keys[:len(keys)-1], true)
+	if err != nil {
+		return err
+	}
+	last := keys[len(keys)-1]
+	name := tableName(keys)
+
+	table := make(map[string]interface{})
+	switch existing := parent[last].(type) {
+	case nil:
+		parent[last] = []interface{}{table}
+		p.arrayTables[name] = true
+	case []interface{}:
+		if !p.arrayTables[name] {
+			return fmt.Errorf("cannot append to static array '%s'", name)
+		}
+		parent[last] = append(existing, table)
+	default:
+		return fmt.Errorf("key '%s' is already defined", name)
+	}
+
+	// Вложенные таблицы нового элемента объявляются заново
+	for defined := range p.defined {
+		if strings.HasPrefix(defined, name+".") {
+			delete(p.defined, defined)
+		}
+	}
+	p.current = table
+	return nil
+}
+
+// parseKeyValue разбирает пару ключ = значение и записывает её в таблицу
+func (p *tomlParser) parseKeyValue(table map[string]interface{}) error {
+	keys, err := p.parseKey()
+	if err != nil {
+		return err
+	}
+	p.skipSpaces()
+	if !p.consume("=") {
+		return fmt.Errorf("expected '=' after key '%s'", tableName(keys))
+	}
+	p.skipSpaces()
+
+	value, err := p.parseValue()
+	if err != nil {
+		return err
+	}
+
+	target, err := p.descend(table, keys[:len(keys)-1], false)
+	if err != nil {
+		return err
+	}
+	last := keys[len(keys)-1]
+	if _, exists := target[last]; exists {
+		return fmt.Errorf("key '%s' is defined more than once", tableName(keys))
+	}
+	target[last] = value
+	return nil
+}
+
+// descend спускается по ключам, создавая недостающие таблицы. Заголовки таблиц
+// (fromHeader) могут вести через массив таблиц [[...]] — тогда используется его
+// последний элемент; составные ключи в парах ключ = значение этого не допускают.
+func (p *tomlParser) descend(table map[string]interface{}, keys []string, fromHeader bool) (map[string]interface{}, error) {
+	for i, key := range keys {
+		switch next := table[key].(type) {
+		case nil:
+			created := make(map[string]interface{})
+			table[key] = created
+			table = created
+		case map[string]interface{}:
+			table = next
+		case []interface{}:
+			if !fromHeader || !p.arrayTables[tableName(keys[:i+1])] {
+				return nil, fmt.Errorf("key '%s' is not a table", tableName(keys[:i+1]))
+			}
+			table = next[len(next)-1].(map[string]interface{})
+		default:
+			return nil, fmt.Errorf("key '%s' is not a table", tableName(keys[:i+1]))
+		}
+	}
+	return table, nil
+}
+
+// parseKey разбирает ключ, в том числе составной (a."b.c".d)
+func (p *tomlParser) parseKey() ([]string, error) {
+	var keys []string
+	for {
+		p.skipSpaces()
+		var key string
+		var err error
+		switch p.peek() {
+		case '"':
+			key, err = p.parseBasicString()
+		case '\'':
+			key, err = p.parseLiteralString()
+		default:
+			start := p.pos
+			for !p.eof() && isBareKeyChar(p.peek()) {
+				p.pos++
+			}
+			key = p.src[start:p.pos]
+			if key == "" {
+				return nil, fmt.Errorf("expected key")
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+
+		p.skipSpaces()
+		if !p.consume(".") {
+			return keys, nil
+		}
+	}
+}
+
+// parseValue разбирает значение любого типа
+func (p *tomlParser) parseValue() (interface{}, error) {
+	switch {
+	case p.eof():
+		return nil, fmt.Errorf("expected value")
+	case strings.HasPrefix(p.src[p.pos:], `"""`):
+		return p.parseMultilineBasicString()
+	case strings.HasPrefix(p.src[p.pos:], "'''"):
+		return p.parseMultilineLiteralString()
+	case p.peek() == '"':
+		return p.parseBasicString()
+	case p.peek() == '\'':
+		return p.parseLiteralString()
+	case p.peek() == '[':
+		return p.parseArray()
+	case p.peek() == '{':
+		return p.parseInlineTable()
+	default:
+		return p.parseScalar()
+	}
+}
+
+// parseArray разбирает массив; допускаются переносы строк, комментарии и запятая в конце
+func (p *tomlParser) parseArray() (interface{}, error) {
+	p.pos++
+	items := []interface{}{}
+	for {
+		p.skipBlank()
+		if p.consume("]") {
+			return items, nil
+		}
+
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, value)
+
+		p.skipBlank()
+		if p.consume("]") {
+			return items, nil
+		}
+		if !p.consume(",") {
+			return nil, fmt.Errorf("expected ',' or ']' in array")
+		}
+	}
+}
+
+// parseInlineTable разбирает встроенную таблицу { a = 1, b.c = 2 }
+func (p *tomlParser) parseInlineTable() (interface{}, error) {
+	p.pos++
+	table := make(map[string]interface{})
+	p.skipSpaces()
+	if p.consume("}") {
+		return table, nil
+	}
+
+	for {
+		if err := p.parseKeyValue(table); err != nil {
+			return nil, err
+		}
+		p.skipSpaces()
+		if p.consume("}") {
+			return table, nil
+		}
+		if !p.consume(",") {
+			return nil, fmt.Errorf("expected ',' or '}' in inline table")
+		}
+	}
+}
+
+// parseBasicString разбирает строку в двойных кавычках с экранированием
+func (p *tomlParser) parseBasicString() (string, error) {
+	p.pos++
+	var result strings.Builder
+	for {
+		if p.eof() || p.peek() == '\n' {
+			return "", fmt.Errorf("unterminated string")
+		}
+		c := p.peek()
+		switch c {
+		case '"':
+			p.pos++
+			return result.String(), nil
+		case '\\':
+			if err := p.parseEscape(&result); err != nil {
+				return "", err
+			}
+		default:
+			result.WriteByte(c)
+			p.pos++
+		}
+	}
+}
+
+// parseMultilineBasicString разбирает строку в тройных двойных кавычках
+func (p *tomlParser) parseMultilineBasicString() (string, error) {
+	p.pos += 3
+	p.consumeNewline()
+
+	var result strings.Builder
+	for {
+		if p.eof() {
+			return "", fmt.Errorf("unterminated multi-line string")
+		}
+		if strings.HasPrefix(p.src[p.pos:], `"""`) {
+			p.pos += 3
+			// До двух кавычек перед закрывающими относятся к содержимому
+			for i := 0; i < 2 && p.peek() == '"'; i++ {
+				result.WriteByte('"')
+				p.pos++
+			}
+			return result.String(), nil
+		}
+
+		c := p.peek()
+		switch {
+		case c == '\\' && p.isLineEndingBackslash():
+			// Обратная косая черта в конце строки убирает перенос и пробелы после него
+			p.pos++
+			for !p.eof() && strings.ContainsRune(" \t\r\n", rune(p.peek())) {
+				if p.peek() == '\n' {
+					p.line++
+				}
+				p.pos++
+			}
+		case c == '\\':
+			if err := p.parseEscape(&result); err != nil {
+				return "", err
+			}
+		default:
+			if c == '\n' {
+				p.line++
+			}
+			result.WriteByte(c)
+			p.pos++
+		}
+	}
+}
+
+// parseLiteralString разбирает строку в одинарных кавычках без экранирования
+func (p *tomlParser) parseLiteralString() (string, error) {
+	p.pos++
+	start := p.pos
+	for {
+		if p.eof() || p.peek() == '\n' {
+			return "", fmt.Errorf("unterminated literal string")
+		}
+		if p.peek() == '\'' {
+			value := p.src[start:p.pos]
+			p.pos++
+			return value, nil
+		}
+		p.pos++
+	}
+}
+
+// parseMultilineLiteralString разбирает строку в тройных одинарных кавычках
+func (p *tomlParser) parseMultilineLiteralString() (string, error) {
+	p.pos += 3
+	p.consumeNewline()
+
+	start := p.pos
+	for {
+		if p.eof() {
+			return "", fmt.Errorf("unterminated multi-line literal string")
+		}
+		if strings.HasPrefix(p.src[p.pos:], "'''") {
+			end := p.pos
+			p.pos += 3
+			for i := 0; i < 2 && p.peek() == '\''; i++ {
+				p.pos++
+				end++
+			}
+			return p.src[start:end], nil
+		}
+		if p.peek() == '\n' {
+			p.line++
+		}
+		p.pos++
+	}
+}
+
+// parseEscape разбирает escape-последовательность и пишет символ в result
+func (p *tomlParser) parseEscape(result *strings.Builder) error {
+	p.pos++
+	if p.eof() {
+		return fmt.Errorf("unterminated escape sequence")
+	}
+	c := p.peek()
+	p.pos++
+	switch c {
+	case 'b':
+		result.WriteByte('\b')
+	case 't':
+		result.WriteByte('\t')
+	case 'n':
+		result.WriteByte('\n')
+	case 'f':
+		result.WriteByte('\f')
+	case 'r':
+		result.WriteByte('\r')
+	case 'e':
+		result.WriteByte('\x1b')
+	case '"':
+		result.WriteByte('"')
+	case '\\':
+		result.WriteByte('\\')
+	case 'u', 'U':
+		size := 4
+		if c == 'U' {
+			size = 8
+		}
+		if p.pos+size > len(p.src) {
+			return fmt.Errorf("invalid unicode escape")
+		}
+		code, err := strconv.ParseUint(p.src[p.pos:p.pos+size], 16, 32)
+		if err != nil || !utf8.ValidRune(rune(code)) {
+			return fmt.Errorf("invalid unicode escape \\%c%s", c, p.src[p.pos:p.pos+size])
+		}
+		result.WriteRune(rune(code))
+		p.pos += size
+	default:
+		return fmt.Errorf("invalid escape sequence \\%c", c)
+	}
+	return nil
+}
+
+// parseScalar разбирает булево значение, число или дату
+func (p *tomlParser) parseScalar() (interface{}, error) {
+	start := p.pos
+	for !p.eof() && !strings.ContainsRune(" \t\r\n,]}#", rune(p.peek())) {
+		p.pos++
+	}
+	token := p.src[start:p.pos]
+
+	// Дата и время могут быть разделены пробелом: 1979-05-27 07:32:00
+	if tomlDatePattern.MatchString(token) && p.pos+3 < len(p.src) && p.src[p.pos] == ' ' &&
+		isDigit(p.src[p.pos+1]) && isDigit(p.src[p.pos+2]) && p.src[p.pos+3] == ':' {
+		p.pos++
+		for !p.eof() && !strings.ContainsRune(" \t\r\n,]}#", rune(p.peek())) {
+			p.pos++
+		}
+		token = p.src[start:p.pos]
+	}
+
+	switch token {
+	case "":
+		return nil, fmt.Errorf("expected value")
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	case "inf", "+inf":
+		return math.Inf(1), nil
+	case "-inf":
+		return math.Inf(-1), nil
+	case "nan", "+nan", "-nan":
+		return math.NaN(), nil
+	}
+
+	if value, ok := normalizeTOMLDateTime(token); ok {
+		return value, nil
+	}
+	return parseTOMLNumber(token)
+}
+
+// parseTOMLNumber разбирает целое (в том числе 0x, 0o, 0b) или дробное число
+func parseTOMLNumber(token string) (interface{}, error) {
+	if strings.HasPrefix(token, "_") || strings.HasSuffix(token, "_") || strings.Contains(token, "__") {
+		return nil, fmt.Errorf("invalid number %q", token)
+	}
+	digits := strings.ReplaceAll(token, "_", "")
+
+	for prefix, base := range map[string]int{"0x": 16, "0o": 8, "0b": 2} {
+		if strings.HasPrefix(digits, prefix) {
+			value, err := strconv.ParseInt(digits[2:], base, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid number %q", token)
+			}
+			return tomlInteger(value), nil
+		}
+	}
+
+	if strings.ContainsAny(digits, ".eE") {
+		value, err := strconv.ParseFloat(digits, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q", token)
+		}
+		return value, nil
+	}
+
+	value, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid value %q", token)
+	}
+	return tomlInteger(value), nil
+}
+
+// maxExactFloatInt — наибольшее целое, которое float64 представляет точно
+const maxExactFloatInt = 1 << 53
+
+// tomlInteger приводит целое к float64, как у чисел из JSON, если это не теряет точности
+func tomlInteger(value int64) interface{} {
+	if value >= -maxExactFloatInt && value <= maxExactFloatInt {
+		return float64(value)
+	}
+	return value
+}
+
+// normalizeTOMLDateTime приводит дату и время TOML к единой строковой записи, чтобы
+// они сравнивались со строками из JSON и YAML: дата со смещением — RFC 3339,
+// локальные дата, время и дата-время — в том же виде без смещения
+func normalizeTOMLDateTime(token string) (string, bool) {
+	if tomlTimePattern.MatchString(token) {
+		if len(token) == 5 {
+			token += ":00"
+		}
+		if t, err := time.Parse("15:04:05.999999999", token); err == nil {
+			return t.Format("15:04:05.999999999"), true
+		}
+		return "", false
+	}
+	if len(token) < 10 || !tomlDatePattern.MatchString(token[:10]) {
+		return "", false
+	}
+	if len(token) == 10 {
+		if t, err := time.Parse("2006-01-02", token); err == nil {
+			return t.Format("2006-01-02"), true
+		}
+		return "", false
+	}
+
+	normalized := strings.ToUpper(token[:10] + "T" + token[11:])
+	if t, err := time.Parse(time.RFC3339Nano, normalized); err == nil {
+		return t.Format(time.RFC3339Nano), true
+	}
+	if t, err := time.Parse("2006-01-02T15:04:05.999999999", normalized); err == nil {
+		return t.Format("2006-01-02T15:04:05.999999999"), true
+	}
+	if t, err := time.Parse("2006-01-02T15:04", normalized); err == nil {
+		return t.Format("2006-01-02T15:04:05"), true
+	}
+	return "", false
+}
+
+// skipBlank пропускает пробелы, комментарии и переносы строк
+func (p *tomlParser) skipBlank() {
+	for !p.eof() {
+		p.skipSpaces()
+		p.skipComment()
+		if !p.consumeNewline() {
+			return
+		}
+	}
+}
+
+// skipSpaces пропускает пробелы и табуляции
+func (p *tomlParser) skipSpaces() {
+	for !p.eof() && (p.peek() == ' ' || p.peek() == '\t') {
+		p.pos++
+	}
+}
+
+// skipComment пропускает комментарий до конца строки
+func (p *tomlParser) skipComment() {
+	if p.peek() != '#' {
+		return
+	}
+	for !p.eof() && p.peek() != '\n' {
+		p.pos++
+	}
+}
+
+// consumeNewline пропускает перенос строки (\n или \r\n)
+func (p *tomlParser) consumeNewline() bool {
+	if p.consume("\r\n") || p.consume("\n") {
+		p.line++
+		return true
+	}
+	return false
+}
+
+// isLineEndingBackslash проверяет, что за обратной косой чертой до конца строки только пробелы
+func (p *tomlParser) isLineEndingBackslash() bool {
+	for i := p.pos + 1; i < len(p.src); i++ {
+		switch p.src[i] {
+		case ' ', '\t', '\r':
+			continue
+		case '\n':
+			return true
+		default:
+			return false
+		}
+	}
+	return false
+}
+
+// consume пропускает s, если текст продолжается им
+func (p *tomlParser) consume(s string) bool {
+	if strings.HasPrefix(p.src[p.pos:], s) {
+		p.pos += len(s)
+		return true
+	}
+	return false
+}
+
+// peek возвращает текущий байт или 0 в конце текста
+func (p *tomlParser) peek() byte {
+	if p.eof() {
+		return 0
+	}
+	return p.src[p.pos]
+}
+
+// eof проверяет, что текст закончился
+func (p *tomlParser) eof() bool {
+	return p.pos >= len(p.src)
+}
+
+// tableName собирает имя таблицы через точку для сообщений и учёта объявлений
+func tableName(keys []string) string {
+	return strings.Join(keys, ".")
+}
+
+// isBareKeyChar проверяет, допустим ли символ в ключе без кавычек
+func isBareKeyChar(c byte) bool {
+	return c == '_' || c == '-' || isDigit(c) || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// isDigit проверяет, что байт — десятичная цифра
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
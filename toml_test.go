@@ -0,0 +1,151 @@
+package code
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenDiff_TOMLAgainstJSON(t *testing.T) {
+	dir := t.TempDir()
+	tomlFile := writeTestFile(t, dir, "config.toml", `# Service configuration
+title = "TOML Example"
+enabled = true
+ratio = 0.5
+hex = 0xff
+big = 1_000_000
+
+[owner]
+name = "Tom"
+dob = 1979-05-27T07:32:00-08:00
+
+[database]
+ports = [ 8000, 8001, 8002 ]
+data = [ ["delta", "phi"], [3.14] ]
+temp_targets = { cpu = 79.5, case = 72.0 }
+
+[servers.alpha]
+ip = "10.0.0.1"
+role = 'frontend'
+
+[servers.beta]
+ip = "10.0.0.2"
+"role.name" = """
+backend"""
+
+[[products]]
+name = "Hammer"
+sku = 738594937
+
+[[products]]
+name = "Nail"
+color.name = "gray"
+`)
+	jsonFile := writeTestFile(t, dir, "config.json", `{
+  "title": "TOML Example",
+  "enabled": true,
+  "ratio": 0.5,
+  "hex": 255,
+  "big": 1000000,
+  "owner": {"name": "Tom", "dob": "1979-05-27T07:32:00-08:00"},
+  "database": {
+    "ports": [8000, 8001, 8002],
+    "data": [["delta", "phi"], [3.14]],
+    "temp_targets": {"cpu": 79.5, "case": 72}
+  },
+  "servers": {
+    "alpha": {"ip": "10.0.0.1", "role": "frontend"},
+    "beta": {"ip": "10.0.0.2", "role.name": "backend"}
+  },
+  "products": [
+    {"name": "Hammer", "sku": 738594937},
+    {"name": "Nail", "color": {"name": "gray"}}
+  ]
+}`)
+
+	result, err := GenDiff(tomlFile, jsonFile, "plain")
+	require.NoError(t, err)
+	assert.Equal(t, "", result)
+
+	stats, err := GenDiffResult(tomlFile, jsonFile, "stylish", Options{})
+	require.NoError(t, err)
+	assert.Zero(t, stats.Stats.Changes())
+}
+
+func TestParseTOML_Values(t *testing.T) {
+	data, err := parseTOML([]byte(`
+str = "tab\there \u00e9"
+literal = 'C:\path'
+multi = """
+first \
+  second"""
+raw = '''
+line1
+line2'''
+neg = -17
+huge = 9_007_199_254_740_993
+oct = 0o755
+bin = 0b101
+exp = 5e+22
+inf = -inf
+nan = nan
+local_dt = 1979-05-27 07:32:00.500
+local_date = 1979-05-27
+local_time = 07:32:00
+utc = 1979-05-27t07:32:00z
+dotted.key = 1
+dotted.other = 2
+empty = []
+trailing = [
+  1, # comment
+  2,
+]
+`))
+	require.NoError(t, err)
+
+	assert.Equal(t, "tab\there é", data["str"])
+	assert.Equal(t, `C:\path`, data["literal"])
+	assert.Equal(t, "first second", data["multi"])
+	assert.Equal(t, "line1\nline2", data["raw"])
+	assert.Equal(t, -17.0, data["neg"])
+	assert.Equal(t, int64(9007199254740993), data["huge"])
+	assert.Equal(t, 493.0, data["oct"])
+	assert.Equal(t, 5.0, data["bin"])
+	assert.Equal(t, 5e22, data["exp"])
+	assert.True(t, math.IsInf(data["inf"].(float64), -1))
+	assert.True(t, math.IsNaN(data["nan"].(float64)))
+	assert.Equal(t, "1979-05-27T07:32:00.5", data["local_dt"])
+	assert.Equal(t, "1979-05-27", data["local_date"])
+	assert.Equal(t, "07:32:00", data["local_time"])
+	assert.Equal(t, "1979-05-27T07:32:00Z", data["utc"])
+	assert.Equal(t, map[string]interface{}{"key": 1.0, "other": 2.0}, data["dotted"])
+	assert.Equal(t, []interface{}{}, data["empty"])
+	assert.Equal(t, []interface{}{1.0, 2.0}, data["trailing"])
+}
+
+func TestParseTOML_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		message string
+	}{
+		{"duplicate key", "a = 1\na = 2", "line 2: key 'a' is defined more than once"},
+		{"duplicate table", "[a]\nx = 1\n[a]\ny = 2", "line 3: table 'a' is defined more than once"},
+		{"missing equals", "a 1", "line 1: expected '=' after key 'a'"},
+		{"unterminated string", `a = "abc`, "line 1: unterminated string"},
+		{"trailing garbage", "a = 1 b", `line 1: unexpected 'b' after value`},
+		{"not a table", "a = 1\n[a.b]", "line 2: key 'a' is not a table"},
+		{"static array", "a = []\n[[a]]", "line 2: cannot append to static array 'a'"},
+		{"invalid number", "a = 1__0", `line 1: invalid number "1__0"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseTOML([]byte(tt.content))
+			require.Error(t, err)
+			assert.Equal(t, "failed to parse TOML: "+tt.message, err.Error())
+		})
+	}
+}
@@ -0,0 +1,28 @@
+package code
+
+import (
+	"sort"
+	"strings"
+)
+
+// transform применяет к значению преобразователь, заданный для пути. Если путь
+// подходит под несколько шаблонов, используется первый по алфавиту.
+func (d *differ) transform(valuePath []string, value interface{}) interface{} {
+	if len(d.opts.ValueTransformers) == 0 {
+		return value
+	}
+
+	patterns := make([]string, 0, len(d.opts.ValueTransformers))
+	for pattern := range d.opts.ValueTransformers {
+		patterns = append(patterns, pattern)
+	}
+	sort.Strings(patterns)
+
+	for _, pattern := range patterns {
+		segments := strings.Split(pattern, ".")
+		if len(segments) == len(valuePath) && matchPathPrefix(segments, valuePath) {
+			return d.opts.ValueTransformers[pattern](value)
+		}
+	}
+	return value
+}
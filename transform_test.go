@@ -0,0 +1,45 @@
+package code
+
+import (
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenDiff_ValueTransformers(t *testing.T) {
+	sortList := func(v interface{}) interface{} {
+		s, ok := v.(string)
+		if !ok {
+			return v
+		}
+		items := strings.Split(s, ",")
+		for i := range items {
+			items[i] = strings.TrimSpace(items[i])
+		}
+		sort.Strings(items)
+		return strings.Join(items, ",")
+	}
+
+	content1 := `{"hosts": "b, a, c", "servers": {"web": {"roles": "db,app"}}, "other": "b,a"}`
+	content2 := `{"hosts": "a,b,c", "servers": {"web": {"roles": "app,db"}}, "other": "a,b"}`
+
+	result, err := GenDiffString(content1, content2, "json", "plain", Options{
+		ValueTransformers: map[string]func(interface{}) interface{}{
+			"hosts":           sortList,
+			"servers.*.roles": sortList,
+		},
+	})
+	require.NoError(t, err)
+	// Only the path without a transformer is reported
+	assert.Equal(t, "Property 'other' was updated. From 'b,a' to 'a,b'", result)
+
+	// Original values are kept in the output
+	stylish, err := GenDiffString(content1, content2, "json", "stylish", Options{
+		ValueTransformers: map[string]func(interface{}) interface{}{"hosts": sortList},
+	})
+	require.NoError(t, err)
+	assert.Contains(t, stylish, "    hosts: b, a, c")
+}
@@ -0,0 +1,102 @@
+package code
+
+import (
+	"sort"
+	"strings"
+)
+
+// Виды значений, по которым определяется смена типа
+const (
+	KindNull    = "null"
+	KindBool    = "boolean"
+	KindNumber  = "number"
+	KindString  = "string"
+	KindObject  = "object"
+	KindArray   = "array"
+	KindUnknown = "unknown"
+)
+
+// TypeChange описывает лист, у которого сменился тип значения
+type TypeChange struct {
+	Path     string
+	OldType  string
+	NewType  string
+	OldValue interface{}
+	NewValue interface{}
+}
+
+// TypeChanges возвращает листья, у которых вид значения различается в двух файлах
+// (например, "50" → 50), даже если логически значения совпадают. Типы сравниваются
+// по виду значения, а не по точному типу Go, чтобы int из YAML и float64 из JSON
+// не считались разными.
+func (n *Node) TypeChanges() []TypeChange {
+	var changes []TypeChange
+	collectNodeTypeChanges(n, nil, &changes)
+	sort.SliceStable(changes, func(i, j int) bool {
+		return changes[i].Path < changes[j].Path
+	})
+	return changes
+}
+
+// collectNodeTypeChanges обходит дерево и собирает смены типов
+func collectNodeTypeChanges(node *Node, nodePath []string, changes *[]TypeChange) {
+	for _, child := range node.Children {
+		childPath := appendPath(nodePath, child.name())
+		switch child.Type {
+		case NodeTypeUpdated:
+			collectTypeChanges(childPath, child.OldValue, child.NewValue, changes)
+		case NodeTypeUnchanged:
+			if child.rightValue != nil {
+				collectTypeChanges(childPath, child.Value, child.rightValue, changes)
+			}
+		case NodeTypeNested:
+			collectNodeTypeChanges(child, childPath, changes)
+		}
+	}
+}
+
+// collectTypeChanges сравнивает виды двух значений, спускаясь в общие ключи карт
+func collectTypeChanges(valuePath []string, a, b interface{}, changes *[]TypeChange) {
+	mapA, okA := a.(map[string]interface{})
+	mapB, okB := b.(map[string]interface{})
+	if okA && okB {
+		for _, key := range getSortedKeys(mapA) {
+			if valueB, exists := mapB[key]; exists {
+				collectTypeChanges(appendPath(valuePath, key), mapA[key], valueB, changes)
+			}
+		}
+		return
+	}
+
+	if kindA, kindB := valueKind(a), valueKind(b); kindA != kindB {
+		*changes = append(*changes, TypeChange{
+			Path:     strings.Join(valuePath, "."),
+			OldType:  kindA,
+			NewType:  kindB,
+			OldValue: a,
+			NewValue: b,
+		})
+	}
+}
+
+// valueKind возвращает вид значения
+func valueKind(v interface{}) string {
+	if v == nil {
+		return KindNull
+	}
+	if _, ok := toFloat(v); ok {
+		return KindNumber
+	}
+	switch v.(type) {
+	case bool:
+		return KindBool
+	case string:
+		return KindString
+	case map[string]interface{}:
+		return KindObject
+	case []interface{}:
+		return KindArray
+	default:
+		return KindUnknown
+	}
+}
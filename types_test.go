@@ -0,0 +1,82 @@
+package code
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTypeChanges(t *testing.T) {
+	file1 := createTempYAMLFile(t, `port: "8080"
+debug: "true"
+name: app
+replicas: 3
+limits:
+  cpu: "2"
+  memory: 512Mi
+timeout: 50`)
+	file2 := createTempFile(t, `{
+  "port": 8080,
+  "debug": true,
+  "name": "app",
+  "replicas": 3,
+  "limits": {"cpu": 2, "memory": "512Mi"},
+  "timeout": "60"
+}`)
+	defer func() {
+		if err := os.Remove(file1); err != nil {
+			t.Logf("failed to remove temp file %s: %v", file1, err)
+		}
+		if err := os.Remove(file2); err != nil {
+			t.Logf("failed to remove temp file %s: %v", file2, err)
+		}
+	}()
+
+	result, err := GenDiffResult(file1, file2, "stylish", Options{LooseTypes: true})
+	require.NoError(t, err)
+
+	// YAML int vs JSON float64 for replicas is not a type change
+	assert.Equal(t, []TypeChange{
+		{Path: "debug", OldType: KindString, NewType: KindBool, OldValue: "true", NewValue: true},
+		{Path: "limits.cpu", OldType: KindString, NewType: KindNumber, OldValue: "2", NewValue: 2.0},
+		{Path: "port", OldType: KindString, NewType: KindNumber, OldValue: "8080", NewValue: 8080.0},
+		{Path: "timeout", OldType: KindNumber, NewType: KindString, OldValue: 50, NewValue: "60"},
+	}, result.Tree.TypeChanges())
+
+	// In loose mode retyped but logically equal values still render as unchanged
+	assert.Contains(t, result.Output, "    port: 8080")
+
+	// By default a retyped value is a change, and it is still reported as a type change
+	result, err = GenDiffResult(file1, file2, "stylish", Options{})
+	require.NoError(t, err)
+	assert.Contains(t, result.Output, "  - port: 8080\n  + port: 8080")
+	assert.Len(t, result.Tree.TypeChanges(), 4)
+}
+
+func TestTypeChanges_NotInOutput(t *testing.T) {
+	content1 := `{"port": "50", "db": {"pool": "5"}}`
+	content2 := `{"port": 50, "db": {"pool": 5}}`
+
+	result, err := GenDiffString(content1, content2, "json", "json", Options{LooseTypes: true})
+	require.NoError(t, err)
+	assert.NotContains(t, result, "newValue")
+
+	result, err = GenDiffString(content1, content2, "json", "yaml", Options{LooseTypes: true})
+	require.NoError(t, err)
+	assert.NotContains(t, result, "newValue")
+
+	data1, err := parseJSON([]byte(content1))
+	require.NoError(t, err)
+	data2, err := parseJSON([]byte(content2))
+	require.NoError(t, err)
+	tree, _ := buildTree(data1, data2, Options{LooseTypes: true})
+	changes := tree.TypeChanges()
+	require.Len(t, changes, 2)
+	assert.Equal(t, "db.pool", changes[0].Path)
+	assert.Equal(t, "port", changes[1].Path)
+
+	// The inverted tree swaps the sides of retyped values
+	assert.Equal(t, "5", InvertDiff(tree).TypeChanges()[0].NewValue)
+}
@@ -0,0 +1,101 @@
+package code
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// unifiedLine — строка unified формата: маркер (" ", "-" или "+"), путь и значение
+type unifiedLine struct {
+	marker string
+	path   string
+	value  string
+}
+
+// formatUnified выводит различия в стиле diff -u: заголовок с именами файлов
+// (a и b для строковых входов), один блок @@ и по строке на каждый лист с полным
+// путём через точку без отступов. Вложенные объекты раскрываются до листьев,
+// строки сортируются по пути.
+func formatUnified(node *Node, opts Options) string {
+	var lines []unifiedLine
+	collectUnifiedLines(node, nil, &lines)
+	sort.SliceStable(lines, func(i, j int) bool {
+		return lines[i].path < lines[j].path
+	})
+
+	left, right := opts.files[0], opts.files[1]
+	if left == "" {
+		left = "a"
+	}
+	if right == "" {
+		right = "b"
+	}
+
+	oldCount, newCount := 0, 0
+	body := make([]string, 0, len(lines))
+	for _, line := range lines {
+		if line.marker != "+" {
+			oldCount++
+		}
+		if line.marker != "-" {
+			newCount++
+		}
+		body = append(body, fmt.Sprintf("%s%s: %s", line.marker, line.path, line.value))
+	}
+
+	header := []string{"--- " + left, "+++ " + right}
+	if len(body) == 0 {
+		return strings.Join(header, "\n")
+	}
+	header = append(header, fmt.Sprintf("@@ -1,%d +1,%d @@", oldCount, newCount))
+	return strings.Join(append(header, body...), "\n")
+}
+
+// collectUnifiedLines обходит дерево и собирает строки для каждого листа
+func collectUnifiedLines(node *Node, nodePath []string, lines *[]unifiedLine) {
+	for _, child := range node.Children {
+		childPath := appendPath(nodePath, child.name())
+		switch child.Type {
+		case NodeTypeNested:
+			collectUnifiedLines(child, childPath, lines)
+		case NodeTypeAdded:
+			appendUnifiedValue(lines, "+", childPath, child.NewValue)
+		case NodeTypeRemoved:
+			appendUnifiedValue(lines, "-", childPath, child.OldValue)
+		case NodeTypeUpdated:
+			appendUnifiedValue(lines, "-", childPath, child.OldValue)
+			appendUnifiedValue(lines, "+", childPath, child.NewValue)
+		case NodeTypeUnchanged:
+			appendUnifiedValue(lines, " ", childPath, child.Value)
+		}
+	}
+}
+
+// appendUnifiedValue добавляет строки для значения, раскрывая непустые объекты до листьев
+func appendUnifiedValue(lines *[]unifiedLine, marker string, valuePath []string, v interface{}) {
+	if m, ok := v.(map[string]interface{}); ok && len(m) > 0 {
+		for _, key := range getSortedKeys(m) {
+			appendUnifiedValue(lines, marker, appendPath(valuePath, key), m[key])
+		}
+		return
+	}
+	*lines = append(*lines, unifiedLine{marker: marker, path: strings.Join(valuePath, "."), value: unifiedValue(v)})
+}
+
+// unifiedValue форматирует значение листа. Строки с переводами строк и другими
+// управляющими символами записываются в JSON с экранированием, чтобы каждое
+// значение занимало ровно одну строку и счётчики в заголовке @@ оставались верными.
+func unifiedValue(v interface{}) string {
+	s, ok := v.(string)
+	if !ok || !strings.ContainsFunc(s, unicode.IsControl) {
+		return rowValue(v)
+	}
+	data, err := json.Marshal(s)
+	if err != nil {
+		return rowValue(v)
+	}
+	return string(data)
+}
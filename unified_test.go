@@ -0,0 +1,72 @@
+package code
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenDiff_Unified(t *testing.T) {
+	content1 := `{
+		"host": "hexlet.io",
+		"timeout": 50,
+		"common": {"follow": false, "setting": {"a": 1, "b": 2}},
+		"proxy": {"addr": "1.2.3.4", "port": 3128}
+	}`
+	content2 := `{
+		"host": "hexlet.io",
+		"timeout": 20,
+		"common": {"follow": false, "setting": "none"},
+		"verbose": true,
+		"tags": ["a", "b"]
+	}`
+
+	expected := `--- a
++++ b
+@@ -1,7 +1,6 @@
+ common.follow: false
++common.setting: none
+-common.setting.a: 1
+-common.setting.b: 2
+ host: hexlet.io
+-proxy.addr: 1.2.3.4
+-proxy.port: 3128
++tags: ["a","b"]
+-timeout: 50
++timeout: 20
++verbose: true`
+
+	result, err := GenDiffString(content1, content2, "json", "unified", Options{})
+	require.NoError(t, err)
+	assert.Equal(t, expected, result)
+}
+
+func TestGenDiff_UnifiedMultilineValues(t *testing.T) {
+	content1 := `{"script": "echo a\necho b", "name": "job"}`
+	content2 := `{"script": "echo a\r\necho c\t# tab", "name": "job"}`
+
+	result, err := GenDiffString(content1, content2, "json", "unified", Options{})
+	require.NoError(t, err)
+	expected := `--- a
++++ b
+@@ -1,2 +1,2 @@
+ name: job
+-script: "echo a\necho b"
++script: "echo a\r\necho c\t# tab"`
+	assert.Equal(t, expected, result)
+}
+
+func TestGenDiff_UnifiedFileNames(t *testing.T) {
+	dir := t.TempDir()
+	file1 := writeTestFile(t, dir, "file1.json", `{"a": 1}`)
+	file2 := writeTestFile(t, dir, "file2.json", `{"a": 1}`)
+
+	result, err := GenDiffResult(file1, file2, "unified", Options{})
+	require.NoError(t, err)
+	assert.Equal(t, "--- "+file1+"\n+++ "+file2+"\n@@ -1,1 +1,1 @@\n a: 1", result.Output)
+
+	empty, err := GenDiffString(`{}`, `{}`, "json", "unified", Options{})
+	require.NoError(t, err)
+	assert.Equal(t, "--- a\n+++ b", empty)
+}
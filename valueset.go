@@ -0,0 +1,62 @@
+package code
+
+import (
+	"fmt"
+	"sort"
+)
+
+// buildValueSetTree строит дерево, описывающее разницу мультимножеств скалярных значений.
+// Ключи и пути игнорируются: ключом узла служит само значение, а значением —
+// количество появившихся или исчезнувших вхождений.
+func buildValueSetTree(data1, data2 map[string]interface{}) *Node {
+	root := &Node{Type: NodeTypeRoot, Children: []*Node{}}
+
+	counts1 := make(map[string]int)
+	counts2 := make(map[string]int)
+	collectLeafValues(data1, counts1)
+	collectLeafValues(data2, counts2)
+
+	allValues := make(map[string]bool)
+	for value := range counts1 {
+		allValues[value] = true
+	}
+	for value := range counts2 {
+		allValues[value] = true
+	}
+
+	values := make([]string, 0, len(allValues))
+	for value := range allValues {
+		values = append(values, value)
+	}
+	sort.Strings(values)
+
+	for _, value := range values {
+		delta := counts2[value] - counts1[value]
+		switch {
+		case delta > 0:
+			root.Children = append(root.Children, &Node{Type: NodeTypeAdded, Key: value, NewValue: delta})
+		case delta < 0:
+			root.Children = append(root.Children, &Node{Type: NodeTypeRemoved, Key: value, OldValue: -delta})
+		}
+	}
+
+	return root
+}
+
+// collectLeafValues рекурсивно подсчитывает скалярные значения во вложенных картах и массивах
+func collectLeafValues(v interface{}, counts map[string]int) {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for _, item := range val {
+			collectLeafValues(item, counts)
+		}
+	case []interface{}:
+		for _, item := range val {
+			collectLeafValues(item, counts)
+		}
+	case nil:
+		counts[NullValue]++
+	default:
+		counts[fmt.Sprintf("%v", val)]++
+	}
+}
@@ -0,0 +1,44 @@
+package code
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenDiff_ValueSetDiff_MovedValue(t *testing.T) {
+	// The same values live under different keys
+	file1 := createTempFile(t, `{"host":"hexlet.io","nested":{"port":8080}}`)
+	file2 := createTempFile(t, `{"server":{"name":"hexlet.io"},"port":8080}`)
+	defer func() {
+		if err := os.Remove(file1); err != nil {
+			t.Logf("failed to remove temp file %s: %v", file1, err)
+		}
+		if err := os.Remove(file2); err != nil {
+			t.Logf("failed to remove temp file %s: %v", file2, err)
+		}
+	}()
+
+	result, err := GenDiffWithOptions(file1, file2, "stylish", Options{ValueSetDiff: true})
+	require.NoError(t, err)
+	assert.Equal(t, "{\n}", result)
+}
+
+func TestGenDiff_ValueSetDiff_ChangedValue(t *testing.T) {
+	file1 := createTempFile(t, `{"host":"hexlet.io","timeout":50}`)
+	file2 := createTempFile(t, `{"host":"hexlet.io","limits":{"timeout":20}}`)
+	defer func() {
+		if err := os.Remove(file1); err != nil {
+			t.Logf("failed to remove temp file %s: %v", file1, err)
+		}
+		if err := os.Remove(file2); err != nil {
+			t.Logf("failed to remove temp file %s: %v", file2, err)
+		}
+	}()
+
+	result, err := GenDiffWithOptions(file1, file2, "stylish", Options{ValueSetDiff: true})
+	require.NoError(t, err)
+	assert.Equal(t, "{\n  + 20: 1\n  - 50: 1\n}", result)
+}
@@ -0,0 +1,32 @@
+package code
+
+import "errors"
+
+// ErrStopWalk возвращается из функции обхода, чтобы остановить Walk; сам Walk
+// в этом случае возвращает nil
+var ErrStopWalk = errors.New("stop walk")
+
+// Walk обходит дерево различий в глубину, начиная с самого узла, и вызывает fn
+// для каждого узла с путём от корня (ключи карт и индексы элементов массивов;
+// для корня путь пустой). Дочерние узлы посещаются в порядке дерева. Ошибка fn
+// прерывает обход и возвращается из Walk, кроме ErrStopWalk.
+func Walk(node *Node, fn func(n *Node, path []string) error) error {
+	err := walkNode(node, nil, fn)
+	if errors.Is(err, ErrStopWalk) {
+		return nil
+	}
+	return err
+}
+
+// walkNode посещает узел и рекурсивно его потомков
+func walkNode(node *Node, nodePath []string, fn func(n *Node, path []string) error) error {
+	if err := fn(node, nodePath); err != nil {
+		return err
+	}
+	for _, child := range node.Children {
+		if err := walkNode(child, appendPath(nodePath, child.name()), fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
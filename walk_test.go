@@ -0,0 +1,57 @@
+package code
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWalk(t *testing.T) {
+	tree := BuildDiff(
+		map[string]interface{}{"host": "a", "db": map[string]interface{}{"user": "x", "ssl": map[string]interface{}{"mode": "on"}}},
+		map[string]interface{}{"host": "b", "db": map[string]interface{}{"user": "x", "ssl": map[string]interface{}{"mode": "off"}}, "debug": true},
+	)
+
+	var paths []string
+	err := Walk(tree, func(n *Node, path []string) error {
+		paths = append(paths, strings.Join(path, ".")+":"+n.Type)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		":root",
+		"db:nested",
+		"db.ssl:nested",
+		"db.ssl.mode:updated",
+		"db.user:unchanged",
+		"debug:added",
+		"host:updated",
+	}, paths)
+
+	t.Run("stop early", func(t *testing.T) {
+		var visited []string
+		err := Walk(tree, func(n *Node, path []string) error {
+			visited = append(visited, strings.Join(path, "."))
+			if n.Type == NodeTypeUpdated {
+				return ErrStopWalk
+			}
+			return nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"", "db", "db.ssl", "db.ssl.mode"}, visited)
+	})
+
+	t.Run("error is returned", func(t *testing.T) {
+		boom := errors.New("boom")
+		err := Walk(tree, func(n *Node, path []string) error {
+			if n.Type == NodeTypeAdded {
+				return boom
+			}
+			return nil
+		})
+		assert.ErrorIs(t, err, boom)
+	})
+}
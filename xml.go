@@ -0,0 +1,93 @@
+package code
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// xmlTextKey — ключ, под которым сохраняется текст элемента, имеющего атрибуты
+// или дочерние элементы
+const xmlTextKey = "#text"
+
+// parseXML парсит XML содержимое. Корневой элемент становится единственным ключом
+// верхнего уровня. Дочерние элементы становятся вложенными картами, атрибуты — ключами
+// с префиксом "@", а повторяющиеся соседние элементы с одним тегом — массивом.
+// Элемент только с текстом становится строкой; при смешанном содержимом текстовые
+// фрагменты без пробелов по краям склеиваются через пробел и хранятся под ключом "#text".
+func parseXML(content []byte) (map[string]interface{}, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(content))
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			return nil, errors.New("failed to parse XML: no root element")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse XML: %w", err)
+		}
+
+		if start, ok := token.(xml.StartElement); ok {
+			value, err := xmlElement(decoder, start)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse XML: %w", err)
+			}
+			return map[string]interface{}{start.Name.Local: value}, nil
+		}
+	}
+}
+
+// xmlElement читает содержимое элемента до его закрывающего тега
+func xmlElement(decoder *xml.Decoder, start xml.StartElement) (interface{}, error) {
+	element := make(map[string]interface{})
+	for _, attr := range start.Attr {
+		element["@"+attr.Name.Local] = attr.Value
+	}
+
+	var texts []string
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+
+		switch tok := token.(type) {
+		case xml.StartElement:
+			child, err := xmlElement(decoder, tok)
+			if err != nil {
+				return nil, err
+			}
+			addRepeated(element, tok.Name.Local, child)
+		case xml.CharData:
+			if text := strings.TrimSpace(string(tok)); text != "" {
+				texts = append(texts, text)
+			}
+		case xml.EndElement:
+			text := strings.Join(texts, " ")
+			if len(element) == 0 {
+				return text, nil
+			}
+			if text != "" {
+				element[xmlTextKey] = text
+			}
+			return element, nil
+		}
+	}
+}
+
+// addRepeated добавляет значение под ключом; повторяющиеся ключи (соседние XML
+// элементы, HCL блоки одного типа) собираются в массив
+func addRepeated(element map[string]interface{}, name string, child interface{}) {
+	existing, ok := element[name]
+	if !ok {
+		element[name] = child
+		return
+	}
+	if items, isSlice := existing.([]interface{}); isSlice {
+		element[name] = append(items, child)
+		return
+	}
+	element[name] = []interface{}{existing, child}
+}
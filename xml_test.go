@@ -0,0 +1,61 @@
+package code
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenDiff_XML(t *testing.T) {
+	dir := t.TempDir()
+	file1 := writeTestFile(t, dir, "file1.xml", `<?xml version="1.0" encoding="UTF-8"?>
+<config version="1">
+  <server host="hexlet.io" port="8080"/>
+  <timeout>50</timeout>
+  <proxy>123.234.53.22</proxy>
+</config>
+`)
+	file2 := writeTestFile(t, dir, "file2.xml", `<config version="1">
+  <server host="hexlet.io" port="9090"/>
+  <timeout>50</timeout>
+</config>
+`)
+
+	result, err := GenDiff(file1, file2, "plain")
+	require.NoError(t, err)
+	assert.Equal(t, `Property 'config.proxy' was removed
+Property 'config.server.@port' was updated. From '8080' to '9090'`, result)
+}
+
+func TestParseXML(t *testing.T) {
+	data, err := parseXML([]byte(`<root id="r">
+  <item>a</item>
+  <item>b</item>
+  <item>c</item>
+  <empty/>
+  <mixed kind="m">hello <b>world</b> again</mixed>
+</root>`))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"root": map[string]interface{}{
+			"@id":   "r",
+			"item":  []interface{}{"a", "b", "c"},
+			"empty": "",
+			"mixed": map[string]interface{}{
+				"@kind": "m",
+				"b":     "world",
+				"#text": "hello again",
+			},
+		},
+	}, data)
+}
+
+func TestParseXML_Errors(t *testing.T) {
+	_, err := parseXML([]byte(`<?xml version="1.0"?>`))
+	assert.EqualError(t, err, "failed to parse XML: no root element")
+
+	_, err = parseXML([]byte(`<root><open></root>`))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to parse XML")
+}
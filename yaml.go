@@ -0,0 +1,185 @@
+package code
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// yamlIncludeTag — тег, которым в YAML подключается другой файл: key: !include other.yaml
+const yamlIncludeTag = "!include"
+
+// yamlDecoder преобразует узлы YAML, накапливая предупреждения. Пути в !include
+// разрешаются относительно файла, который сейчас разбирается.
+type yamlDecoder struct {
+	warnings []string
+	// file — путь к разбираемому локальному файлу; пустой для содержимого из сети,
+	// источника, строки или stdin, где !include запрещён
+	file string
+	// stack — цепочка подключённых файлов для обнаружения циклов
+	stack []string
+}
+
+// nodeValue преобразует узел YAML в значения, с которыми работает построитель дерева:
+// карты становятся map[string]interface{}, последовательности — []interface{}.
+// Повторяющиеся ключи записываются в warnings, при этом побеждает последнее значение.
+// Скаляры с тегом !include заменяются содержимым подключённого файла.
+func (d *yamlDecoder) nodeValue(node *yaml.Node) (interface{}, error) {
+	switch node.Kind {
+	case 0:
+		// Пустой документ
+		return nil, nil
+	case yaml.DocumentNode:
+		if len(node.Content) == 0 {
+			return nil, nil
+		}
+		return d.nodeValue(node.Content[0])
+	case yaml.AliasNode:
+		return d.nodeValue(node.Alias)
+	case yaml.SequenceNode:
+		items := make([]interface{}, 0, len(node.Content))
+		for _, item := range node.Content {
+			value, err := d.nodeValue(item)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, value)
+		}
+		return items, nil
+	case yaml.MappingNode:
+		return d.mappingValue(node)
+	case yaml.ScalarNode:
+		if node.Tag == yamlIncludeTag {
+			return d.include(node)
+		}
+		fallthrough
+	default:
+		var value interface{}
+		if err := node.Decode(&value); err != nil {
+			return nil, err
+		}
+		return value, nil
+	}
+}
+
+// mappingValue преобразует отображение YAML в карту, раскрывая ключи слияния (<<)
+func (d *yamlDecoder) mappingValue(node *yaml.Node) (map[string]interface{}, error) {
+	result := make(map[string]interface{}, len(node.Content)/2)
+	var merged []map[string]interface{}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		keyNode, valueNode := node.Content[i], node.Content[i+1]
+
+		value, err := d.nodeValue(valueNode)
+		if err != nil {
+			return nil, err
+		}
+
+		// Ключ слияния подмешивает карты, не перекрывая явно заданные ключи
+		if keyNode.Tag == "!!merge" {
+			switch val := value.(type) {
+			case map[string]interface{}:
+				merged = append(merged, val)
+			case []interface{}:
+				for _, item := range val {
+					if m, ok := item.(map[string]interface{}); ok {
+						merged = append(merged, m)
+					}
+				}
+			}
+			continue
+		}
+
+		if _, exists := result[keyNode.Value]; exists {
+			d.warnings = append(d.warnings, fmt.Sprintf("duplicate key '%s' at line %d", keyNode.Value, keyNode.Line))
+		}
+		result[keyNode.Value] = value
+	}
+
+	for _, m := range merged {
+		for key, value := range m {
+			if _, exists := result[key]; !exists {
+				result[key] = value
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// yamlDocuments разбирает все документы потока YAML; пустые документы (например,
+// после завершающего "---") пропускаются
+func yamlDocuments(content []byte) ([]*yaml.Node, error) {
+	var documents []*yaml.Node
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	for {
+		var document yaml.Node
+		err := decoder.Decode(&document)
+		if errors.Is(err, io.EOF) {
+			return documents, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(document.Content) == 0 || document.Content[0].Tag == "!!null" {
+			continue
+		}
+		documents = append(documents, &document)
+	}
+}
+
+// include читает файл, указанный в теге !include, и возвращает его содержимое.
+// JSON-файлы разбираются как JSON, остальные — как YAML с поддержкой вложенных !include.
+func (d *yamlDecoder) include(node *yaml.Node) (interface{}, error) {
+	// Иначе загруженный по сети документ мог бы прочитать любой локальный файл
+	if d.file == "" {
+		return nil, fmt.Errorf("!include %s at line %d: includes are only allowed in local files", node.Value, node.Line)
+	}
+
+	includePath := node.Value
+	if !filepath.IsAbs(includePath) {
+		includePath = filepath.Join(filepath.Dir(d.file), includePath)
+	}
+
+	absPath, err := filepath.Abs(includePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve include %s: %w", node.Value, err)
+	}
+	for _, visited := range d.stack {
+		if visited == absPath {
+			return nil, fmt.Errorf("include cycle: %s", strings.Join(append(d.stack, absPath), " -> "))
+		}
+	}
+
+	// nolint:gosec // Подключаются только конфигурационные файлы
+	content, err := os.ReadFile(includePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to include %s at line %d: %w", node.Value, node.Line, err)
+	}
+
+	if strings.ToLower(filepath.Ext(includePath)) == ".json" {
+		var value interface{}
+		if err := unmarshalJSON(content, &value); err != nil {
+			return nil, fmt.Errorf("failed to include %s: %w", node.Value, err)
+		}
+		return normalizeJSONNumbers(value), nil
+	}
+
+	var document yaml.Node
+	if err := yaml.Unmarshal(content, &document); err != nil {
+		return nil, fmt.Errorf("failed to include %s: %w", node.Value, err)
+	}
+	nested := &yamlDecoder{file: includePath, stack: append(append([]string{}, d.stack...), absPath)}
+	value, err := nested.nodeValue(&document)
+	if err != nil {
+		return nil, err
+	}
+	d.warnings = append(d.warnings, prefixWarnings(includePath, nested.warnings)...)
+	return value, nil
+}
@@ -0,0 +1,134 @@
+package code
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func writeTestFile(t *testing.T, dir, name, content string) string {
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	return path
+}
+
+func TestGenDiff_YAMLInclude(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, dir, "v1/db.yaml", "host: localhost\nport: 5432\n")
+	writeTestFile(t, dir, "v2/db.yaml", "host: localhost\nport: 6432\n")
+	writeTestFile(t, dir, "v2/shared/limits.json", `{"cpu": 2}`)
+	file1 := writeTestFile(t, dir, "v1/app.yaml", "name: app\ndb: !include db.yaml\n")
+	file2 := writeTestFile(t, dir, "v2/app.yaml", "name: app\ndb: !include db.yaml\nlimits: !include shared/limits.json\n")
+
+	result, err := GenDiff(file1, file2, "plain")
+	require.NoError(t, err)
+	assert.Equal(t, "Property 'db.port' was updated. From 5432 to 6432\nProperty 'limits' was added with value: [complex value]", result)
+}
+
+func TestGenDiff_YAMLIncludeCycle(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, dir, "a.yaml", "b: !include b.yaml\n")
+	writeTestFile(t, dir, "b.yaml", "a: !include a.yaml\n")
+	file1 := writeTestFile(t, dir, "main.yaml", "root: !include a.yaml\n")
+	file2 := writeTestFile(t, dir, "other.yaml", "root: 1\n")
+
+	_, err := GenDiff(file1, file2, "stylish")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "include cycle:")
+	assert.Contains(t, err.Error(), "a.yaml -> ")
+}
+
+func TestGenDiff_YAMLIncludeOnlyInLocalFiles(t *testing.T) {
+	secret := writeTestFile(t, t.TempDir(), "secret.yaml", "password: hunter2\n")
+	content := "name: app\nleak: !include " + secret + "\n"
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(content))
+	}))
+	defer server.Close()
+	local := writeTestFile(t, t.TempDir(), "app.yaml", "name: app\n")
+
+	_, err := GenDiff(local, server.URL+"/app.yaml", "plain")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "includes are only allowed in local files")
+
+	_, err = GenDiffString("name: app\n", content, "yaml", "plain", Options{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "includes are only allowed in local files")
+
+	_, err = GenDiffReader(strings.NewReader("name: app\n"), strings.NewReader(content), "yaml", "yaml", "plain", Options{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "includes are only allowed in local files")
+}
+
+func TestGenDiff_YAMLOutput(t *testing.T) {
+	content1 := `{"host": "hexlet.io", "timeout": 50, "common": {"follow": false}, "big": 9007199254740993}`
+	content2 := `{"host": "hexlet.io", "timeout": 20, "common": {"follow": true}, "verbose": true, "big": 9007199254740993}`
+
+	result, err := GenDiffString(content1, content2, "json", "yaml", Options{})
+	require.NoError(t, err)
+
+	var tree Node
+	require.NoError(t, yaml.Unmarshal([]byte(result), &tree))
+	assert.Equal(t, NodeTypeRoot, tree.Type)
+	require.Len(t, tree.Children, 5)
+
+	assert.Equal(t, "big", tree.Children[0].Key)
+	assert.Equal(t, 9007199254740993, tree.Children[0].Value)
+	assert.Equal(t, NodeTypeNested, tree.Children[1].Type)
+	assert.Equal(t, &Node{Type: NodeTypeUpdated, Key: "follow", OldValue: false, NewValue: true}, tree.Children[1].Children[0])
+	assert.Equal(t, &Node{Type: NodeTypeUpdated, Key: "timeout", OldValue: 50, NewValue: 20}, tree.Children[3])
+	assert.Contains(t, result, "    oldValue: 50\n    newValue: 20")
+
+	empty, err := GenDiffString(`{}`, `{}`, "json", "yaml", Options{})
+	require.NoError(t, err)
+	assert.Equal(t, "type: root\nchildren: []", empty)
+}
+
+func TestGenDiff_YAMLMultipleDocuments(t *testing.T) {
+	dir := t.TempDir()
+	file1 := writeTestFile(t, dir, "manifests1.yaml", `---
+kind: Service
+metadata:
+  name: web
+---
+kind: Deployment
+spec:
+  replicas: 2
+`)
+	file2 := writeTestFile(t, dir, "manifests2.yaml", `kind: Service
+metadata:
+  name: web
+---
+kind: Deployment
+spec:
+  replicas: 3
+---
+`)
+
+	t.Run("error by default", func(t *testing.T) {
+		_, err := GenDiff(file1, file2, "plain")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "found 2 documents separated by '---'")
+	})
+
+	t.Run("indexed documents", func(t *testing.T) {
+		result, err := GenDiffWithOptions(file1, file2, "plain", Options{IndexYAMLDocuments: true})
+		require.NoError(t, err)
+		assert.Equal(t, "Property 'doc1.spec.replicas' was updated. From 2 to 3", result)
+	})
+
+	t.Run("single document is unaffected", func(t *testing.T) {
+		result, err := GenDiffString("---\na: 1\n", "a: 2\n---\n", "yaml", "plain", Options{})
+		require.NoError(t, err)
+		assert.Equal(t, "Property 'a' was updated. From 1 to 2", result)
+	})
+}